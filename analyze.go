@@ -0,0 +1,27 @@
+package jpegmetawebstrip
+
+import "time"
+
+// Analyze reports what Strip would remove from jpegData with the same Result accounting
+// (per-category bytes and Total) without writing any output, e.g. to estimate the savings
+// of a bucket before rewriting it. The output is not validated, so FellBack is never set.
+func Analyze(jpegData []byte, opts ...Option) (*Result, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		o.deadline = time.Now().Add(o.timeout)
+	}
+	return analyze(jpegData, o)
+}
+
+// analyze parses jpegData and runs the segment cleaning without writing output
+func analyze(jpegData []byte, o *options) (*Result, error) {
+	sl, err := parseSegmentList(jpegData)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{OriginalSize: int64(len(jpegData))}
+	if _, err := cleanSegments(sl.Segments(), result, o); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
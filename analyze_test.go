@@ -0,0 +1,37 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			jpegData, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			_, expected, err := Strip(jpegData, WithKeepSoftware())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			result, err := Analyze(jpegData, WithKeepSoftware())
+			if err != nil {
+				t.Fatalf("Analyze failed: %v", err)
+			}
+			if result.Removed != expected.Removed || result.Total != expected.Total || result.OriginalSize != expected.OriginalSize {
+				t.Errorf("Expected the accounting of Strip %+v, got %+v", expected, result)
+			}
+		})
+	}
+
+	if _, err := Analyze([]byte("not a jpeg")); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+}
@@ -0,0 +1,178 @@
+package jpegmetawebstrip
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ArchiveResult summarizes the JPEG entries processed in a container
+type ArchiveResult struct {
+	// Entries is the number of entries copied to the output
+	Entries int
+	// Stripped is the number of JPEG entries whose metadata was removed
+	Stripped int
+	// Total is the number of bytes removed across all JPEG entries
+	Total int64
+	// Failed lists the JPEG entries that could not be stripped and were copied unchanged,
+	// metadata included, so callers can reject or quarantine the container
+	Failed []FailedEntry
+}
+
+// FailedEntry is a JPEG entry of a container that was copied without being stripped
+type FailedEntry struct {
+	// Name is the entry name, or for mail the part's file name or media type
+	Name string
+	// Err is why the entry could not be stripped
+	Err error
+}
+
+// fail records an entry that is copied unchanged
+func (r *ArchiveResult) fail(name string, err error) {
+	r.Failed = append(r.Failed, FailedEntry{Name: name, Err: err})
+}
+
+// stripEntry strips a JPEG entry body, returning the original data and recording the entry
+// in result.Failed when it cannot be cleaned
+func stripEntry(name string, data []byte, result *ArchiveResult, opts []Option) []byte {
+	cleaned, r, err := Strip(data, opts...)
+	if err == nil && r.FellBack {
+		err = fmt.Errorf("output validation failed: %s", r.FallbackReason)
+	}
+	if err != nil {
+		result.fail(name, err)
+		return data
+	}
+	if r.Total > 0 {
+		result.Stripped++
+		result.Total += int64(len(data) - len(cleaned))
+	}
+	return cleaned
+}
+
+// StripArchive rewrites a zip-based container (EPUB, DOCX, a plain zip of photos) to w,
+// stripping every JPEG entry. Other entries are copied without recompression, so entry
+// order, names, timestamps, comments and the stored "mimetype" entry of EPUB survive.
+// JPEG entries that cannot be stripped are copied unchanged and listed in ArchiveResult.Failed.
+func StripArchive(r io.ReaderAt, size int64, w io.Writer, opts ...Option) (*ArchiveResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	result := &ArchiveResult{}
+	zw := zip.NewWriter(w)
+	for _, f := range zr.File {
+		if !IsJPEGName(f.Name) || f.FileInfo().IsDir() {
+			if err := zw.Copy(f); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+			result.Entries++
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		header := f.FileHeader
+		header.Extra = withoutZip64Extra(header.Extra)
+		fw, err := zw.CreateHeader(&header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", f.Name, err)
+		}
+		if _, err := fw.Write(stripEntry(f.Name, data, result, opts)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.Name, err)
+		}
+		result.Entries++
+	}
+
+	if err := zw.SetComment(zr.Comment); err != nil {
+		return nil, fmt.Errorf("failed to set archive comment: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return result, nil
+}
+
+// readZipFile reads and decompresses a zip entry
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// withoutZip64Extra drops the zip64 extended information field, whose sizes no longer
+// apply once an entry is rewritten; the writer adds a fresh one when needed
+func withoutZip64Extra(extra []byte) []byte {
+	out := make([]byte, 0, len(extra))
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if 4+size > len(extra) {
+			break
+		}
+		if id != 0x0001 {
+			out = append(out, extra[:4+size]...)
+		}
+		extra = extra[4+size:]
+	}
+	return out
+}
+
+// StripTar copies a tar stream from r to w, stripping .jpg/.jpeg entries on the fly.
+// Only one JPEG entry is buffered at a time; other entries are streamed through unchanged.
+func StripTar(r io.Reader, w io.Writer, opts ...Option) (*ArchiveResult, error) {
+	result := &ArchiveResult{}
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !IsJPEGName(header.Name) {
+			if err := tw.WriteHeader(header); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", header.Name, err)
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", header.Name, err)
+			}
+			result.Entries++
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		data = stripEntry(header.Name, data, result, opts)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", header.Name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", header.Name, err)
+		}
+		result.Entries++
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish tar: %w", err)
+	}
+	return result, nil
+}
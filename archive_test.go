@@ -0,0 +1,187 @@
+package jpegmetawebstrip
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStripArchive(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	modified := time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)
+	entries := []testZipEntry{
+		{"mimetype", zip.Store, []byte("application/epub+zip")},
+		{"OEBPS/images/photo.JPG", zip.Deflate, jpegData},
+		{"OEBPS/chapter.xhtml", zip.Deflate, []byte("<html/>")},
+		{"OEBPS/images/broken.jpg", zip.Deflate, []byte("not really a jpeg")},
+	}
+	input := buildTestZip(t, entries, modified)
+
+	output := new(bytes.Buffer)
+	result, err := StripArchive(bytes.NewReader(input), int64(len(input)), output)
+	if err != nil {
+		t.Fatalf("StripArchive failed: %v", err)
+	}
+	if result.Entries != len(entries) || result.Stripped != 1 {
+		t.Errorf("Expected %d entries with 1 stripped, got %+v", len(entries), result)
+	}
+	if result.Total != int64(len(jpegData)-len(expected)) {
+		t.Errorf("Expected %d bytes removed, got %d", len(jpegData)-len(expected), result.Total)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Name != "OEBPS/images/broken.jpg" || result.Failed[0].Err == nil {
+		t.Errorf("Expected the broken entry to be reported as failed, got %+v", result.Failed)
+	}
+
+	assertZipEntries(t, output.Bytes(), entries, modified, map[string][]byte{"OEBPS/images/photo.JPG": expected})
+}
+
+// testZipEntry is an entry written by buildTestZip
+type testZipEntry struct {
+	name   string
+	method uint16
+	data   []byte
+}
+
+// buildTestZip writes entries with the modified time and comments assertZipEntries expects
+func buildTestZip(t *testing.T, entries []testZipEntry, modified time.Time) []byte {
+	input := new(bytes.Buffer)
+	zw := zip.NewWriter(input)
+	for _, entry := range entries {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: entry.method, Modified: modified, Comment: "entry comment"})
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		fw.Write(entry.data)
+	}
+	zw.SetComment("archive comment")
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return input.Bytes()
+}
+
+// assertZipEntries checks that a zip written from buildTestZip input kept its headers and
+// comments and holds the original data, or the stripped data for the names in stripped
+func assertZipEntries(t *testing.T, output []byte, entries []testZipEntry, modified time.Time, stripped map[string][]byte) {
+	zr, err := zip.NewReader(bytes.NewReader(output), int64(len(output)))
+	if err != nil {
+		t.Fatalf("Output is not a valid zip: %v", err)
+	}
+	if zr.Comment != "archive comment" {
+		t.Errorf("Expected archive comment to survive, got %q", zr.Comment)
+	}
+	if len(zr.File) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(zr.File))
+	}
+	for i, f := range zr.File {
+		entry := entries[i]
+		if f.Name != entry.name || f.Method != entry.method || f.Comment != "entry comment" || !f.Modified.Equal(modified) {
+			t.Errorf("Entry %d header changed: %s method=%d comment=%q modified=%v", i, f.Name, f.Method, f.Comment, f.Modified)
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		want, ok := stripped[entry.name]
+		if !ok {
+			want = entry.data
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("Entry %s has unexpected content", f.Name)
+		}
+	}
+}
+
+func TestStripArchiveInvalid(t *testing.T) {
+	data := []byte("not a zip")
+	if _, err := StripArchive(bytes.NewReader(data), int64(len(data)), new(bytes.Buffer)); err == nil {
+		t.Error("Expected error for invalid archive")
+	}
+}
+
+func TestStripTar(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	entries := []testTarEntry{
+		{&tar.Header{Name: "photos/", Typeflag: tar.TypeDir, Mode: 0o755}, nil},
+		{&tar.Header{Name: "photos/a.jpeg", Typeflag: tar.TypeReg, Mode: 0o640, Uname: "alice"}, jpegData},
+		{&tar.Header{Name: "photos/notes.txt", Typeflag: tar.TypeReg, Mode: 0o644}, []byte("hello")},
+		{&tar.Header{Name: "photos/link.jpg", Typeflag: tar.TypeSymlink, Linkname: "a.jpeg"}, nil},
+	}
+
+	input := new(bytes.Buffer)
+	tw := tar.NewWriter(input)
+	for _, entry := range entries {
+		entry.header.Size = int64(len(entry.data))
+		if err := tw.WriteHeader(entry.header); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		tw.Write(entry.data)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+
+	output := new(bytes.Buffer)
+	result, err := StripTar(input, output)
+	if err != nil {
+		t.Fatalf("StripTar failed: %v", err)
+	}
+	if result.Entries != len(entries) || result.Stripped != 1 || result.Total != int64(len(jpegData)-len(expected)) {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	assertTarEntries(t, output, entries, map[string][]byte{"photos/a.jpeg": expected})
+}
+
+// testTarEntry is an entry of a tar test input
+type testTarEntry struct {
+	header *tar.Header
+	data   []byte
+}
+
+// assertTarEntries checks that output holds entries in order with their headers, and the
+// original data or the stripped data for the names in stripped
+func assertTarEntries(t *testing.T, output io.Reader, entries []testTarEntry, stripped map[string][]byte) {
+	tr := tar.NewReader(output)
+	for i, entry := range entries {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read entry %d: %v", i, err)
+		}
+		data, _ := io.ReadAll(tr)
+		want, ok := stripped[header.Name]
+		if !ok {
+			want = entry.data
+		}
+		if header.Mode != entry.header.Mode || header.Uname != entry.header.Uname {
+			t.Errorf("Expected header fields to survive, got mode=%o uname=%q", header.Mode, header.Uname)
+		}
+		if header.Name != entry.header.Name || header.Typeflag != entry.header.Typeflag || !bytes.Equal(data, want) {
+			t.Errorf("Entry %d mismatch: %s type=%c size=%d", i, header.Name, header.Typeflag, len(data))
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("Expected end of archive, got %v", err)
+	}
+}
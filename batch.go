@@ -0,0 +1,119 @@
+package jpegmetawebstrip
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// FileResult is the outcome of stripping one file in BatchStrip
+type FileResult struct {
+	// Path is the file as given to BatchStrip
+	Path string
+	// Result is the Strip result, or nil when Err is set
+	Result *Result
+	// Err is the error that stopped this file; the file is left as it was
+	Err error
+	// InputSHA256 and OutputSHA256 are the hex digests of the file before and after
+	// stripping, recorded by Manifest.AddBatch; they are empty when Err is set
+	InputSHA256  string
+	OutputSHA256 string
+}
+
+// BatchStrip strips the JPEG files at paths in place using up to concurrency workers;
+// concurrency <= 0 uses GOMAXPROCS. Each file is replaced atomically through a temporary
+// file in the same directory and keeps its permissions; files Strip leaves unchanged or
+// falls back on are not rewritten. A failing file does not stop the others: its error is
+// reported in its FileResult. Results are in the order of paths. When ctx is canceled,
+// files not yet started get ctx.Err() as their error and BatchStrip returns it too.
+func BatchStrip(ctx context.Context, paths []string, concurrency int, opts ...Option) ([]FileResult, error) {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	results := make([]FileResult, len(paths))
+	for i, path := range paths {
+		results[i].Path = path
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].Err = stripFile(&results[i], opts)
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(paths) && ctx.Err() == nil; next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if next < len(paths) {
+		for i := next; i < len(paths); i++ {
+			results[i].Err = ctx.Err()
+		}
+		return results, ctx.Err()
+	}
+	return results, nil
+}
+
+// stripFile strips the file of r in place, writing it only when the output differs from the
+// input, and fills in the result and digests of r on success
+func stripFile(r *FileResult, opts []Option) error {
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		return err
+	}
+	cleaned, result, err := Strip(data, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to strip %s: %w", r.Path, err)
+	}
+	if !result.Unchanged && !result.FellBack {
+		if err := replaceFile(r.Path, cleaned, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	r.Result, r.InputSHA256, r.OutputSHA256 = result, sha256Hex(data), sha256Hex(cleaned)
+	return nil
+}
+
+// replaceFile atomically replaces path with data by renaming a temporary file over it
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
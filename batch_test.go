@@ -0,0 +1,97 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchStrip(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"with_comment.jpg", "with_xmp.jpg", "basic_copy.jpg", "with_gps.jpg"}
+	paths, originals := copyTestFiles(t, dir, names)
+	broken := filepath.Join(dir, "broken.jpg")
+	if err := os.WriteFile(broken, []byte("not a jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, broken, filepath.Join(dir, "missing.jpg"))
+
+	results, err := BatchStrip(context.Background(), paths, 2)
+	if err != nil {
+		t.Fatalf("BatchStrip failed: %v", err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("Expected %d results, got %d", len(paths), len(results))
+	}
+
+	for i, original := range originals {
+		r := results[i]
+		if r.Path != paths[i] || r.Err != nil {
+			t.Fatalf("%s: unexpected result %+v", names[i], r)
+		}
+		expected, _, err := Strip(original)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		written, err := os.ReadFile(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(written, expected) {
+			t.Errorf("%s: file differs from Strip output", names[i])
+		}
+		if info, _ := os.Stat(paths[i]); info.Mode().Perm() != 0o640 {
+			t.Errorf("%s: permissions changed to %v", names[i], info.Mode().Perm())
+		}
+	}
+
+	// Failures are isolated to their own result and leave the file alone
+	if results[len(originals)].Err == nil || results[len(originals)+1].Err == nil {
+		t.Error("Expected errors for the broken and missing files")
+	}
+	if data, _ := os.ReadFile(broken); string(data) != "not a jpeg" {
+		t.Error("Expected the broken file to be left as it was")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, ".*.tmp")); len(leftovers) > 0 {
+		t.Errorf("Temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestBatchStripCanceled(t *testing.T) {
+	paths, _ := copyTestFiles(t, t.TempDir(), []string{"with_comment.jpg", "with_xmp.jpg"})
+
+	// A canceled context starts no more files
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := BatchStrip(ctx, paths, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	for _, r := range results {
+		if r.Err != nil && !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: unexpected error %v", r.Path, r.Err)
+		}
+	}
+}
+
+// copyTestFiles copies the named fixtures into dir and returns their paths and contents
+func copyTestFiles(t *testing.T, dir string, names []string) ([]string, [][]byte) {
+	var paths []string
+	var originals [][]byte
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o640); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+		originals = append(originals, data)
+	}
+	return paths, originals
+}
@@ -0,0 +1,75 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+)
+
+// colorDelta decodes both JPEGs and returns the largest per-channel histogram difference,
+// from 0 (identical R, G and B distributions) to 1 (disjoint distributions or different sizes)
+func colorDelta(original, cleaned []byte) (float64, error) {
+	originalHist, originalBounds, err := channelHistograms(original)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode original image: %w", err)
+	}
+	cleanedHist, cleanedBounds, err := channelHistograms(cleaned)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode cleaned image: %w", err)
+	}
+	if originalBounds != cleanedBounds {
+		return 1, nil
+	}
+
+	pixels := float64(originalBounds.Dx() * originalBounds.Dy())
+	if pixels == 0 {
+		return 0, nil
+	}
+	delta := 0.0
+	for c := range originalHist {
+		diff := 0
+		for i := range originalHist[c] {
+			d := originalHist[c][i] - cleanedHist[c][i]
+			if d < 0 {
+				d = -d
+			}
+			diff += d
+		}
+		delta = math.Max(delta, float64(diff)/(2*pixels))
+	}
+	return delta, nil
+}
+
+// channelHistograms decodes a JPEG and counts 8-bit R, G and B values
+func channelHistograms(data []byte) ([3][256]int, image.Rectangle, error) {
+	var hist [3][256]int
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return hist, image.Rectangle{}, err
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			hist[0][r>>8]++
+			hist[1][g>>8]++
+			hist[2][b>>8]++
+		}
+	}
+	return hist, bounds, nil
+}
+
+// verifyColors fails with a *ColorShiftError when the decoded colors drift beyond tolerance
+func verifyColors(original, cleaned []byte, tolerance float64) error {
+	delta, err := colorDelta(original, cleaned)
+	if err != nil {
+		return fmt.Errorf("failed to verify colors: %w", err)
+	}
+	if delta > tolerance {
+		return &ColorShiftError{Delta: delta, Tolerance: tolerance}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// encodeSolidJPEG returns a JPEG filled with a single color
+func encodeSolidJPEG(t *testing.T, width, height int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	b := new(bytes.Buffer)
+	if err := jpeg.Encode(b, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestColorDelta(t *testing.T) {
+	red := encodeSolidJPEG(t, 16, 16, color.RGBA{R: 200, G: 20, B: 20, A: 255})
+	blue := encodeSolidJPEG(t, 16, 16, color.RGBA{R: 20, G: 20, B: 200, A: 255})
+	wide := encodeSolidJPEG(t, 32, 16, color.RGBA{R: 200, G: 20, B: 20, A: 255})
+
+	tests := []struct {
+		name     string
+		a, b     []byte
+		expected float64
+	}{
+		{"identical", red, red, 0},
+		{"different colors", red, blue, 1},
+		{"different dimensions", red, wide, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, err := colorDelta(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("colorDelta failed: %v", err)
+			}
+			if delta != tt.expected {
+				t.Errorf("Expected delta %v, got %v", tt.expected, delta)
+			}
+		})
+	}
+
+	if _, err := colorDelta(red, []byte("not a jpeg")); err == nil {
+		t.Error("Expected error for undecodable image")
+	}
+}
+
+func TestVerifyColors(t *testing.T) {
+	red := encodeSolidJPEG(t, 16, 16, color.RGBA{R: 200, G: 20, B: 20, A: 255})
+	blue := encodeSolidJPEG(t, 16, 16, color.RGBA{R: 20, G: 20, B: 200, A: 255})
+
+	if err := verifyColors(red, red, 0); err != nil {
+		t.Errorf("Expected identical images to pass, got %v", err)
+	}
+
+	err := verifyColors(red, blue, 0.5)
+	var shift *ColorShiftError
+	if !errors.As(err, &shift) {
+		t.Fatalf("Expected *ColorShiftError, got %v", err)
+	}
+	if shift.Tolerance != 0.5 || shift.Delta <= 0.5 {
+		t.Errorf("Unexpected error values: %+v", shift)
+	}
+}
@@ -0,0 +1,75 @@
+package jpegmetawebstrip
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Comment encodings reported by DecodeComment
+const (
+	EncodingUTF8     = "UTF-8"
+	EncodingShiftJIS = "Shift_JIS"
+	EncodingLatin1   = "ISO-8859-1"
+)
+
+// Comment is the decoded text of a COM segment
+type Comment struct {
+	// Encoding is the heuristically detected character encoding of the raw bytes
+	Encoding string
+	// Text is the comment decoded to UTF-8
+	Text string
+}
+
+// DecodeComment detects the encoding of a COM payload and decodes it to UTF-8.
+// Valid UTF-8 (including plain ASCII) wins, then strictly valid Shift_JIS,
+// and ISO-8859-1 is used as the fallback since every byte sequence is valid in it.
+func DecodeComment(data []byte) Comment {
+	if utf8.Valid(data) {
+		return Comment{Encoding: EncodingUTF8, Text: string(data)}
+	}
+	if isValidShiftJIS(data) {
+		if text, err := decodeWith(japanese.ShiftJIS, data); err == nil {
+			return Comment{Encoding: EncodingShiftJIS, Text: text}
+		}
+	}
+	text, _ := decodeWith(charmap.ISO8859_1, data)
+	return Comment{Encoding: EncodingLatin1, Text: text}
+}
+
+// decodeWith decodes data with the given encoding
+func decodeWith(enc encoding.Encoding, data []byte) (string, error) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// isValidShiftJIS checks that data consists only of valid Shift_JIS byte sequences
+// and contains at least one double-byte character
+func isValidShiftJIS(data []byte) bool {
+	doubleByte := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c < 0x80, c >= 0xA1 && c <= 0xDF: // ASCII, half-width katakana
+			continue
+		case (c >= 0x81 && c <= 0x9F) || (c >= 0xE0 && c <= 0xFC): // Lead byte
+			if i+1 >= len(data) {
+				return false
+			}
+			t := data[i+1]
+			if t < 0x40 || t == 0x7F || t > 0xFC {
+				return false
+			}
+			doubleByte = true
+			i++
+		default:
+			return false
+		}
+	}
+	return doubleByte
+}
@@ -0,0 +1,68 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeComment(t *testing.T) {
+	testCases := []struct {
+		name             string
+		data             []byte
+		expectedEncoding string
+		expectedText     string
+	}{
+		{"ASCII", []byte("This is a test comment"), EncodingUTF8, "This is a test comment"},
+		{"UTF-8 Japanese", []byte("テストコメント"), EncodingUTF8, "テストコメント"},
+		// "テスト" in Shift_JIS
+		{"Shift_JIS Japanese", []byte{0x83, 0x65, 0x83, 0x58, 0x83, 0x67}, EncodingShiftJIS, "テスト"},
+		// "café" in ISO-8859-1
+		{"Latin-1", []byte{'c', 'a', 'f', 0xE9}, EncodingLatin1, "café"},
+		{"Empty", []byte{}, EncodingUTF8, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := DecodeComment(tc.data)
+			if comment.Encoding != tc.expectedEncoding {
+				t.Errorf("Expected encoding %s, got %s", tc.expectedEncoding, comment.Encoding)
+			}
+			if comment.Text != tc.expectedText {
+				t.Errorf("Expected text %q, got %q", tc.expectedText, comment.Text)
+			}
+		})
+	}
+}
+
+func TestWithCaptureComments(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	jpegData = insertSegment(jpegData, 0xFE, []byte("This is a test comment"))
+	// "写真" in Shift_JIS, inserted before the first comment
+	jpegData = insertSegment(jpegData, 0xFE, []byte{0x8E, 0xCA, 0x90, 0x5E})
+
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if len(result.RemovedComments) != 0 {
+		t.Error("Expected comments not to be captured by default")
+	}
+
+	_, result, err = Strip(jpegData, WithCaptureComments())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if len(result.RemovedComments) != 2 {
+		t.Fatalf("Expected 2 captured comments, got %d", len(result.RemovedComments))
+	}
+	if c := result.RemovedComments[0]; c.Encoding != EncodingShiftJIS || c.Text != "写真" {
+		t.Errorf("Unexpected first comment: %+v", c)
+	}
+	if c := result.RemovedComments[1]; c.Encoding != EncodingUTF8 || c.Text != "This is a test comment" {
+		t.Errorf("Unexpected second comment: %+v", c)
+	}
+}
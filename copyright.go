@@ -0,0 +1,33 @@
+package jpegmetawebstrip
+
+// copyrightIPTCWanted are the IPTC datasets kept by WithKeepCopyright
+var copyrightIPTCWanted = []iptcDataset{
+	{Record: 2, Dataset: 80},  // By-line
+	{Record: 2, Dataset: 116}, // CopyrightNotice
+}
+
+// copyrightExif builds a minimal EXIF payload holding only the IFD0 attribution tags
+// (Artist and Copyright) of exifData, in its byte order. It returns nil when there are none.
+func copyrightExif(exifData []byte) []byte {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+
+	dir := &exifDirectory{}
+	for _, entry := range entries {
+		if _, ok := copyrightExifTags[entry.Tag]; !ok {
+			continue
+		}
+		value, ok := entry.valueBytes(exifData, order)
+		if !ok {
+			continue
+		}
+		dir.Fields = append(dir.Fields, exifField{Tag: entry.Tag, Type: entry.Type, Count: entry.Count, Data: value})
+	}
+	if len(dir.Fields) == 0 {
+		return nil
+	}
+	return (&exifTree{order: order, ifd0: dir}).bytes()
+}
@@ -0,0 +1,153 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithKeepCopyrightIPTC(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_iptc.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, result, err := Strip(jpegData, WithKeepCopyright())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+
+	app13 := getSegmentPayloads(t, cleaned, 0xED)
+	if len(app13) != 1 {
+		t.Fatalf("Expected a minimal APP13 segment, got %d", len(app13))
+	}
+	values := map[byte]string{}
+	for _, dataset := range iptcDatasets(app13[0]) {
+		if dataset.Record == 2 && dataset.Dataset != 0 {
+			values[dataset.Dataset] = string(dataset.Value)
+		}
+	}
+	if len(values) != 2 || values[80] != "Test Photographer" || values[116] != "Copyright 2024 Test" {
+		t.Errorf("Expected only By-line and CopyrightNotice, got %v", values)
+	}
+	if len(warningTexts(result)) != 0 {
+		t.Errorf("Expected no copyright warnings, got %v", result.Warnings)
+	}
+}
+
+func TestWithKeepCopyrightCompactIPTC(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	// A bare By-line without the record envelopes grows when it is rebuilt
+	iptc := new(bytes.Buffer)
+	writeIPTCDataset(iptc, iptcDataset{Record: 2, Dataset: 80, Value: []byte("Test Photographer")})
+	app13 := buildPhotoshopSegment([]photoshopResource{{ID: photoshopIPTCResourceID, Data: iptc.Bytes()}})
+	if filtered := filterIPTCSegment(app13, copyrightIPTCWanted); len(filtered) <= len(app13) {
+		t.Fatalf("Expected the filtered block to be larger, got %d <= %d", len(filtered), len(app13))
+	}
+
+	cleaned, result, err := Strip(insertSegment(jpegData, 0xED, app13), WithKeepCopyright())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	kept := getSegmentPayloads(t, cleaned, 0xED)
+	if len(kept) != 1 {
+		t.Fatalf("Expected the APP13 segment to be kept, got %d", len(kept))
+	}
+	var byline string
+	for _, dataset := range iptcDatasets(kept[0]) {
+		if dataset.Record == 2 && dataset.Dataset == 80 {
+			byline = string(dataset.Value)
+		}
+	}
+	if byline != "Test Photographer" {
+		t.Errorf("Expected By-line to survive, got %q", byline)
+	}
+	if result.Removed.PhotoshopIRB != 0 {
+		t.Errorf("Expected no PhotoshopIRB savings for a grown block, got %d", result.Removed.PhotoshopIRB)
+	}
+	if len(warningTexts(result)) != 0 {
+		t.Errorf("Expected no copyright warnings, got %v", result.Warnings)
+	}
+}
+
+func TestWithKeepCopyrightXMP(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	xmp := XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:CreatorTool="Editor 1.0">` +
+		`<dc:rights><rdf:Alt><rdf:li xml:lang="x-default">(c) 2024 Example &amp; Co</rdf:li></rdf:Alt></dc:rights>` +
+		`<dc:creator><rdf:Seq><rdf:li>Doe; Jane</rdf:li><rdf:li>John</rdf:li></rdf:Seq></dc:creator>` +
+		`<xmp:Label>` + strings.Repeat("editing history ", 64) + `</xmp:Label>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	jpegData = insertSegment(jpegData, 0xE1, []byte(xmp))
+
+	cleaned, result, err := Strip(jpegData, WithKeepCopyright())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	payloads := getSegmentPayloads(t, cleaned, 0xE1)
+	if len(payloads) != 1 {
+		t.Fatalf("Expected a minimal XMP packet, got %d APP1 segments", len(payloads))
+	}
+	packet := bytes.TrimPrefix(payloads[0], []byte(XMPHeader))
+	if bytes.Contains(packet, []byte("CreatorTool")) || bytes.Contains(packet, []byte("history")) {
+		t.Error("Expected the other XMP properties to be removed")
+	}
+	properties := extractXMPProperties(packet, copyrightXMPProperties)
+	if len(properties) != 2 || properties[0].Value != "(c) 2024 Example & Co" || !slices.Equal(properties[1].Items, []string{"Doe; Jane", "John"}) {
+		t.Errorf("Expected dc:rights and dc:creator to survive, got %+v", properties)
+	}
+	if !bytes.Contains(packet, []byte("<rdf:Alt>")) || !bytes.Contains(packet, []byte("<rdf:li>John</rdf:li>")) {
+		t.Errorf("Expected array values to be written as rdf containers, got %s", packet)
+	}
+	if result.Removed.XMP == 0 || len(warningTexts(result)) != 0 {
+		t.Errorf("Expected XMP savings without warnings, got %d bytes and %v", result.Removed.XMP, result.Warnings)
+	}
+}
+
+func TestWithKeepCopyrightEXIF(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	e := newTestExif(binary.LittleEndian)
+	e.ifd0 = []testTag{e.ascii(0x010F, "Canon"), e.ascii(0x013B, "Jane Example"), e.ascii(0x8298, "(c) 2024 Example")}
+	e.exifIFD = []testTag{e.short(0xA001, 1)}
+	jpegData = insertSegment(jpegData, 0xE1, e.build())
+
+	// Whitelist mode keeps the attribution tags alongside the whitelisted ones
+	cleaned, result, err := Strip(jpegData, WithWhitelistMode(), WithKeepCopyright())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	tags := exifTagIDs(presetTestExif(t, cleaned))
+	if !containsTag(tags, 0x013B) || !containsTag(tags, 0x8298) || containsTag(tags, 0x010F) {
+		t.Errorf("Expected Artist and Copyright kept and Make removed, got %v", tags)
+	}
+	if len(warningTexts(result)) != 0 {
+		t.Errorf("Expected no copyright warnings, got %v", result.Warnings)
+	}
+
+	// A whitelist without APP1 leaves a minimal EXIF holding only the attribution
+	cleaned, result, err = Strip(jpegData, WithWhitelistMode(), WithWhitelist([]byte{0xE0}, nil), WithKeepCopyright())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	exifData := presetTestExif(t, cleaned)
+	if tags := exifTagIDs(exifData); !equalTags(tags, []uint16{0x013B, 0x8298}) {
+		t.Errorf("Expected a minimal EXIF with Artist and Copyright, got %v", tags)
+	}
+	if !strings.Contains(string(exifData), "(c) 2024 Example") || len(warningTexts(result)) != 0 {
+		t.Errorf("Expected the copyright value to survive without warnings, got %v", result.Warnings)
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -8,6 +9,20 @@ import (
 )
 
 func main() {
+	corpusList := flag.String("corpus", "", "fetch the benchmark corpus listed in this URL list instead of generating test data")
+	cacheDir := flag.String("cache", "corpus", "directory the benchmark corpus is cached in")
+	flag.Parse()
+
+	if *corpusList != "" {
+		manifest, err := datacreator.FetchCorpus(*corpusList, *cacheDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Benchmark corpus of %d images ready in %s\n", len(manifest.Images), *cacheDir)
+		return
+	}
+
 	if err := datacreator.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
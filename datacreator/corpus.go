@@ -0,0 +1,165 @@
+package datacreator
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CorpusManifestName is the manifest written into the corpus cache directory
+const CorpusManifestName = "manifest.json"
+
+// CorpusImage is a downloaded benchmark image
+type CorpusImage struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// CorpusManifest lists the images of a benchmark corpus. Two machines that fetched the
+// same list have identical manifests, so their benchmark numbers are comparable.
+type CorpusManifest struct {
+	// Source is the base name of the URL list the corpus was fetched from
+	Source string        `json:"source"`
+	Images []CorpusImage `json:"images"`
+}
+
+// corpusEntry is one line of a corpus URL list
+type corpusEntry struct {
+	URL    string
+	SHA256 string
+}
+
+// FetchCorpus downloads the images of an open-licensed corpus (for example a RAISE or
+// Unsplash subset) into cacheDir and writes a manifest there. listPath names a text file
+// with one "URL [sha256]" entry per line; blank lines and lines starting with # are ignored.
+// Images already in the cache with the expected hash are not downloaded again.
+func FetchCorpus(listPath, cacheDir string) (*CorpusManifest, error) {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus list: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := parseCorpusList(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse corpus list: %w", err)
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	manifest := &CorpusManifest{Source: filepath.Base(listPath)}
+	for i, entry := range entries {
+		image, err := fetchCorpusImage(client, entry, fmt.Sprintf("%04d-%s", i+1, corpusFileName(entry.URL)), cacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", entry.URL, err)
+		}
+		manifest.Images = append(manifest.Images, image)
+		fmt.Printf("Fetched: %s (%d bytes)\n", image.Name, image.Size)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, CorpusManifestName), append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// ReadCorpusManifest loads the manifest written by FetchCorpus
+func ReadCorpusManifest(cacheDir string) (*CorpusManifest, error) {
+	data, err := os.ReadFile(filepath.Join(cacheDir, CorpusManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	manifest := &CorpusManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// parseCorpusList reads "URL [sha256]" lines
+func parseCorpusList(r io.Reader) ([]corpusEntry, error) {
+	entries := make([]corpusEntry, 0)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected URL and optional sha256", line)
+		}
+		entry := corpusEntry{URL: fields[0]}
+		if len(fields) == 2 {
+			entry.SHA256 = strings.ToLower(fields[1])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// corpusFileName derives a file name from the last path element of a URL
+func corpusFileName(rawURL string) string {
+	name := "image.jpg"
+	if u, err := url.Parse(rawURL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		name = path.Base(u.Path)
+	}
+	if ext := strings.ToLower(path.Ext(name)); ext != ".jpg" && ext != ".jpeg" {
+		name += ".jpg"
+	}
+	return name
+}
+
+// fetchCorpusImage downloads one image unless a cached copy with the expected hash exists
+func fetchCorpusImage(client *http.Client, entry corpusEntry, name, cacheDir string) (CorpusImage, error) {
+	image := CorpusImage{Name: name, URL: entry.URL}
+	target := filepath.Join(cacheDir, name)
+
+	if data, err := os.ReadFile(target); err == nil {
+		sum := sha256.Sum256(data)
+		if hash := hex.EncodeToString(sum[:]); entry.SHA256 == "" || hash == entry.SHA256 {
+			image.SHA256, image.Size = hash, int64(len(data))
+			return image, nil
+		}
+	}
+
+	resp, err := client.Get(entry.URL)
+	if err != nil {
+		return image, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return image, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return image, fmt.Errorf("failed to download: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	image.SHA256, image.Size = hex.EncodeToString(sum[:]), int64(len(data))
+	if entry.SHA256 != "" && image.SHA256 != entry.SHA256 {
+		return image, fmt.Errorf("sha256 mismatch: got %s, want %s", image.SHA256, entry.SHA256)
+	}
+	if err := os.WriteFile(target, data, 0o644); err != nil {
+		return image, fmt.Errorf("failed to write image: %w", err)
+	}
+	return image, nil
+}
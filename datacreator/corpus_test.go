@@ -0,0 +1,64 @@
+package datacreator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFetchCorpus(t *testing.T) {
+	body := []byte("\xFF\xD8 not really a jpeg \xFF\xD9")
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "list.txt")
+	content := "# test corpus\n" + server.URL + "/photos/a.jpg " + hash + "\n\n" + server.URL + "/b\n"
+	if err := os.WriteFile(list, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(dir, "cache")
+
+	manifest, err := FetchCorpus(list, cache)
+	if err != nil {
+		t.Fatalf("FetchCorpus failed: %v", err)
+	}
+	if len(manifest.Images) != 2 || manifest.Images[0].Name != "0001-a.jpg" || manifest.Images[1].Name != "0002-b.jpg" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if manifest.Images[1].SHA256 != hash || manifest.Images[1].Size != int64(len(body)) {
+		t.Errorf("unexpected image entry: %+v", manifest.Images[1])
+	}
+
+	// A second fetch is served from the cache and yields the same manifest
+	if _, err := FetchCorpus(list, cache); err != nil {
+		t.Fatalf("second FetchCorpus failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected cached images not to be downloaded again, got %d requests", requests)
+	}
+	read, err := ReadCorpusManifest(cache)
+	if err != nil || len(read.Images) != 2 || read.Source != "list.txt" {
+		t.Errorf("unexpected manifest read back: %+v %v", read, err)
+	}
+
+	// A hash mismatch is an error
+	if err := os.WriteFile(list, []byte(server.URL+"/c.jpg "+strings.Repeat("0", 64)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchCorpus(list, cache); err == nil {
+		t.Error("expected sha256 mismatch error")
+	}
+}
@@ -18,6 +18,8 @@ type TestImage struct {
 	Name        string
 	Description string
 	Command     []string
+	// UseExiftool copies the original with ImageMagick and passes Command to exiftool,
+	// for metadata ImageMagick cannot write
 	UseExiftool bool
 }
 
@@ -76,6 +78,9 @@ func ensureTestdataDir() error {
 
 func generateImage(originalPath string, img TestImage) error {
 	outputPath := filepath.Join(testdataDir, img.Name)
+	if img.UseExiftool {
+		return generateWithExiftool(originalPath, outputPath, img.Command)
+	}
 
 	// Build the command properly
 	args := append([]string{originalPath}, img.Command...)
@@ -91,6 +96,22 @@ func generateImage(originalPath string, img TestImage) error {
 	return nil
 }
 
+func generateWithExiftool(originalPath, outputPath string, tags []string) error {
+	if _, err := exec.LookPath("exiftool"); err != nil {
+		return fmt.Errorf("exiftool not found")
+	}
+	if output, err := exec.Command("magick", originalPath, outputPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("ImageMagick command failed: %w\nOutput: %s", err, output)
+	}
+
+	// #nosec G204 - exiftool is a trusted tool and the tags are fixed in getTestImages
+	args := append(append([]string{}, tags...), "-overwrite_original", outputPath)
+	if output, err := exec.Command("exiftool", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("exiftool command failed: %w\nOutput: %s", err, output)
+	}
+	return nil
+}
+
 func getTestImages() []TestImage {
 	return []TestImage{
 		// Basic copy for testing
@@ -104,12 +125,26 @@ func getTestImages() []TestImage {
 		{
 			Name:        "with_gps.jpg",
 			Description: "JPEG with GPS data",
-			Command:     []string{"-set", "EXIF:GPSLatitude", "40.7142", "-set", "EXIF:GPSLongitude", "-74.0064"},
+			Command:     []string{"-GPSLatitude=40.7142", "-GPSLatitudeRef=N", "-GPSLongitude=74.0064", "-GPSLongitudeRef=W"},
+			UseExiftool: true,
 		},
 		{
 			Name:        "with_camera_info.jpg",
 			Description: "JPEG with camera information",
-			Command:     []string{"-set", "EXIF:Make", "Canon", "-set", "EXIF:Model", "EOS 5D Mark IV"},
+			Command:     []string{"-Make=Canon", "-Model=EOS 5D Mark IV"},
+			UseExiftool: true,
+		},
+		{
+			Name:        "with_software.jpg",
+			Description: "JPEG with editing software",
+			Command:     []string{"-Software=Test Editor 1.0"},
+			UseExiftool: true,
+		},
+		{
+			Name:        "with_image_unique_id.jpg",
+			Description: "JPEG with a unique image ID",
+			Command:     []string{"-ImageUniqueID=0123456789abcdef0123456789abcdef"},
+			UseExiftool: true,
 		},
 		{
 			Name:        "with_comment.jpg",
@@ -0,0 +1,34 @@
+package datacreator
+
+// Fixture describes a generated test image and the Result.Removed categories that
+// stripping it with default options is expected to report as non-zero
+type Fixture struct {
+	Name       string
+	Categories []string
+}
+
+// Manifest lists every generated fixture with the removal categories it exercises.
+// Category names match the field names of Result.Removed.
+var Manifest = []Fixture{
+	{Name: "basic_copy.jpg"},
+	{Name: "with_gps.jpg", Categories: []string{"ExifGPS"}},
+	{Name: "with_camera_info.jpg", Categories: []string{"CameraInfo"}},
+	{Name: "with_software.jpg", Categories: []string{"Software"}},
+	{Name: "with_image_unique_id.jpg", Categories: []string{"Identifiers"}},
+	{Name: "with_comment.jpg"},
+	{Name: "with_orientation.jpg"},
+	{Name: "with_dpi.jpg"},
+	{Name: "with_colorspace.jpg"},
+	{Name: "with_quality.jpg"},
+	{Name: "with_gamma.jpg"},
+	{Name: "with_exif_thumbnail.jpg", Categories: []string{"ExifThumbnail"}},
+	{Name: "with_xmp.jpg", Categories: []string{"XMP"}},
+	{Name: "with_iptc.jpg", Categories: []string{"PhotoshopIRB"}},
+	{Name: "with_photoshop_irb.jpg", Categories: []string{"PhotoshopIRB"}},
+	{Name: "with_all_removable.jpg", Categories: []string{"ExifThumbnail", "ExifGPS", "CameraInfo", "XMP", "PhotoshopIRB", "Comments"}},
+	{Name: "with_icc_profile_srgb.jpg"},
+	{Name: "with_icc_profile_p3.jpg"},
+	{Name: "with_mixed_metadata.jpg", Categories: []string{"ExifGPS", "XMP", "PhotoshopIRB", "Comments"}},
+	{Name: "with_comprehensive_mixed.jpg", Categories: []string{"ExifThumbnail", "ExifGPS", "CameraInfo", "XMP", "PhotoshopIRB", "Comments"}},
+	{Name: "with_thumbnail_and_icc.jpg", Categories: []string{"ExifThumbnail"}},
+}
@@ -0,0 +1,51 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// StripDataURI strips a JPEG given as a "data:image/jpeg;base64,..." URI and returns the
+// cleaned image as a data URI with the same media type and parameters
+func StripDataURI(s string, opts ...Option) (string, *Result, error) {
+	prefix, payload, ok := strings.Cut(s, ",")
+	if !ok || !strings.HasPrefix(strings.ToLower(prefix), "data:") {
+		return "", nil, fmt.Errorf("invalid data URI")
+	}
+
+	params := strings.Split(prefix[len("data:"):], ";")
+	switch strings.ToLower(strings.TrimSpace(params[0])) {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+	default:
+		return "", nil, fmt.Errorf("unsupported data URI media type %q", params[0])
+	}
+	if !strings.EqualFold(params[len(params)-1], "base64") {
+		return "", nil, fmt.Errorf("data URI is not base64 encoded")
+	}
+
+	// Inline images are sometimes wrapped or use the URL-safe alphabet
+	payload = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		case '-':
+			return '+'
+		case '_':
+			return '/'
+		}
+		return r
+	}, payload)
+	jpegData, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		if jpegData, err = base64.RawStdEncoding.DecodeString(payload); err != nil {
+			return "", nil, fmt.Errorf("failed to decode data URI: %w", err)
+		}
+	}
+
+	cleaned, result, err := Strip(jpegData, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	return prefix + "," + base64.StdEncoding.EncodeToString(cleaned), result, nil
+}
@@ -0,0 +1,64 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripDataURI(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(jpegData)
+
+	tests := []struct {
+		name   string
+		input  string
+		prefix string
+	}{
+		{"standard", "data:image/jpeg;base64," + encoded, "data:image/jpeg;base64,"},
+		{"with parameters", "data:image/jpeg;name=photo.jpg;base64," + encoded, "data:image/jpeg;name=photo.jpg;base64,"},
+		{"wrapped", "data:image/jpeg;base64," + encoded[:100] + "\n" + encoded[100:], "data:image/jpeg;base64,"},
+		{"unpadded url-safe", "data:image/jpeg;base64," + base64.RawURLEncoding.EncodeToString(jpegData), "data:image/jpeg;base64,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, result, err := StripDataURI(tt.input)
+			if err != nil {
+				t.Fatalf("StripDataURI failed: %v", err)
+			}
+			if !strings.HasPrefix(output, tt.prefix) {
+				t.Errorf("Expected prefix %q, got %q", tt.prefix, output[:len(tt.prefix)])
+			}
+			if output[len(tt.prefix):] != base64.StdEncoding.EncodeToString(expected) {
+				t.Error("Expected payload to be the stripped JPEG")
+			}
+			if result.Total == 0 {
+				t.Error("Expected metadata to be removed")
+			}
+		})
+	}
+}
+
+func TestStripDataURIErrors(t *testing.T) {
+	for _, input := range []string{
+		"image/jpeg;base64,AAAA",
+		"data:image/png;base64,AAAA",
+		"data:image/jpeg,%FF%D8",
+		"data:image/jpeg;base64,!!!",
+		"data:image/jpeg;base64," + base64.StdEncoding.EncodeToString([]byte("not a jpeg")),
+	} {
+		if _, _, err := StripDataURI(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
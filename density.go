@@ -0,0 +1,165 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// JFIFHeader is the identifier that starts an APP0 JFIF segment
+const JFIFHeader = "JFIF\x00"
+
+// EXIF resolution tags in IFD0
+const (
+	exifXResolution    = 0x011A
+	exifYResolution    = 0x011B
+	exifResolutionUnit = 0x0128
+)
+
+// density is a horizontal and vertical resolution in dots per inch
+type density struct {
+	X, Y float64
+}
+
+// matches reports whether two densities agree after rounding to whole DPI
+func (d density) matches(other density) bool {
+	return math.Round(d.X) == math.Round(other.X) && math.Round(d.Y) == math.Round(other.Y)
+}
+
+// jfifDensity reads the density of an APP0 JFIF payload.
+// It returns false for aspect-ratio-only (unit 0) or malformed segments.
+func jfifDensity(data []byte) (density, bool) {
+	if len(data) < 12 || !bytes.HasPrefix(data, []byte(JFIFHeader)) {
+		return density{}, false
+	}
+	x := float64(binary.BigEndian.Uint16(data[8:10]))
+	y := float64(binary.BigEndian.Uint16(data[10:12]))
+	switch data[7] {
+	case 1: // dots per inch
+		return density{X: x, Y: y}, true
+	case 2: // dots per cm
+		return density{X: x * 2.54, Y: y * 2.54}, true
+	}
+	return density{}, false
+}
+
+// exifDensity reads XResolution, YResolution and ResolutionUnit from IFD0
+func exifDensity(exifData []byte) (density, bool) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return density{}, false
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+
+	var d density
+	var haveX, haveY bool
+	scale := 1.0
+	for _, entry := range entries {
+		switch entry.Tag {
+		case exifXResolution:
+			d.X, haveX = exifRational(exifData, order, entry)
+		case exifYResolution:
+			d.Y, haveY = exifRational(exifData, order, entry)
+		case exifResolutionUnit:
+			if entry.Type == 3 && order.Uint16(entry.Value[0:2]) == 3 {
+				scale = 2.54
+			}
+		}
+	}
+	if !haveX || !haveY {
+		return density{}, false
+	}
+	return density{X: d.X * scale, Y: d.Y * scale}, true
+}
+
+// exifRational reads an unsigned RATIONAL value
+func exifRational(exifData []byte, order binary.ByteOrder, entry ifdEntry) (float64, bool) {
+	if entry.Type != 5 || entry.Count != 1 {
+		return 0, false
+	}
+	value, ok := entry.valueBytes(exifData, order)
+	if !ok {
+		return 0, false
+	}
+	numerator, denominator := order.Uint32(value[0:4]), order.Uint32(value[4:8])
+	if denominator == 0 {
+		return 0, false
+	}
+	return float64(numerator) / float64(denominator), true
+}
+
+// normalizeDensity rewrites the JFIF and EXIF resolution to dpi when both are declared
+// and disagree. The segments are replaced rather than modified.
+func normalizeDensity(segments []*jpegSegment, dpi int) ([]*jpegSegment, bool) {
+	jfifIndex, exifIndex := -1, -1
+	var jfif, exif density
+	for i, segment := range segments {
+		switch {
+		case segment.MarkerId == markerAPP0 && jfifIndex < 0:
+			if d, ok := jfifDensity(segment.Data); ok {
+				jfifIndex, jfif = i, d
+			}
+		case segment.MarkerId == markerAPP1 && exifIndex < 0 && isExifSegment(segment):
+			if d, ok := exifDensity(segment.Data); ok {
+				exifIndex, exif = i, d
+			}
+		}
+	}
+	if jfifIndex < 0 || exifIndex < 0 || jfif.matches(exif) {
+		return segments, false
+	}
+
+	exifData, ok := setExifDensity(segments[exifIndex].Data, dpi)
+	if !ok {
+		return segments, false
+	}
+	jfifData := append([]byte{}, segments[jfifIndex].Data...)
+	jfifData[7] = 1
+	binary.BigEndian.PutUint16(jfifData[8:10], uint16(dpi))
+	binary.BigEndian.PutUint16(jfifData[10:12], uint16(dpi))
+
+	normalized := append([]*jpegSegment{}, segments...)
+	normalized[jfifIndex] = replaceSegmentData(segments[jfifIndex], jfifData)
+	normalized[exifIndex] = replaceSegmentData(segments[exifIndex], exifData)
+	return normalized, true
+}
+
+// setExifDensity returns a copy of exifData with IFD0 resolution set to dpi pixels per inch
+func setExifDensity(exifData []byte, dpi int) ([]byte, bool) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil, false
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+
+	data := append([]byte{}, exifData...)
+	for _, entry := range entries {
+		switch entry.Tag {
+		case exifXResolution, exifYResolution:
+			if entry.Type != 5 || entry.Count != 1 {
+				return nil, false
+			}
+			start := tiffHeaderPos + int(entry.offset(order))
+			if start < tiffHeaderPos || start+8 > len(data) {
+				return nil, false
+			}
+			order.PutUint32(data[start:start+4], uint32(dpi))
+			order.PutUint32(data[start+4:start+8], 1)
+		case exifResolutionUnit:
+			if entry.Type == 3 {
+				order.PutUint16(data[entry.Pos+8:entry.Pos+10], 2)
+			}
+		}
+	}
+	return data, true
+}
+
+// replaceSegmentData returns a copy of segment carrying data
+func replaceSegmentData(segment *jpegSegment, data []byte) *jpegSegment {
+	return &jpegSegment{
+		MarkerId:   segment.MarkerId,
+		MarkerName: segment.MarkerName,
+		Offset:     segment.Offset,
+		Data:       data,
+	}
+}
@@ -0,0 +1,82 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readDensities returns the JFIF and EXIF densities of a JPEG
+func readDensities(t *testing.T, jpegData []byte) (jfif, exif density) {
+	t.Helper()
+	for _, segment := range parseTestSegments(t, jpegData) {
+		switch {
+		case segment.MarkerId == markerAPP0:
+			jfif, _ = jfifDensity(segment.Data)
+		case segment.MarkerId == markerAPP1 && isExifSegment(segment):
+			exif, _ = exifDensity(segment.Data)
+		}
+	}
+	return jfif, exif
+}
+
+func TestWithNormalizeDensity(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	// The fixture declares 300 DPI in both; make JFIF claim 72 DPI instead
+	if !bytes.HasPrefix(jpegData[6:], []byte(JFIFHeader)) {
+		t.Fatal("fixture does not start with a JFIF segment")
+	}
+	conflicting := append([]byte{}, jpegData...)
+	copy(conflicting[6+7:], []byte{1, 0, 72, 0, 72})
+
+	cleaned, result, err := Strip(conflicting, WithNormalizeDensity(144))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !result.DensityNormalized {
+		t.Error("expected DensityNormalized")
+	}
+	jfif, exif := readDensities(t, cleaned)
+	if want := (density{X: 144, Y: 144}); jfif != want || exif != want {
+		t.Errorf("expected 144 DPI everywhere, got JFIF %v EXIF %v", jfif, exif)
+	}
+	assertPixelsUnchanged(t, conflicting, cleaned)
+
+	// Consistent densities are left alone
+	cleaned, result, err = Strip(jpegData, WithNormalizeDensity(144))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.DensityNormalized {
+		t.Error("consistent densities must not be normalized")
+	}
+	if jfif, exif := readDensities(t, cleaned); jfif.X != 300 || exif.X != 300 {
+		t.Errorf("expected 300 DPI to be kept, got JFIF %v EXIF %v", jfif, exif)
+	}
+
+	// Without the option the conflict is kept
+	_, result, err = Strip(conflicting)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.DensityNormalized {
+		t.Error("density normalized without WithNormalizeDensity")
+	}
+}
+
+func TestJFIFDensityUnits(t *testing.T) {
+	payload := append([]byte(JFIFHeader), 1, 2, 2, 0, 100, 0, 50, 0, 0)
+	d, ok := jfifDensity(payload)
+	if !ok || d.X != 254 || d.Y != 127 {
+		t.Errorf("expected 254x127 DPI from dots per cm, got %v %v", d, ok)
+	}
+	payload[7] = 0
+	if _, ok := jfifDensity(payload); ok {
+		t.Error("aspect-ratio-only JFIF must not report a density")
+	}
+}
@@ -0,0 +1,203 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tagTypeNames maps TIFF field types to their names
+var tagTypeNames = map[uint16]string{
+	1:  "BYTE",
+	2:  "ASCII",
+	3:  "SHORT",
+	4:  "LONG",
+	5:  "RATIONAL",
+	6:  "SBYTE",
+	7:  "UNDEFINED",
+	8:  "SSHORT",
+	9:  "SLONG",
+	10: "SRATIONAL",
+	11: "FLOAT",
+	12: "DOUBLE",
+}
+
+// DumpStructure writes a stable, line-oriented description of the JPEG segments and EXIF tags to w.
+// Offsets are omitted and payloads are summarized by size and hash so that two dumps can be diffed.
+func DumpStructure(w io.Writer, data []byte) error {
+	sl, err := scanSegments(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse JPEG: %w", err)
+	}
+
+	b := new(bytes.Buffer)
+	for _, segment := range sl.Segments() {
+		fmt.Fprintf(b, "%s size=%d sha256=%s%s\n",
+			segmentName(segment), len(segment.Data), shortHash(segment.Data), segmentKind(segment))
+		if segment.MarkerId == markerAPP1 && isExifSegment(segment) {
+			dumpExif(b, segment.Data)
+		}
+	}
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// segmentName returns the marker name of a segment, or its hex ID when unnamed
+func segmentName(segment *jpegSegment) string {
+	if segment.MarkerId == 0x00 {
+		return "SCAN"
+	}
+	if segment.MarkerName != "" {
+		return segment.MarkerName
+	}
+	return fmt.Sprintf("0x%02X", segment.MarkerId)
+}
+
+// segmentKind describes the payload type of APPn segments
+func segmentKind(segment *jpegSegment) string {
+	switch {
+	case segment.MarkerId == markerAPP1 && isExifSegment(segment):
+		return " kind=EXIF"
+	case segment.MarkerId == markerAPP1 && isXMPSegment(segment):
+		return " kind=XMP"
+	case segment.MarkerId == markerAPP1 && isExtendedXMPSegment(segment):
+		return " kind=ExtendedXMP"
+	}
+	for _, k := range prefixedKinds {
+		if segment.MarkerId == k.marker && bytes.HasPrefix(segment.Data, k.prefix) {
+			return " kind=" + k.kind
+		}
+	}
+	if isFLIRSegment(segment) {
+		return " kind=FLIR"
+	}
+	if vendor := telemetryVendor(segment); vendor != "" {
+		return " kind=Telemetry vendor=" + vendor
+	}
+	return ""
+}
+
+// prefixedKinds are the segment kinds recognized by their marker and payload prefix
+var prefixedKinds = []struct {
+	marker byte
+	prefix []byte
+	kind   string
+}{
+	{markerAPP2, ICCHeader, "ICC"},
+	{markerAPP0, []byte("JFIF\x00"), "JFIF"},
+	{markerAPP13, []byte("Photoshop 3.0\x00"), "Photoshop"},
+	{markerAPP14, []byte("Adobe"), "Adobe"},
+}
+
+// dumpExif writes one line per IFD entry for IFD0, Exif IFD, GPS IFD, Interop IFD and IFD1
+func dumpExif(b *bytes.Buffer, exifData []byte) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		fmt.Fprintf(b, "  error: %v\n", err)
+		return
+	}
+	if order == binary.LittleEndian {
+		fmt.Fprintf(b, "  byteorder=II\n")
+	} else {
+		fmt.Fprintf(b, "  byteorder=MM\n")
+	}
+
+	type pendingIFD struct {
+		name string
+		pos  int
+	}
+	queue := []pendingIFD{{"IFD0", ifd0Pos}}
+	visited := make(map[int]bool)
+
+	for len(queue) > 0 {
+		ifd := queue[0]
+		queue = queue[1:]
+		if visited[ifd.pos] {
+			fmt.Fprintf(b, "  %s: loop detected\n", ifd.name)
+			continue
+		}
+		visited[ifd.pos] = true
+
+		entries, next, err := readIFD(exifData, order, ifd.pos)
+		if err != nil {
+			fmt.Fprintf(b, "  %s: %v\n", ifd.name, err)
+		}
+		for _, entry := range entries {
+			fmt.Fprintf(b, "  %s 0x%04X %s count=%d %s\n",
+				ifd.name, entry.Tag, typeName(entry.Type), entry.Count, formatEntryValue(exifData, order, entry))
+
+			if name, ok := subIFDPointers[entry.Tag]; ok && entry.offset(order) != 0 {
+				queue = append(queue, pendingIFD{name, tiffHeaderPos + int(entry.offset(order))})
+			}
+		}
+		if ifd.name == "IFD0" && next != 0 {
+			queue = append(queue, pendingIFD{"IFD1", tiffHeaderPos + int(next)})
+		}
+	}
+}
+
+// subIFDPointers names the directories followed by dumpExif; a zero offset means absent
+var subIFDPointers = map[uint16]string{
+	0x8769: "ExifIFD",    // Exif IFD Pointer
+	0x8825: "GPSIFD",     // GPS IFD Pointer
+	0xA005: "InteropIFD", // Interoperability IFD Pointer
+}
+
+// typeName returns the TIFF type name, or its number for unknown types
+func typeName(tagType uint16) string {
+	if name, ok := tagTypeNames[tagType]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", tagType)
+}
+
+// formatEntryValue renders short values literally and long values as size and hash
+func formatEntryValue(exifData []byte, order binary.ByteOrder, entry ifdEntry) string {
+	value, ok := entry.valueBytes(exifData, order)
+	if !ok {
+		return "value=<out of range>"
+	}
+
+	switch entry.Type {
+	case 2: // ASCII
+		text := strings.TrimRight(string(value), "\x00")
+		if len(text) <= 64 {
+			return fmt.Sprintf("value=%q", text)
+		}
+	case 3: // SHORT
+		if entry.Count <= 4 {
+			values := make([]string, entry.Count)
+			for i := range values {
+				values[i] = fmt.Sprintf("%d", order.Uint16(value[i*2:]))
+			}
+			return "value=" + strings.Join(values, ",")
+		}
+	case 4: // LONG
+		if entry.Count <= 2 {
+			values := make([]string, entry.Count)
+			for i := range values {
+				values[i] = fmt.Sprintf("%d", order.Uint32(value[i*4:]))
+			}
+			return "value=" + strings.Join(values, ",")
+		}
+	case 5: // RATIONAL
+		if entry.Count <= 3 {
+			values := make([]string, entry.Count)
+			for i := range values {
+				values[i] = fmt.Sprintf("%d/%d", order.Uint32(value[i*8:]), order.Uint32(value[i*8+4:]))
+			}
+			return "value=" + strings.Join(values, ",")
+		}
+	}
+	return fmt.Sprintf("size=%d sha256=%s", len(value), shortHash(value))
+}
+
+// shortHash returns the first 8 hex digits of the SHA-256 of data
+func shortHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:4])
+}
@@ -0,0 +1,128 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden structure dumps in testdata/golden")
+
+// TestStripGolden compares the structure of every stripped fixture against a golden dump.
+// Run `go test -run TestStripGolden -update` after an intentional output change.
+func TestStripGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to list test files: %v", err)
+	}
+	if len(files) == 0 {
+		t.Skip("no test data found, run `make data` first")
+	}
+
+	for _, inputPath := range files {
+		name := filepath.Base(inputPath)
+		t.Run(name, func(t *testing.T) {
+			jpegData, err := os.ReadFile(inputPath)
+			if err != nil {
+				t.Fatalf("Failed to read test file %s: %v", name, err)
+			}
+
+			cleanedData, _, err := Strip(jpegData)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+
+			var dump bytes.Buffer
+			if err := DumpStructure(&dump, cleanedData); err != nil {
+				t.Fatalf("DumpStructure failed: %v", err)
+			}
+
+			goldenPath := filepath.Join("testdata", "golden", strings.TrimSuffix(name, ".jpg")+".txt")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+					t.Fatalf("Failed to create golden directory: %v", err)
+				}
+				if err := os.WriteFile(goldenPath, dump.Bytes(), 0o644); err != nil {
+					t.Fatalf("Failed to write golden file: %v", err)
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create): %v", err)
+			}
+			if !bytes.Equal(dump.Bytes(), expected) {
+				t.Errorf("Structure differs from %s\n--- got ---\n%s--- want ---\n%s", goldenPath, dump.String(), expected)
+			}
+		})
+	}
+}
+
+func TestDumpStructure(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	var first, second bytes.Buffer
+	if err := DumpStructure(&first, jpegData); err != nil {
+		t.Fatalf("DumpStructure failed: %v", err)
+	}
+	if err := DumpStructure(&second, jpegData); err != nil {
+		t.Fatalf("DumpStructure failed: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Error("Expected DumpStructure output to be stable")
+	}
+
+	expectedLines := []string{
+		"SOI size=0",
+		"kind=EXIF",
+		"  IFD0 0x010F ASCII count=11 value=\"TestCamera\"",
+		"  GPSIFD 0x0002 RATIONAL count=3",
+		"  IFD1 0x0202 LONG count=1",
+		"kind=XMP",
+		"EOI size=0",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(first.String(), line) {
+			t.Errorf("Expected dump to contain %q", line)
+		}
+	}
+
+	if err := DumpStructure(&first, []byte("not a jpeg")); err == nil {
+		t.Error("Expected error for invalid data")
+	}
+}
+
+func TestDumpStructureZeroSubIFDOffsets(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	e := newTestExif(binary.LittleEndian)
+	e.ifd0 = []testTag{e.ascii(0x010F, "TestCamera"), e.long(0x8769, 0), e.long(0x8825, 0), e.long(0xA005, 0)}
+	exifData := e.build()
+	// build points the Exif and GPS entries at their directories, so zero them afterwards
+	for i := 1; i <= 2; i++ {
+		binary.LittleEndian.PutUint32(exifData[len(ExifHeader)+8+2+i*12+8:], 0)
+	}
+
+	var b bytes.Buffer
+	if err := DumpStructure(&b, insertSegment(jpegData, 0xE1, exifData)); err != nil {
+		t.Fatalf("DumpStructure failed: %v", err)
+	}
+	for _, name := range []string{"ExifIFD", "GPSIFD", "InteropIFD"} {
+		if strings.Contains(b.String(), "  "+name) {
+			t.Errorf("Expected a zero %s pointer to be skipped, got:\n%s", name, b.String())
+		}
+	}
+	if !strings.Contains(b.String(), "  IFD0 0x8769 LONG count=1 value=0") {
+		t.Errorf("Expected the pointer entries themselves to be listed, got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,59 @@
+package jpegmetawebstrip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTimeout is returned when processing exceeds the limit set by WithTimeout
+var ErrTimeout = errors.New("processing timed out")
+
+// ErrFrameChanged is returned when the output frame header (dimensions, precision or
+// component count) differs from the input, which indicates a rewrite bug
+var ErrFrameChanged = errors.New("frame header changed during rewrite")
+
+// UnknownMarkerError is returned in strict mode when segments with unrecognized markers are found
+type UnknownMarkerError struct {
+	Markers []byte
+}
+
+func (e *UnknownMarkerError) Error() string {
+	names := make([]string, len(e.Markers))
+	for i, marker := range e.Markers {
+		names[i] = fmt.Sprintf("0x%02X", marker)
+	}
+	return fmt.Sprintf("unknown JPEG markers: %s", strings.Join(names, ", "))
+}
+
+// IFDLimitError is returned with WithStrictIFDLimits when EXIF directories nest too deeply,
+// hold too many entries or loop. Without that option such EXIF segments are dropped instead.
+type IFDLimitError struct {
+	Reason string
+}
+
+func (e *IFDLimitError) Error() string {
+	return "EXIF exceeds IFD limits: " + e.Reason
+}
+
+// RawImageError is returned when the input is a raw camera file (DNG, CR2, NEF, ARW, ORF,
+// RW2, RAF, ...) or a plain TIFF rather than a JPEG. With WithEmbeddedPreview, Strip cleans
+// the largest embedded JPEG preview instead and this error only reports raw files without one.
+type RawImageError struct {
+	Format string
+}
+
+func (e *RawImageError) Error() string {
+	return fmt.Sprintf("input is a %s file, not a JPEG", e.Format)
+}
+
+// ColorShiftError is returned when WithColorVerify detects that the decoded colors of the
+// output drift from the input by more than the configured tolerance
+type ColorShiftError struct {
+	Delta     float64
+	Tolerance float64
+}
+
+func (e *ColorShiftError) Error() string {
+	return fmt.Sprintf("decoded colors shifted by %.4f (tolerance %.4f)", e.Delta, e.Tolerance)
+}
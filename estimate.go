@@ -0,0 +1,93 @@
+package jpegmetawebstrip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// estimateZ is the two-sided 95% quantile of the normal distribution
+const estimateZ = 1.959964
+
+// SavingsEstimate is the result of EstimateCorpusSavings
+type SavingsEstimate struct {
+	// Samples is the number of images drawn; Failed counts those that could not be parsed,
+	// which are included with zero savings because Strip would not change them
+	Samples int
+	Failed  int
+	// MeanSavings is the average number of bytes saved per image, and Low and High bound it
+	// at 95% confidence
+	MeanSavings float64
+	Low         float64
+	High        float64
+	// MeanSize is the average input size, and Ratio the share of all sampled bytes saved
+	MeanSize float64
+	Ratio    float64
+}
+
+// EstimateCorpusSavings draws n images from sampler, analyzes what opts would remove from
+// each without producing output, and estimates the savings per image with a 95% confidence
+// interval. sampler should return random images; io.EOF ends sampling early, while other
+// errors abort the estimate.
+func EstimateCorpusSavings(sampler func() ([]byte, error), n int, opts ...Option) (*SavingsEstimate, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("sample size must be at least 1, got %d", n)
+	}
+	o := newOptions(opts)
+
+	estimate := &SavingsEstimate{}
+	var sum, sumSquares, totalSize float64
+	for estimate.Samples < n {
+		data, err := sampler()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to draw sample %d: %w", estimate.Samples+1, err)
+		}
+		estimate.Samples++
+		totalSize += float64(len(data))
+
+		saved, ok := analyzeSavings(data, o)
+		if !ok {
+			estimate.Failed++
+		}
+		sum += saved
+		sumSquares += saved * saved
+	}
+	if estimate.Samples == 0 {
+		return nil, fmt.Errorf("sampler returned no images")
+	}
+
+	count := float64(estimate.Samples)
+	estimate.MeanSavings = sum / count
+	estimate.MeanSize = totalSize / count
+	if totalSize > 0 {
+		estimate.Ratio = sum / totalSize
+	}
+	halfWidth := 0.0
+	if estimate.Samples > 1 {
+		variance := (sumSquares - sum*sum/count) / (count - 1)
+		halfWidth = estimateZ * math.Sqrt(math.Max(variance, 0)/count)
+	}
+	estimate.Low = math.Max(estimate.MeanSavings-halfWidth, 0)
+	estimate.High = estimate.MeanSavings + halfWidth
+	return estimate, nil
+}
+
+// Extrapolate scales the estimate to a corpus of population images and returns the total
+// bytes saved with its 95% confidence bounds
+func (e *SavingsEstimate) Extrapolate(population int64) (total, low, high float64) {
+	p := float64(population)
+	return e.MeanSavings * p, e.Low * p, e.High * p
+}
+
+// analyzeSavings returns the bytes opts would remove from data, without writing output
+func analyzeSavings(data []byte, o *options) (float64, bool) {
+	result, err := analyze(data, o)
+	if err != nil {
+		return 0, false
+	}
+	return float64(result.Total), true
+}
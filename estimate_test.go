@@ -0,0 +1,82 @@
+package jpegmetawebstrip
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEstimateCorpusSavings(t *testing.T) {
+	corpus, expectedSum := loadTestCorpus(t)
+	corpus = append(corpus, []byte("not a jpeg"))
+
+	// Drawing the whole corpus once gives its exact mean
+	next := 0
+	sampler := func() ([]byte, error) {
+		if next == len(corpus) {
+			return nil, io.EOF
+		}
+		next++
+		return corpus[next-1], nil
+	}
+	estimate, err := EstimateCorpusSavings(sampler, 1000)
+	if err != nil {
+		t.Fatalf("EstimateCorpusSavings failed: %v", err)
+	}
+	if estimate.Samples != len(corpus) || estimate.Failed != 1 {
+		t.Errorf("Expected %d samples with 1 failure, got %d with %d", len(corpus), estimate.Samples, estimate.Failed)
+	}
+	if want := expectedSum / float64(len(corpus)); math.Abs(estimate.MeanSavings-want) > 1e-9 {
+		t.Errorf("Expected mean %.2f, got %.2f", want, estimate.MeanSavings)
+	}
+	if !(estimate.Low <= estimate.MeanSavings && estimate.MeanSavings < estimate.High) {
+		t.Errorf("Expected the mean inside a non-empty interval, got %.2f [%.2f, %.2f]", estimate.MeanSavings, estimate.Low, estimate.High)
+	}
+	if estimate.Ratio <= 0 || estimate.Ratio >= 1 {
+		t.Errorf("Expected a savings ratio in (0, 1), got %v", estimate.Ratio)
+	}
+
+	total, low, high := estimate.Extrapolate(40_000_000)
+	if total != estimate.MeanSavings*40_000_000 || low > total || high < total {
+		t.Errorf("Unexpected extrapolation %.0f [%.0f, %.0f]", total, low, high)
+	}
+}
+
+// loadTestCorpus reads every fixture and returns them with the sum of their Strip savings
+func loadTestCorpus(t *testing.T) ([][]byte, float64) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpus := make([][]byte, 0, len(files))
+	var sum float64
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		_, result, err := Strip(data)
+		if err != nil {
+			t.Fatalf("Strip failed for %s: %v", file, err)
+		}
+		corpus = append(corpus, data)
+		sum += float64(result.Total)
+	}
+	return corpus, sum
+}
+
+func TestEstimateCorpusSavingsErrors(t *testing.T) {
+	if _, err := EstimateCorpusSavings(func() ([]byte, error) { return nil, io.EOF }, 10); err == nil {
+		t.Error("Expected an error when the sampler is empty")
+	}
+	if _, err := EstimateCorpusSavings(func() ([]byte, error) { return nil, nil }, 0); err == nil {
+		t.Error("Expected an error for a zero sample size")
+	}
+	failure := errors.New("bucket unavailable")
+	if _, err := EstimateCorpusSavings(func() ([]byte, error) { return nil, failure }, 10); !errors.Is(err, failure) {
+		t.Errorf("Expected the sampler error to be wrapped, got %v", err)
+	}
+}
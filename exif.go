@@ -0,0 +1,92 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// tiffHeaderPos is the position of the TIFF header inside an EXIF APP1 payload
+const tiffHeaderPos = 6
+
+// ifdEntry is a single 12-byte IFD directory entry
+type ifdEntry struct {
+	Tag   uint16
+	Type  uint16
+	Count uint32
+	// Value holds the raw 4-byte value/offset field
+	Value [4]byte
+	// Pos is the position of the entry within the EXIF payload
+	Pos int
+}
+
+// exifByteOrder validates the EXIF and TIFF headers and returns the byte order and IFD0 position
+func exifByteOrder(exifData []byte) (binary.ByteOrder, int, error) {
+	if len(exifData) < tiffHeaderPos || string(exifData[0:tiffHeaderPos]) != ExifHeader {
+		return nil, 0, fmt.Errorf("invalid EXIF header")
+	}
+	if len(exifData) < tiffHeaderPos+8 {
+		return nil, 0, fmt.Errorf("invalid TIFF header")
+	}
+
+	var order binary.ByteOrder
+	switch string(exifData[tiffHeaderPos : tiffHeaderPos+2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, fmt.Errorf("invalid TIFF byte order")
+	}
+
+	ifd0Pos := tiffHeaderPos + int(order.Uint32(exifData[tiffHeaderPos+4:tiffHeaderPos+8]))
+	return order, ifd0Pos, nil
+}
+
+// readIFD reads the entries of the IFD at ifdPos and the offset of the next IFD (0 if none)
+func readIFD(exifData []byte, order binary.ByteOrder, ifdPos int) ([]ifdEntry, uint32, error) {
+	if ifdPos < tiffHeaderPos || len(exifData) < ifdPos+2 {
+		return nil, 0, fmt.Errorf("IFD at %d out of range", ifdPos)
+	}
+
+	entryCount := int(order.Uint16(exifData[ifdPos : ifdPos+2]))
+	entries := make([]ifdEntry, 0, entryCount)
+	for i := 0; i < entryCount; i++ {
+		entryPos := ifdPos + 2 + i*12
+		if len(exifData) < entryPos+12 {
+			return entries, 0, fmt.Errorf("IFD entry %d truncated", i)
+		}
+		entry := ifdEntry{
+			Tag:   order.Uint16(exifData[entryPos : entryPos+2]),
+			Type:  order.Uint16(exifData[entryPos+2 : entryPos+4]),
+			Count: order.Uint32(exifData[entryPos+4 : entryPos+8]),
+			Pos:   entryPos,
+		}
+		copy(entry.Value[:], exifData[entryPos+8:entryPos+12])
+		entries = append(entries, entry)
+	}
+
+	nextPos := ifdPos + 2 + entryCount*12
+	if len(exifData) < nextPos+4 {
+		return entries, 0, nil
+	}
+	return entries, order.Uint32(exifData[nextPos : nextPos+4]), nil
+}
+
+// valueBytes returns the value data of an entry, inline or at its offset
+func (e ifdEntry) valueBytes(exifData []byte, order binary.ByteOrder) ([]byte, bool) {
+	size := getTagDataSize(e.Type, e.Count)
+	if size <= 4 {
+		return e.Value[:size], true
+	}
+	start := tiffHeaderPos + int(order.Uint32(e.Value[:]))
+	end := start + int(size)
+	if start < tiffHeaderPos || end > len(exifData) || end < start {
+		return nil, false
+	}
+	return exifData[start:end], true
+}
+
+// offset interprets the value field as a LONG offset
+func (e ifdEntry) offset(order binary.ByteOrder) uint32 {
+	return order.Uint32(e.Value[:])
+}
@@ -0,0 +1,313 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// testTag is an IFD entry used to build synthetic EXIF data
+type testTag struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// testExif builds synthetic EXIF payloads with optional Exif, GPS and IFD1 directories
+type testExif struct {
+	order     binary.ByteOrder
+	ifd0      []testTag
+	exifIFD   []testTag
+	gpsIFD    []testTag
+	ifd1      []testTag
+	thumbnail []byte
+}
+
+func newTestExif(order binary.ByteOrder) *testExif {
+	return &testExif{order: order}
+}
+
+func (e *testExif) ascii(tag uint16, s string) testTag {
+	return testTag{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func (e *testExif) short(tag uint16, v uint16) testTag {
+	b := make([]byte, 2)
+	e.order.PutUint16(b, v)
+	return testTag{tag, 3, 1, b}
+}
+
+func (e *testExif) long(tag uint16, v uint32) testTag {
+	b := make([]byte, 4)
+	e.order.PutUint32(b, v)
+	return testTag{tag, 4, 1, b}
+}
+
+func (e *testExif) rational(tag uint16, num, den uint32) testTag {
+	b := make([]byte, 8)
+	e.order.PutUint32(b, num)
+	e.order.PutUint32(b[4:], den)
+	return testTag{tag, 5, 1, b}
+}
+
+func (e *testExif) undefined(tag uint16, data []byte) testTag {
+	return testTag{tag, 7, uint32(len(data)), data}
+}
+
+// ifdSize returns the size of an IFD including its out-of-line value area (0 when omitted)
+func ifdSize(tags []testTag) int {
+	if len(tags) == 0 {
+		return 0
+	}
+	size := 2 + len(tags)*12 + 4
+	for _, tag := range tags {
+		if len(tag.value) > 4 {
+			size += len(tag.value) + len(tag.value)%2
+		}
+	}
+	return size
+}
+
+// build serializes the EXIF payload including the "Exif\0\0" header
+func (e *testExif) build() []byte {
+	ifd0 := append([]testTag{}, e.ifd0...)
+	ifd1 := append([]testTag{}, e.ifd1...)
+	if len(e.exifIFD) > 0 {
+		ifd0 = append(ifd0, e.long(0x8769, 0))
+	}
+	if len(e.gpsIFD) > 0 {
+		ifd0 = append(ifd0, e.long(0x8825, 0))
+	}
+	if len(e.thumbnail) > 0 {
+		ifd1 = append(ifd1, e.long(0x0201, 0), e.long(0x0202, uint32(len(e.thumbnail))))
+	}
+
+	// Layout: header, IFD0, Exif IFD, GPS IFD, IFD1, thumbnail (offsets relative to TIFF header)
+	ifd0Offset := 8
+	exifOffset := ifd0Offset + ifdSize(ifd0)
+	gpsOffset := exifOffset + ifdSize(e.exifIFD)
+	ifd1Offset := gpsOffset + ifdSize(e.gpsIFD)
+	thumbOffset := ifd1Offset + ifdSize(ifd1)
+
+	for i := range ifd0 {
+		switch ifd0[i].tag {
+		case 0x8769:
+			ifd0[i] = e.long(0x8769, uint32(exifOffset))
+		case 0x8825:
+			ifd0[i] = e.long(0x8825, uint32(gpsOffset))
+		}
+	}
+	for i := range ifd1 {
+		if ifd1[i].tag == 0x0201 {
+			ifd1[i] = e.long(0x0201, uint32(thumbOffset))
+		}
+	}
+
+	tiff := make([]byte, 8)
+	if e.order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	e.order.PutUint16(tiff[2:], 42)
+	e.order.PutUint32(tiff[4:], uint32(ifd0Offset))
+
+	next := uint32(0)
+	if len(ifd1) > 0 {
+		next = uint32(ifd1Offset)
+	}
+	tiff = e.appendIFD(tiff, ifd0, next)
+	if len(e.exifIFD) > 0 {
+		tiff = e.appendIFD(tiff, e.exifIFD, 0)
+	}
+	if len(e.gpsIFD) > 0 {
+		tiff = e.appendIFD(tiff, e.gpsIFD, 0)
+	}
+	if len(ifd1) > 0 {
+		tiff = e.appendIFD(tiff, ifd1, 0)
+		tiff = append(tiff, e.thumbnail...)
+	}
+
+	return append([]byte(ExifHeader), tiff...)
+}
+
+// appendIFD serializes an IFD and its value area at the end of tiff
+func (e *testExif) appendIFD(tiff []byte, tags []testTag, next uint32) []byte {
+	start := len(tiff)
+	dataPos := start + 2 + len(tags)*12 + 4
+
+	ifd := make([]byte, 2+len(tags)*12+4)
+	e.order.PutUint16(ifd, uint16(len(tags)))
+	var data []byte
+	for i, tag := range tags {
+		entry := ifd[2+i*12:]
+		e.order.PutUint16(entry, tag.tag)
+		e.order.PutUint16(entry[2:], tag.typ)
+		e.order.PutUint32(entry[4:], tag.count)
+		if len(tag.value) <= 4 {
+			copy(entry[8:12], tag.value)
+			continue
+		}
+		e.order.PutUint32(entry[8:], uint32(dataPos+len(data)))
+		data = append(data, tag.value...)
+		if len(tag.value)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	e.order.PutUint32(ifd[2+len(tags)*12:], next)
+
+	tiff = append(tiff, ifd...)
+	return append(tiff, data...)
+}
+
+// findTag returns the first IFD0 or Exif IFD entry with the given tag
+func findTag(t *testing.T, exifData []byte, tag uint16) (ifdEntry, bool) {
+	t.Helper()
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		t.Fatalf("Invalid EXIF: %v", err)
+	}
+	entries, _, err := readIFD(exifData, order, ifd0Pos)
+	if err != nil {
+		t.Fatalf("Failed to read IFD0: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Tag == 0x8769 {
+			sub, _, err := readIFD(exifData, order, tiffHeaderPos+int(entry.offset(order)))
+			if err != nil {
+				t.Fatalf("Failed to read Exif IFD: %v", err)
+			}
+			entries = append(entries, sub...)
+		}
+	}
+	for _, entry := range entries {
+		if entry.Tag == tag {
+			return entry, true
+		}
+	}
+	return ifdEntry{}, false
+}
+
+func TestReadIFD(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			e := newTestExif(order)
+			e.ifd0 = []testTag{e.short(0x0112, 6), e.ascii(0x010F, "TestCamera")}
+			e.exifIFD = []testTag{e.short(0xA001, 1)}
+			e.thumbnail = []byte{0xFF, 0xD8, 0xFF, 0xD9}
+			exifData := e.build()
+
+			gotOrder, ifd0Pos, err := exifByteOrder(exifData)
+			if err != nil {
+				t.Fatalf("exifByteOrder failed: %v", err)
+			}
+			if gotOrder != order {
+				t.Errorf("Expected %v byte order, got %v", order, gotOrder)
+			}
+
+			entries, next, err := readIFD(exifData, order, ifd0Pos)
+			if err != nil {
+				t.Fatalf("readIFD failed: %v", err)
+			}
+			if len(entries) != 3 || next == 0 {
+				t.Fatalf("Expected 3 IFD0 entries and an IFD1 link, got %d entries, next=%d", len(entries), next)
+			}
+
+			makeValue, ok := entries[1].valueBytes(exifData, order)
+			if !ok || string(makeValue) != "TestCamera\x00" {
+				t.Errorf("Unexpected Make value %q", makeValue)
+			}
+			if entry, ok := findTag(t, exifData, 0xA001); !ok || order.Uint16(entry.Value[:]) != 1 {
+				t.Error("Expected ColorSpace in Exif IFD")
+			}
+			if getThumbnailLength(exifData) != 4 {
+				t.Errorf("Expected thumbnail length 4, got %d", getThumbnailLength(exifData))
+			}
+		})
+	}
+
+	if _, _, err := exifByteOrder([]byte("Exif\x00\x00XX\x00\x2a\x00\x00\x00\x08")); err == nil {
+		t.Error("Expected error for invalid byte order")
+	}
+}
+
+func TestRemoveGPSFromExif(t *testing.T) {
+	coordinates := []byte("N\x00\x00\x00")
+	for _, withStrips := range []bool{false, true} {
+		e := newTestExif(binary.BigEndian)
+		e.ifd0 = []testTag{e.short(0x0112, 1)}
+		if withStrips {
+			// TIFF strip offsets prevent rebuilding, so the GPS data must be wiped in place
+			e.ifd0 = append(e.ifd0, e.long(0x0111, 8))
+		}
+		e.gpsIFD = []testTag{
+			e.undefined(0x0001, coordinates),
+			{tag: 0x0002, typ: 5, count: 3, value: []byte{0, 0, 0, 35, 0, 0, 0, 1, 0, 0, 0, 41, 0, 0, 0, 1, 0, 0, 0, 59, 0, 0, 0, 1}},
+		}
+		exifData := e.build()
+
+		cleaned, removed, size := removeGPSFromExif(exifData)
+		if !removed {
+			t.Fatal("Expected GPS to be removed")
+		}
+		// Pointer entry, a directory of two entries and 24 bytes of latitude
+		if want := int64(12 + 2 + 2*12 + 4 + 24); size != want {
+			t.Errorf("Expected %d bytes removed, got %d", want, size)
+		}
+		if bytes.Contains(cleaned, []byte{0, 0, 0, 35, 0, 0, 0, 1, 0, 0, 0, 41}) {
+			t.Error("Latitude values are still present")
+		}
+		if ids := exifTagIDs(cleaned); containsTag(ids, 0x8825) {
+			t.Errorf("GPS pointer still listed: %v", ids)
+		}
+
+		result := &Result{}
+		stripped, _, _ := cleanExifSegment(exifData, result, newOptions(nil))
+		if withStrips != (len(stripped) == len(exifData)) {
+			t.Errorf("Expected the payload to shrink only without strip offsets: %d -> %d", len(exifData), len(stripped))
+		}
+		if result.Removed.ExifGPS != size {
+			t.Errorf("Expected ExifGPS %d, got %d", size, result.Removed.ExifGPS)
+		}
+	}
+}
+
+func TestRemoveMakerNote(t *testing.T) {
+	note := append([]byte("Nikon\x00\x02\x10\x00\x00MM\x00\x2a\x00\x00\x00\x08"), bytes.Repeat([]byte("SERIAL-4711 "), 100)...)
+	for _, withStrips := range []bool{false, true} {
+		e := newTestExif(binary.LittleEndian)
+		e.ifd0 = []testTag{e.short(0x0112, 6)}
+		if withStrips {
+			e.ifd0 = append(e.ifd0, e.long(0x0111, 8))
+		}
+		e.exifIFD = []testTag{e.short(0xA001, 1), e.undefined(0x927C, note)}
+		exifData := e.build()
+
+		result := &Result{}
+		cleaned, modified, _ := cleanExifSegment(exifData, result, newOptions(nil))
+		if !modified {
+			t.Fatal("Expected the MakerNote to be removed")
+		}
+		// The data block is wiped even when the EXIF cannot be compacted
+		if bytes.Contains(cleaned, []byte("SERIAL-4711")) || bytes.Contains(cleaned, []byte("Nikon")) {
+			t.Error("MakerNote data is still present")
+		}
+		if want := int64(12 + len(note)); result.Removed.CameraInfo != want {
+			t.Errorf("Expected CameraInfo %d, got %d", want, result.Removed.CameraInfo)
+		}
+		if shrunk := len(exifData) - len(cleaned); withStrips != (shrunk == 0) || (!withStrips && shrunk < len(note)) {
+			t.Errorf("Expected the payload to be compacted only without strip offsets, shrunk by %d", shrunk)
+		}
+		if orientation, ok := exifOrientation(cleaned); !ok || orientation != 6 {
+			t.Error("Orientation was lost")
+		}
+	}
+
+	// Thumbnail-only mode leaves the MakerNote alone
+	e := newTestExif(binary.LittleEndian)
+	e.exifIFD = []testTag{e.undefined(0x927C, note)}
+	if cleaned, _, _ := cleanExifSegment(e.build(), &Result{}, newOptions([]Option{WithThumbnailOnly()})); !bytes.Contains(cleaned, []byte("SERIAL-4711")) {
+		t.Error("Expected the MakerNote to be kept in thumbnail-only mode")
+	}
+}
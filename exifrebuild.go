@@ -0,0 +1,287 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// exifSubIFDTags are the pointer tags whose directories are rebuilt along with their parent
+var exifSubIFDTags = map[uint16]bool{
+	0x8769: true, // Exif IFD Pointer
+	0x8825: true, // GPS IFD Pointer
+	0xA005: true, // Interoperability IFD Pointer
+}
+
+// Tags whose values hold offsets into the TIFF data that a rebuild cannot relocate
+const (
+	exifMakerNoteTag   = 0x927C // vendor directories with absolute offsets
+	exifSubIFDsTag     = 0x014A // raw/TIFF sub-images
+	exifStripOffsetTag = 0x0111 // uncompressed TIFF thumbnails
+)
+
+// Thumbnail location tags in IFD1
+const (
+	exifThumbnailOffsetTag = 0x0201
+	exifThumbnailLengthTag = 0x0202
+)
+
+// exifField is an IFD entry with its value bytes, in the byte order of the source
+type exifField struct {
+	Tag   uint16
+	Type  uint16
+	Count uint32
+	Data  []byte
+	// Sub is the directory a pointer tag refers to
+	Sub *exifDirectory
+}
+
+// exifDirectory is an IFD in an exifTree
+type exifDirectory struct {
+	Fields []exifField
+}
+
+// exifTree is a parsed EXIF payload that can be serialized again in canonical form
+type exifTree struct {
+	order     binary.ByteOrder
+	ifd0      *exifDirectory
+	ifd1      *exifDirectory
+	thumbnail []byte
+}
+
+// parseExifTree reads IFD0, its Exif, GPS and Interoperability directories, and IFD1 with
+// its JPEG thumbnail. Cleared (zeroed) entries and null pointers are dropped. It fails for
+// structures whose values would move incorrectly, such as a MakerNote or TIFF strips.
+func parseExifTree(exifData []byte) (*exifTree, error) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil, err
+	}
+	tree := &exifTree{order: order}
+	visited := make(map[int]bool)
+
+	var next uint32
+	tree.ifd0, next, err = parseExifDirectory(exifData, order, ifd0Pos, visited)
+	if err != nil {
+		return nil, err
+	}
+	if next == 0 {
+		return tree, nil
+	}
+
+	tree.ifd1, _, err = parseExifDirectory(exifData, order, tiffHeaderPos+int(next), visited)
+	if err != nil {
+		return nil, err
+	}
+	var thumbOffset, thumbLength int
+	for _, field := range tree.ifd1.Fields {
+		switch field.Tag {
+		case exifThumbnailOffsetTag:
+			thumbOffset = tiffHeaderPos + int(order.Uint32(field.Data))
+		case exifThumbnailLengthTag:
+			thumbLength = int(order.Uint32(field.Data))
+		}
+	}
+	for _, field := range tree.ifd1.Fields {
+		if field.Tag != exifThumbnailOffsetTag {
+			continue
+		}
+		if thumbOffset <= tiffHeaderPos || thumbLength <= 0 || thumbOffset+thumbLength > len(exifData) {
+			return nil, fmt.Errorf("thumbnail cannot be located")
+		}
+		tree.thumbnail = exifData[thumbOffset : thumbOffset+thumbLength]
+	}
+	return tree, nil
+}
+
+// parseExifDirectory reads the IFD at pos and the directories its pointer tags refer to
+func parseExifDirectory(exifData []byte, order binary.ByteOrder, pos int, visited map[int]bool) (*exifDirectory, uint32, error) {
+	if visited[pos] {
+		return nil, 0, fmt.Errorf("IFD at %d is referenced more than once", pos)
+	}
+	visited[pos] = true
+
+	entries, next, err := readIFD(exifData, order, pos)
+	if err != nil {
+		return nil, 0, err
+	}
+	dir := &exifDirectory{}
+	for _, entry := range entries {
+		switch {
+		case entry.Tag == 0:
+			// Cleared by a remover
+			continue
+		case entry.Tag == exifMakerNoteTag, entry.Tag == exifSubIFDsTag, entry.Tag == exifStripOffsetTag:
+			return nil, 0, fmt.Errorf("tag 0x%04X holds offsets that cannot be relocated", entry.Tag)
+		case entry.Type < 1 || entry.Type > 12:
+			return nil, 0, fmt.Errorf("tag 0x%04X has unknown type %d", entry.Tag, entry.Type)
+		}
+
+		field := exifField{Tag: entry.Tag, Type: entry.Type, Count: entry.Count}
+		if exifSubIFDTags[entry.Tag] {
+			if entry.offset(order) == 0 {
+				// A cleared pointer refers to nothing
+				continue
+			}
+			field.Sub, _, err = parseExifDirectory(exifData, order, tiffHeaderPos+int(entry.offset(order)), visited)
+			if err != nil {
+				return nil, 0, err
+			}
+		} else {
+			value, ok := entry.valueBytes(exifData, order)
+			if !ok {
+				return nil, 0, fmt.Errorf("tag 0x%04X value out of range", entry.Tag)
+			}
+			field.Data = value
+		}
+		dir.Fields = append(dir.Fields, field)
+	}
+	return dir, next, nil
+}
+
+// bytes serializes the tree canonically: entries sorted by tag ID, values of up to four
+// bytes stored inline and larger values word-aligned right after their directory,
+// followed by sub-directories, IFD1 and the thumbnail
+func (t *exifTree) bytes() []byte {
+	b := make([]byte, 8)
+	if t.order == binary.LittleEndian {
+		copy(b, "II")
+	} else {
+		copy(b, "MM")
+	}
+	t.order.PutUint16(b[2:], 42)
+	t.order.PutUint32(b[4:], 8)
+
+	next, _ := t.writeDirectory(&b, t.ifd0)
+	if t.ifd1 != nil {
+		alignWord(&b)
+		t.order.PutUint32(b[next:], uint32(len(b)))
+		_, positions := t.writeDirectory(&b, t.ifd1)
+		if pos, ok := positions[exifThumbnailOffsetTag]; ok && t.thumbnail != nil {
+			alignWord(&b)
+			t.order.PutUint32(b[pos+8:], uint32(len(b)))
+			b = append(b, t.thumbnail...)
+		}
+	}
+	return append([]byte(ExifHeader), b...)
+}
+
+// writeDirectory appends dir and its values and sub-directories to b. It returns the
+// position of the next-IFD field and the entry position of every tag.
+func (t *exifTree) writeDirectory(b *[]byte, dir *exifDirectory) (int, map[uint16]int) {
+	fields := append([]exifField{}, dir.Fields...)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Tag < fields[j].Tag })
+
+	start := len(*b)
+	*b = append(*b, make([]byte, 2+12*len(fields)+4)...)
+	t.order.PutUint16((*b)[start:], uint16(len(fields)))
+
+	positions := make(map[uint16]int, len(fields))
+	for i, field := range fields {
+		pos := start + 2 + 12*i
+		positions[field.Tag] = pos
+		t.order.PutUint16((*b)[pos:], field.Tag)
+		t.order.PutUint16((*b)[pos+2:], field.Type)
+		t.order.PutUint32((*b)[pos+4:], field.Count)
+		switch {
+		case field.Sub != nil:
+			// Patched once the sub-directory is written
+		case len(field.Data) <= 4:
+			copy((*b)[pos+8:pos+12], field.Data)
+		default:
+			alignWord(b)
+			t.order.PutUint32((*b)[pos+8:], uint32(len(*b)))
+			*b = append(*b, field.Data...)
+		}
+	}
+
+	for _, field := range fields {
+		if field.Sub == nil {
+			continue
+		}
+		alignWord(b)
+		t.order.PutUint32((*b)[positions[field.Tag]+8:], uint32(len(*b)))
+		t.writeDirectory(b, field.Sub)
+	}
+	return start + 2 + 12*len(fields), positions
+}
+
+// alignWord pads b to an even length, as TIFF requires for offsets
+func alignWord(b *[]byte) {
+	if len(*b)%2 == 1 {
+		*b = append(*b, 0)
+	}
+}
+
+// exifTypeUnits maps TIFF field types to the size of the units that are byte-swapped
+// when the byte order changes; BYTE, ASCII, SBYTE and UNDEFINED are left as they are
+var exifTypeUnits = map[uint16]int{
+	3: 2, 8: 2, // SHORT, SSHORT
+	4: 4, 9: 4, 11: 4, // LONG, SLONG, FLOAT
+	5: 4, 10: 4, // RATIONAL, SRATIONAL as two LONGs
+	12: 8, // DOUBLE
+}
+
+// UNDEFINED tags whose content still follows the byte order of the file
+const (
+	exifUserCommentTag = 0x9286 // UTF-16 text after the "UNICODE\x00" prefix
+	exifCFAPatternTag  = 0xA302 // two SHORT dimensions before the pattern bytes
+)
+
+// setByteOrder converts every value of the tree to order
+func (t *exifTree) setByteOrder(order binary.ByteOrder) {
+	if t.order == order {
+		return
+	}
+	for _, dir := range []*exifDirectory{t.ifd0, t.ifd1} {
+		if dir != nil {
+			swapDirectory(dir)
+		}
+	}
+	t.order = order
+}
+
+// swapDirectory byte-swaps the values of dir and its sub-directories in place. Values are
+// copied first because they share memory with the source payload.
+func swapDirectory(dir *exifDirectory) {
+	for i := range dir.Fields {
+		field := &dir.Fields[i]
+		if field.Sub != nil {
+			swapDirectory(field.Sub)
+			continue
+		}
+		field.Data = append([]byte{}, field.Data...)
+		switch {
+		case exifTypeUnits[field.Type] > 0:
+			swapUnits(field.Data, exifTypeUnits[field.Type])
+		case field.Tag == exifUserCommentTag && bytes.HasPrefix(field.Data, []byte("UNICODE\x00")):
+			swapUnits(field.Data[8:], 2)
+		case field.Tag == exifCFAPatternTag && len(field.Data) >= 4:
+			swapUnits(field.Data[:4], 2)
+		}
+	}
+}
+
+// swapUnits reverses the bytes of each size-byte unit of b; a trailing partial unit is left alone
+func swapUnits(b []byte, size int) {
+	for start := 0; start+size <= len(b); start += size {
+		for i, j := start, start+size-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+}
+
+// rebuildExif serializes an EXIF payload in canonical form, converted to order unless it
+// is nil. It returns false when the payload cannot be rebuilt safely, in which case the
+// original should be kept.
+func rebuildExif(exifData []byte, order binary.ByteOrder) ([]byte, bool) {
+	tree, err := parseExifTree(exifData)
+	if err != nil {
+		return exifData, false
+	}
+	if order != nil {
+		tree.setByteOrder(order)
+	}
+	return tree.bytes(), true
+}
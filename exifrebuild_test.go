@@ -0,0 +1,274 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestRebuildExifCanonical(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			e := newTestExif(order)
+			// Deliberately out of tag order, as some camera firmware writes them
+			e.ifd0 = []testTag{e.short(0x0128, 2), e.short(0x0112, 6), e.rational(0x011A, 300, 1), e.ascii(0x8298, "(c) Test")}
+			e.exifIFD = []testTag{e.short(0xA002, 640), e.short(0xA001, 1), e.ascii(0x9003, "2024:01:02 03:04:05")}
+			e.ifd1 = []testTag{e.short(0x0103, 6)}
+			e.thumbnail = []byte{0xFF, 0xD8, 0x01, 0x02, 0x03, 0xFF, 0xD9}
+			original := e.build()
+
+			// Clear one entry the way the removers do
+			cleared, _, _ := removeIFD0Tags(original, []uint16{0x8298})
+
+			rebuilt, ok := rebuildExif(cleared, nil)
+			if !ok {
+				t.Fatal("rebuildExif refused a plain EXIF payload")
+			}
+
+			_, ifd0Pos, _ := exifByteOrder(rebuilt)
+			entries, next, err := readIFD(rebuilt, order, ifd0Pos)
+			if err != nil {
+				t.Fatalf("Failed to read rebuilt IFD0: %v", err)
+			}
+			assertSortedTags(t, entries)
+			if _, found := findTag(t, rebuilt, 0x8298); found {
+				t.Error("cleared entry survived the rebuild")
+			}
+			resolution, _ := findTag(t, rebuilt, 0x011A)
+			if value, _ := resolution.valueBytes(rebuilt, order); order.Uint32(value) != 300 || resolution.offset(order)%2 != 0 {
+				t.Errorf("XResolution not preserved at a word boundary: %v", value)
+			}
+			date, _ := findTag(t, rebuilt, 0x9003)
+			if value, _ := date.valueBytes(rebuilt, order); string(value) != "2024:01:02 03:04:05\x00" {
+				t.Errorf("DateTimeOriginal not preserved: %q", value)
+			}
+			orientation, _ := findTag(t, rebuilt, 0x0112)
+			if order.Uint16(orientation.Value[:2]) != 6 {
+				t.Error("inline Orientation not preserved")
+			}
+
+			if next == 0 {
+				t.Fatal("IFD1 was lost")
+			}
+			ifd1, _, _ := readIFD(rebuilt, order, tiffHeaderPos+int(next))
+			assertSortedTags(t, ifd1)
+			var thumbOffset uint32
+			for _, entry := range ifd1 {
+				if entry.Tag == exifThumbnailOffsetTag {
+					thumbOffset = entry.offset(order)
+				}
+			}
+			start := tiffHeaderPos + int(thumbOffset)
+			if !bytes.Equal(rebuilt[start:start+len(e.thumbnail)], e.thumbnail) {
+				t.Error("thumbnail not relocated correctly")
+			}
+
+			// Rebuilding canonical output is a no-op
+			again, _ := rebuildExif(rebuilt, nil)
+			if !bytes.Equal(again, rebuilt) {
+				t.Error("rebuild is not idempotent")
+			}
+		})
+	}
+}
+
+// assertSortedTags fails when IFD entries are not in ascending tag order
+func assertSortedTags(t *testing.T, entries []ifdEntry) {
+	t.Helper()
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Tag < entries[j].Tag }) {
+		t.Errorf("entries not sorted by tag: %v", entries)
+	}
+}
+
+func TestRebuildExifRefusesMakerNote(t *testing.T) {
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.short(0x0112, 1)}
+	e.exifIFD = []testTag{e.undefined(0x927C, []byte("Nikon\x00\x02\x10\x00\x00MM\x00\x2a\x00\x00\x00\x08"))}
+	if _, ok := rebuildExif(e.build(), nil); ok {
+		t.Error("EXIF with a MakerNote must not be rebuilt")
+	}
+}
+
+func TestWithCanonicalExif(t *testing.T) {
+	files := []string{"with_comprehensive_mixed.jpg", "with_exif_thumbnail.jpg", "with_thumbnail_and_icc.jpg", "with_all_removable.jpg"}
+	for _, name := range files {
+		t.Run(name, func(t *testing.T) {
+			jpegData, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			plain, _, err := Strip(jpegData, WithThumbnailMaxBytes(1<<20))
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			canonical, result, err := Strip(jpegData, WithThumbnailMaxBytes(1<<20), WithCanonicalExif())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if result.FellBack {
+				t.Fatalf("canonical output failed validation: %s", result.FallbackReason)
+			}
+			assertPixelsUnchanged(t, jpegData, canonical)
+
+			// The same tags survive, now in order
+			before, err := Inspect(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := Inspect(canonical)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Pointers cleared by the GPS remover refer to nothing and are dropped
+			var sortedBefore []uint16
+			for _, tag := range before.ExifTags {
+				if !exifSubIFDTags[tag] || containsTag(after.ExifTags, tag) {
+					sortedBefore = append(sortedBefore, tag)
+				}
+			}
+			sort.Slice(sortedBefore, func(i, j int) bool { return sortedBefore[i] < sortedBefore[j] })
+			sortedAfter := append([]uint16{}, after.ExifTags...)
+			sort.Slice(sortedAfter, func(i, j int) bool { return sortedAfter[i] < sortedAfter[j] })
+			if !equalTags(sortedBefore, sortedAfter) {
+				t.Errorf("tags changed: %v -> %v", before.ExifTags, after.ExifTags)
+			}
+			if len(canonical) > len(plain) {
+				t.Errorf("canonical output grew: %d > %d", len(canonical), len(plain))
+			}
+		})
+	}
+}
+
+// equalTags compares two tag lists
+func equalTags(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsTag reports whether tags includes tag
+func containsTag(tags []uint16, tag uint16) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRebuildExifByteOrder(t *testing.T) {
+	build := func(byteOrder binary.ByteOrder) *testExif {
+		order := byteOrder.(binary.AppendByteOrder)
+		e := newTestExif(byteOrder)
+		comment := []byte("UNICODE\x00")
+		for _, r := range "Hi" {
+			comment = order.AppendUint16(comment, uint16(r))
+		}
+		e.ifd0 = []testTag{e.short(0x0112, 6), e.rational(0x011A, 300, 1), e.ascii(0x0131, "Editor 1.0")}
+		e.exifIFD = []testTag{
+			e.long(0xA002, 4000),
+			{tag: 0x9204, typ: 10, count: 1, value: order.AppendUint32(order.AppendUint32(nil, uint32(0xFFFFFFFF)), 3)}, // -1/3
+			e.undefined(0x9286, comment),
+			e.undefined(0xA302, append(order.AppendUint16(order.AppendUint16(nil, 2), 2), 0, 1, 1, 2)),
+		}
+		e.ifd1 = []testTag{e.short(0x0103, 6)}
+		e.thumbnail = []byte{0xFF, 0xD8, 0x01, 0x02, 0xFF, 0xD9}
+		return e
+	}
+
+	// Converting big-endian EXIF must give exactly what a little-endian camera would write
+	expected, ok := rebuildExif(build(binary.LittleEndian).build(), nil)
+	if !ok {
+		t.Fatal("rebuildExif refused little-endian EXIF")
+	}
+	converted, ok := rebuildExif(build(binary.BigEndian).build(), binary.LittleEndian)
+	if !ok {
+		t.Fatal("rebuildExif refused big-endian EXIF")
+	}
+	if !bytes.Equal(converted, expected) {
+		t.Errorf("converted EXIF differs:\n% X\n% X", converted, expected)
+	}
+
+	// Already little-endian input is only canonicalized
+	again, _ := rebuildExif(expected, binary.LittleEndian)
+	if !bytes.Equal(again, expected) {
+		t.Error("converting little-endian EXIF changed it")
+	}
+}
+
+func TestWithNormalizeByteOrder(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	plain, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	normalized, result, err := Strip(jpegData, WithNormalizeByteOrder())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.FellBack {
+		t.Fatalf("normalized output failed validation: %s", result.FallbackReason)
+	}
+	assertPixelsUnchanged(t, jpegData, normalized)
+
+	var exifPayload []byte
+	for _, payload := range getSegmentPayloads(t, normalized, 0xE1) {
+		if bytes.HasPrefix(payload, []byte(ExifHeader)) {
+			exifPayload = payload
+		}
+	}
+	if order, _, err := exifByteOrder(exifPayload); err != nil || order != binary.LittleEndian {
+		t.Fatalf("Expected little-endian EXIF, got %v (%v)", order, err)
+	}
+
+	before, _ := Inspect(plain)
+	after, _ := Inspect(normalized)
+	orientation := func(data []byte) uint16 {
+		for _, payload := range getSegmentPayloads(t, data, 0xE1) {
+			if value, ok := exifOrientation(payload); ok {
+				return value
+			}
+		}
+		return 0
+	}
+	if len(after.ExifTags) == 0 || orientation(plain) != orientation(normalized) {
+		t.Errorf("Expected tags and orientation to survive: %v -> %v", before.ExifTags, after.ExifTags)
+	}
+}
+
+func TestCleanExifSegmentReclaimsRemovedTags(t *testing.T) {
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.ascii(0x010F, "ExampleCameraMaker"), e.ascii(0x0110, "ExampleModel Mark IV"), e.short(0x0112, 1), e.ascii(0x0131, "Example Editor 2.1")}
+	e.exifIFD = []testTag{e.short(0xA001, 1), e.ascii(0xA420, "0123456789abcdef0123456789abcdef")}
+	exifData := e.build()
+
+	result := &Result{}
+	cleaned, modified, removed := cleanExifSegment(exifData, result, newOptions(nil))
+	if !modified || removed == 0 {
+		t.Fatal("Expected camera tags to be removed")
+	}
+	// The values are gone from the payload rather than left behind cleared entries
+	for _, value := range []string{"ExampleCameraMaker", "ExampleModel", "Example Editor", "0123456789abcdef"} {
+		if bytes.Contains(cleaned, []byte(value)) {
+			t.Errorf("%q is still present", value)
+		}
+	}
+	if shrunk := int64(len(exifData) - len(cleaned)); shrunk < removed {
+		t.Errorf("Expected the payload to shrink by at least %d bytes, got %d", removed, shrunk)
+	}
+	if orientation, ok := exifOrientation(cleaned); !ok || orientation != 1 {
+		t.Error("Orientation was lost")
+	}
+}
@@ -0,0 +1,59 @@
+package jpegmetawebstrip
+
+import "fmt"
+
+// Languages supported by the formatting helpers
+const (
+	LangEnglish  = "en"
+	LangJapanese = "ja"
+)
+
+// FormatBytes renders a byte count with a binary unit, e.g. "512 B", "38.2 KB" or "1.5 MB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n) / unit
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := 0
+	for (value >= unit || value <= -unit) && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// FormatSavings renders the savings of a Result as a human-readable sentence in the given
+// language, e.g. "38.2 KB saved, 12% smaller" or "38.2 KB 削減（12% 縮小）".
+// Unknown languages fall back to English.
+func FormatSavings(result *Result, lang string) string {
+	if result == nil || result.Total <= 0 {
+		if lang == LangJapanese {
+			return "削減なし"
+		}
+		return "No savings"
+	}
+
+	size := FormatBytes(result.Total)
+	if result.OriginalSize <= 0 {
+		if lang == LangJapanese {
+			return fmt.Sprintf("%s 削減", size)
+		}
+		return fmt.Sprintf("%s saved", size)
+	}
+
+	percent := formatPercent(float64(result.Total) / float64(result.OriginalSize) * 100)
+	if lang == LangJapanese {
+		return fmt.Sprintf("%s 削減（%s 縮小）", size, percent)
+	}
+	return fmt.Sprintf("%s saved, %s smaller", size, percent)
+}
+
+// formatPercent shows one decimal place only for values below 1%
+func formatPercent(p float64) string {
+	if p < 1 {
+		return fmt.Sprintf("%.1f%%", p)
+	}
+	return fmt.Sprintf("%.0f%%", p)
+}
@@ -0,0 +1,51 @@
+package jpegmetawebstrip
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1024, "1.0 KB"},
+		{39117, "38.2 KB"},
+		{1572864, "1.5 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, tc := range testCases {
+		if got := FormatBytes(tc.n); got != tc.expected {
+			t.Errorf("FormatBytes(%d) = %q, expected %q", tc.n, got, tc.expected)
+		}
+	}
+}
+
+func TestFormatSavings(t *testing.T) {
+	result := &Result{Total: 39117, OriginalSize: 325975}
+
+	testCases := []struct {
+		name     string
+		result   *Result
+		lang     string
+		expected string
+	}{
+		{"English", result, LangEnglish, "38.2 KB saved, 12% smaller"},
+		{"Japanese", result, LangJapanese, "38.2 KB 削減（12% 縮小）"},
+		{"Unknown language falls back to English", result, "fr", "38.2 KB saved, 12% smaller"},
+		{"Small percentage", &Result{Total: 100, OriginalSize: 50000}, LangEnglish, "100 B saved, 0.2% smaller"},
+		{"Unknown original size", &Result{Total: 2048}, LangEnglish, "2.0 KB saved"},
+		{"No savings English", &Result{OriginalSize: 1000}, LangEnglish, "No savings"},
+		{"No savings Japanese", &Result{OriginalSize: 1000}, LangJapanese, "削減なし"},
+		{"Nil result", nil, LangEnglish, "No savings"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FormatSavings(tc.result, tc.lang); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// Handler processes a segment matched by a registered marker and signature.
+// It returns the payload to write and whether the segment is kept at all.
+// Bytes dropped by a handler are reported under Result.Removed.Other.
+type Handler interface {
+	HandleSegment(data []byte) ([]byte, bool)
+}
+
+// HandlerFunc adapts an ordinary function to the Handler interface
+type HandlerFunc func(data []byte) ([]byte, bool)
+
+// HandleSegment calls f(data)
+func (f HandlerFunc) HandleSegment(data []byte) ([]byte, bool) {
+	return f(data)
+}
+
+// segmentHandler is a registered handler with the segments it applies to
+type segmentHandler struct {
+	marker    byte
+	signature []byte
+	handler   Handler
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   []segmentHandler
+)
+
+// RegisterSegmentHandler adds support for a vendor segment without changing the core:
+// segments with the given marker whose payload starts with signature are passed to handler
+// instead of the built-in processing. Segments matched by a handler also count as known in
+// strict mode. Handlers registered later take precedence; it is safe for concurrent use.
+// Only APP0 through APP15 and COM can be handled; other markers are rejected with an error.
+func RegisterSegmentHandler(marker byte, signature []byte, handler Handler) error {
+	if !isAPPMarker(marker) && marker != markerCOM {
+		return fmt.Errorf("cannot register a handler for marker 0x%02X: only APPn and COM segments can be handled", marker)
+	}
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers = append(handlers, segmentHandler{
+		marker:    marker,
+		signature: append([]byte{}, signature...),
+		handler:   handler,
+	})
+	return nil
+}
+
+// findSegmentHandler returns the most recently registered handler matching the segment
+func findSegmentHandler(segment *jpegSegment) Handler {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	for i := len(handlers) - 1; i >= 0; i-- {
+		h := handlers[i]
+		if h.marker == segment.MarkerId && bytes.HasPrefix(segment.Data, h.signature) {
+			return h.handler
+		}
+	}
+	return nil
+}
+
+// processWithHandler applies a registered handler and records any dropped bytes
+func processWithHandler(handler Handler, segment *jpegSegment, result *Result) (*jpegSegment, bool) {
+	data, keep := handler.HandleSegment(segment.Data)
+	if !keep {
+		data = nil
+	}
+	if removed := int64(len(segment.Data) - len(data)); removed > 0 {
+		result.Removed.Other += removed
+		result.Total += removed
+	}
+	if !keep {
+		return segment, false
+	}
+	return &jpegSegment{
+		MarkerId:   segment.MarkerId,
+		MarkerName: segment.MarkerName,
+		Data:       data,
+	}, true
+}
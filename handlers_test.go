@@ -0,0 +1,105 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withHandlers restores the handler registry after a test
+func withHandlers(t *testing.T) {
+	t.Helper()
+	handlersMu.Lock()
+	saved := append([]segmentHandler{}, handlers...)
+	handlersMu.Unlock()
+	t.Cleanup(func() {
+		handlersMu.Lock()
+		handlers = saved
+		handlersMu.Unlock()
+	})
+}
+
+// registerTestHandler registers handler and fails the test if the marker is rejected
+func registerTestHandler(t *testing.T, marker byte, signature []byte, handler Handler) {
+	t.Helper()
+	if err := RegisterSegmentHandler(marker, signature, handler); err != nil {
+		t.Fatalf("RegisterSegmentHandler failed: %v", err)
+	}
+}
+
+func TestRegisterSegmentHandlerMarkers(t *testing.T) {
+	withHandlers(t)
+
+	discard := HandlerFunc(func([]byte) ([]byte, bool) { return nil, false })
+	for _, marker := range []byte{markerSOI, markerDQT, markerSOF0, markerSOS, markerEOI, 0x00} {
+		if err := RegisterSegmentHandler(marker, nil, discard); err == nil {
+			t.Errorf("Expected marker 0x%02X to be rejected", marker)
+		}
+	}
+	for _, marker := range []byte{markerAPP0, markerAPP15, markerCOM} {
+		if err := RegisterSegmentHandler(marker, nil, discard); err != nil {
+			t.Errorf("Expected marker 0x%02X to be accepted: %v", marker, err)
+		}
+	}
+}
+
+func TestRegisterSegmentHandler(t *testing.T) {
+	withHandlers(t)
+
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	telemetry := append([]byte("TELEMETRY\x00"), bytes.Repeat([]byte{0xAB}, 100)...)
+	overlay := append([]byte("OVERLAY\x00"), bytes.Repeat([]byte{0xCD}, 50)...)
+	jpegData = insertSegment(jpegData, 0xE5, telemetry)
+	jpegData = insertSegment(jpegData, 0xE6, overlay)
+
+	// Without handlers, strict mode rejects the vendor segments
+	if _, _, err := Strip(jpegData, WithStrictUnknown()); err == nil {
+		t.Fatal("Expected unknown marker error before registering handlers")
+	}
+
+	registerTestHandler(t, 0xE5, []byte("TELEMETRY\x00"), HandlerFunc(func(data []byte) ([]byte, bool) {
+		return nil, false
+	}))
+	registerTestHandler(t, 0xE6, []byte("OVERLAY\x00"), HandlerFunc(func(data []byte) ([]byte, bool) {
+		return data[:len("OVERLAY\x00")], true
+	}))
+
+	cleanedData, result, err := Strip(jpegData, WithStrictUnknown())
+	if err != nil {
+		t.Fatalf("Strip failed with registered handlers: %v", err)
+	}
+	if payloads := getSegmentPayloads(t, cleanedData, 0xE5); len(payloads) != 0 {
+		t.Errorf("Expected telemetry segment to be removed, got %d", len(payloads))
+	}
+	overlays := getSegmentPayloads(t, cleanedData, 0xE6)
+	if len(overlays) != 1 || string(overlays[0]) != "OVERLAY\x00" {
+		t.Errorf("Expected trimmed overlay segment, got %q", overlays)
+	}
+	if expected := int64(len(telemetry) + 50); result.Removed.Other != expected {
+		t.Errorf("Expected %d bytes under Other, got %d", expected, result.Removed.Other)
+	}
+	assertPixelsUnchanged(t, jpegData, cleanedData)
+}
+
+func TestFindSegmentHandlerPrecedence(t *testing.T) {
+	withHandlers(t)
+
+	first := HandlerFunc(func(data []byte) ([]byte, bool) { return data, true })
+	second := HandlerFunc(func(data []byte) ([]byte, bool) { return nil, false })
+	registerTestHandler(t, 0xE5, []byte("A"), first)
+	registerTestHandler(t, 0xE5, []byte("AB"), second)
+
+	if _, keep := findSegmentHandler(&jpegSegment{MarkerId: 0xE5, Data: []byte("ABC")}).HandleSegment(nil); keep {
+		t.Error("Expected the later registration to take precedence")
+	}
+	if h := findSegmentHandler(&jpegSegment{MarkerId: 0xE5, Data: []byte("AC")}); h == nil {
+		t.Error("Expected the earlier registration to match its own signature")
+	}
+	if h := findSegmentHandler(&jpegSegment{MarkerId: 0xE6, Data: []byte("ABC")}); h != nil {
+		t.Error("Expected no handler for a different marker")
+	}
+}
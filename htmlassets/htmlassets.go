@@ -0,0 +1,153 @@
+// Package htmlassets finds and cleans the JPEGs referenced by HTML and CSS documents.
+//
+// It is the building block for static-site optimizers: Scan lists the local JPEG files a
+// document references, StripInline cleans JPEG data URIs embedded in the document itself,
+// and Fingerprint with RewriteRefs renames cleaned files to cache-busting names.
+// File access is left to the caller.
+package htmlassets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	jpegmetawebstrip "github.com/ideamans/go-jpeg-meta-web-strip"
+)
+
+// Result summarizes the inline images cleaned by StripInline
+type Result struct {
+	// DataURIs is the number of JPEG data URIs found
+	DataURIs int
+	// Stripped is the number of data URIs whose metadata was removed
+	Stripped int
+	// Total is the number of decoded bytes removed across all data URIs
+	Total int64
+}
+
+var (
+	// urlAttribute matches attributes holding a single URL
+	urlAttribute = regexp.MustCompile(`(?i)(\s(?:src|href|poster|data-src)\s*=\s*)("[^"]*"|'[^']*')`)
+	// srcsetAttribute matches attributes holding a list of "URL descriptor" candidates
+	srcsetAttribute = regexp.MustCompile(`(?i)(\s(?:srcset|data-srcset)\s*=\s*)("[^"]*"|'[^']*')`)
+	// cssURL matches url(...) references in stylesheets and style attributes
+	cssURL = regexp.MustCompile(`(?i)(url\(\s*)("[^"]*"|'[^']*'|[^)'"\s]+)(\s*\))`)
+	// jpegDataURI matches base64 JPEG data URIs anywhere in a document
+	jpegDataURI = regexp.MustCompile(`(?i)data:image/(?:jpeg|jpg|pjpeg)(?:;[a-z0-9_.=+-]+)*;base64,[a-z0-9+/=_-]+`)
+)
+
+// Scan returns the local JPEG references of an HTML or CSS document without duplicates:
+// src-like attributes first, then srcset candidates, then CSS url() values. Remote URLs
+// and data URIs are skipped; query strings and fragments are removed from the returned paths.
+func Scan(doc []byte) []string {
+	refs := make([]string, 0)
+	seen := make(map[string]bool)
+	forEachRef(doc, func(ref string) string {
+		if p, ok := localJPEGPath(ref); ok && !seen[p] {
+			seen[p] = true
+			refs = append(refs, p)
+		}
+		return ref
+	})
+	return refs
+}
+
+// StripInline strips every base64 JPEG data URI embedded in the document.
+// Data URIs that cannot be cleaned are left untouched.
+func StripInline(doc []byte, opts ...jpegmetawebstrip.Option) ([]byte, *Result) {
+	result := &Result{}
+	out := jpegDataURI.ReplaceAllFunc(doc, func(uri []byte) []byte {
+		result.DataURIs++
+		cleaned, r, err := jpegmetawebstrip.StripDataURI(string(uri), opts...)
+		if err != nil || r.Total == 0 {
+			return uri
+		}
+		result.Stripped++
+		result.Total += r.Total
+		return []byte(cleaned)
+	})
+	return out, result
+}
+
+// Fingerprint returns a cache-busting name for cleaned content, inserting the first
+// eight bytes of its SHA-256 before the extension: "img/photo.jpg" becomes
+// "img/photo.1a2b3c4d5e6f7a8b.jpg"
+func Fingerprint(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:8]) + ext
+}
+
+// RewriteRefs replaces references to the keys of renames with their values in src,
+// srcset, href, poster and CSS url() references, keeping query strings and fragments
+func RewriteRefs(doc []byte, renames map[string]string) []byte {
+	return forEachRef(doc, func(ref string) string {
+		p, ok := localJPEGPath(ref)
+		if !ok {
+			return ref
+		}
+		renamed, ok := renames[p]
+		if !ok {
+			return ref
+		}
+		return renamed + ref[len(refPath(ref)):]
+	})
+}
+
+// forEachRef calls fn for every URL reference in the document and substitutes its result
+func forEachRef(doc []byte, fn func(ref string) string) []byte {
+	doc = urlAttribute.ReplaceAllFunc(doc, func(m []byte) []byte {
+		sub := urlAttribute.FindSubmatch(m)
+		quoted := string(sub[2])
+		return []byte(string(sub[1]) + quoted[:1] + fn(quoted[1:len(quoted)-1]) + quoted[len(quoted)-1:])
+	})
+	doc = srcsetAttribute.ReplaceAllFunc(doc, func(m []byte) []byte {
+		sub := srcsetAttribute.FindSubmatch(m)
+		quoted := string(sub[2])
+		candidates := strings.Split(quoted[1:len(quoted)-1], ",")
+		for i, candidate := range candidates {
+			trimmed := strings.TrimLeft(candidate, " \t\r\n")
+			ref, descriptor, _ := strings.Cut(trimmed, " ")
+			rewritten := fn(ref)
+			if descriptor != "" {
+				rewritten += " " + descriptor
+			}
+			candidates[i] = candidate[:len(candidate)-len(trimmed)] + rewritten
+		}
+		return []byte(string(sub[1]) + quoted[:1] + strings.Join(candidates, ",") + quoted[len(quoted)-1:])
+	})
+	return cssURL.ReplaceAllFunc(doc, func(m []byte) []byte {
+		sub := cssURL.FindSubmatch(m)
+		value := string(sub[2])
+		quote := ""
+		if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`) {
+			quote, value = value[:1], value[1:len(value)-1]
+		}
+		return []byte(string(sub[1]) + quote + fn(value) + quote + string(sub[3]))
+	})
+}
+
+// refPath returns the reference without its query string and fragment
+func refPath(ref string) string {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		return ref[:i]
+	}
+	return ref
+}
+
+// localJPEGPath returns the path of a local JPEG reference
+func localJPEGPath(ref string) (string, bool) {
+	p := refPath(strings.TrimSpace(ref))
+	if p == "" || strings.HasPrefix(p, "//") {
+		return "", false
+	}
+	if u, err := url.Parse(p); err != nil || u.Scheme != "" {
+		return "", false
+	}
+	if !jpegmetawebstrip.IsJPEGName(p) {
+		return "", false
+	}
+	return p, true
+}
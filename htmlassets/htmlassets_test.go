@@ -0,0 +1,80 @@
+package htmlassets
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const page = `<html><head><style>body { background: url("img/bg.jpg?v=1"); }</style></head>
+<body>
+<img src="photo.JPG" alt="">
+<img src='https://example.com/remote.jpg'>
+<img srcset="small.jpg 480w, large.jpeg 1024w" src="small.jpg">
+<a href="doc.pdf">doc</a>
+<div style="background-image:url(img/tile.jpg#frag)"></div>
+</body></html>`
+
+func TestScan(t *testing.T) {
+	got := Scan([]byte(page))
+	want := []string{"photo.JPG", "small.jpg", "large.jpeg", "img/bg.jpg", "img/tile.jpg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %v, want %v", got, want)
+	}
+}
+
+func TestRewriteRefs(t *testing.T) {
+	out := string(RewriteRefs([]byte(page), map[string]string{
+		"small.jpg":    "small.abc.jpg",
+		"img/bg.jpg":   "img/bg.def.jpg",
+		"img/tile.jpg": "img/tile.123.jpg",
+	}))
+	for _, want := range []string{
+		`url("img/bg.def.jpg?v=1")`,
+		`srcset="small.abc.jpg 480w, large.jpeg 1024w"`,
+		`src="small.abc.jpg"`,
+		`url(img/tile.123.jpg#frag)`,
+		`src="photo.JPG"`,
+		`src='https://example.com/remote.jpg'`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rewritten document does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("img/photo.jpg", []byte("a"))
+	if !strings.HasPrefix(a, "img/photo.") || !strings.HasSuffix(a, ".jpg") || len(a) != len("img/photo..jpg")+16 {
+		t.Errorf("unexpected fingerprint %q", a)
+	}
+	if a == Fingerprint("img/photo.jpg", []byte("b")) {
+		t.Error("different content produced the same fingerprint")
+	}
+}
+
+func TestStripInline(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("..", "testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	uri := "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(jpegData)
+	doc := `<img src="` + uri + `"><style>a { background: url(` + uri + `) }</style><img src="data:image/png;base64,iVBORw0KGgo=">`
+
+	out, result := StripInline([]byte(doc))
+	if result.DataURIs != 2 || result.Stripped != 2 || result.Total == 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if strings.Contains(string(out), uri) {
+		t.Error("original data URI still present")
+	}
+	if !strings.Contains(string(out), "data:image/png;base64,iVBORw0KGgo=") {
+		t.Error("non-JPEG data URI was modified")
+	}
+	if len(out) >= len(doc) {
+		t.Errorf("document did not shrink: %d >= %d", len(out), len(doc))
+	}
+}
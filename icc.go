@@ -0,0 +1,144 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// ICCHeader is the identifier that starts every APP2 ICC profile chunk
+var ICCHeader = []byte("ICC_PROFILE\x00")
+
+// iccHeaderSize is the fixed size of the ICC profile header preceding the tag table
+const iccHeaderSize = 128
+
+// ICCProfile describes an embedded ICC profile
+type ICCProfile struct {
+	// Description is the profile name from the desc tag, e.g. "Display P3" or "Adobe RGB (1998)"
+	Description string
+	// ColorSpace is the data color space signature, e.g. "RGB", "CMYK" or "GRAY"
+	ColorSpace string
+	// Size is the profile size in bytes as declared in the ICC header
+	Size int
+	// Version is the profile version from the ICC header, e.g. "2.1" or "4.3".
+	// Some older Android and Internet Explorer renderers ignore v4 profiles.
+	Version string
+}
+
+// assembleICCProfile concatenates APP2 ICC chunks in sequence order.
+// It returns nil when the segments carry no ICC profile.
+func assembleICCProfile(segments []*jpegSegment) []byte {
+	type chunk struct {
+		seq  byte
+		data []byte
+	}
+	chunks := make([]chunk, 0)
+	for _, segment := range filterSegments(segments, markerAPP2) {
+		if !bytes.HasPrefix(segment.Data, ICCHeader) || len(segment.Data) < len(ICCHeader)+2 {
+			continue
+		}
+		chunks = append(chunks, chunk{seq: segment.Data[len(ICCHeader)], data: segment.Data[len(ICCHeader)+2:]})
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].seq < chunks[j].seq })
+	profile := make([]byte, 0)
+	for _, c := range chunks {
+		profile = append(profile, c.data...)
+	}
+	return profile
+}
+
+// parseICCProfile reads the header and desc tag of an ICC profile.
+// It returns false when the data is not a valid profile.
+func parseICCProfile(profile []byte) (*ICCProfile, bool) {
+	if len(profile) < iccHeaderSize+4 || string(profile[36:40]) != "acsp" {
+		return nil, false
+	}
+
+	info := &ICCProfile{
+		ColorSpace: strings.TrimRight(string(profile[16:20]), " "),
+		Size:       int(binary.BigEndian.Uint32(profile[0:4])),
+		Version:    fmt.Sprintf("%d.%d", profile[8], profile[9]>>4),
+	}
+
+	count := int(binary.BigEndian.Uint32(profile[iccHeaderSize : iccHeaderSize+4]))
+	for i := 0; i < count; i++ {
+		pos := iccHeaderSize + 4 + i*12
+		if pos+12 > len(profile) {
+			break
+		}
+		if string(profile[pos:pos+4]) != "desc" {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(profile[pos+4 : pos+8]))
+		size := int(binary.BigEndian.Uint32(profile[pos+8 : pos+12]))
+		if offset < 0 || size < 0 || offset+size > len(profile) {
+			break
+		}
+		info.Description = iccDescription(profile[offset : offset+size])
+		break
+	}
+	return info, true
+}
+
+// iccDescription decodes a desc tag of type textDescriptionType (ICC v2) or
+// multiLocalizedUnicodeType (ICC v4), preferring the English record of the latter
+func iccDescription(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+
+	switch string(tag[0:4]) {
+	case "desc":
+		length := int(binary.BigEndian.Uint32(tag[8:12]))
+		if length > len(tag)-12 {
+			length = len(tag) - 12
+		}
+		return strings.TrimRight(string(tag[12:12+length]), "\x00")
+	case "mluc":
+		if len(tag) < 16 {
+			return ""
+		}
+		records := int(binary.BigEndian.Uint32(tag[8:12]))
+		recordSize := int(binary.BigEndian.Uint32(tag[12:16]))
+		if recordSize < 12 {
+			return ""
+		}
+		text := ""
+		for i := 0; i < records; i++ {
+			pos := 16 + i*recordSize
+			if pos+12 > len(tag) {
+				break
+			}
+			length := int(binary.BigEndian.Uint32(tag[pos+4 : pos+8]))
+			offset := int(binary.BigEndian.Uint32(tag[pos+8 : pos+12]))
+			if offset+length > len(tag) {
+				continue
+			}
+			decoded := decodeUTF16BE(tag[offset : offset+length])
+			if text == "" || string(tag[pos:pos+2]) == "en" {
+				text = decoded
+			}
+			if string(tag[pos:pos+2]) == "en" {
+				break
+			}
+		}
+		return text
+	}
+	return ""
+}
+
+// decodeUTF16BE converts big-endian UTF-16 bytes to a string, dropping trailing NULs
+func decodeUTF16BE(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, binary.BigEndian.Uint16(data[i:i+2]))
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
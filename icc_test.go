@@ -0,0 +1,109 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildICCProfile returns a minimal ICC profile with the given color space and desc tag
+func buildICCProfile(colorSpace string, descTag []byte) []byte {
+	tagOffset := iccHeaderSize + 4 + 12
+	profile := make([]byte, tagOffset+len(descTag))
+	binary.BigEndian.PutUint32(profile[0:4], uint32(len(profile)))
+	copy(profile[16:20], colorSpace)
+	copy(profile[36:40], "acsp")
+	binary.BigEndian.PutUint32(profile[iccHeaderSize:], 1)
+	copy(profile[iccHeaderSize+4:], "desc")
+	binary.BigEndian.PutUint32(profile[iccHeaderSize+8:], uint32(tagOffset))
+	binary.BigEndian.PutUint32(profile[iccHeaderSize+12:], uint32(len(descTag)))
+	copy(profile[tagOffset:], descTag)
+	return profile
+}
+
+// textDescriptionTag builds an ICC v2 textDescriptionType tag
+func textDescriptionTag(text string) []byte {
+	tag := make([]byte, 12, 12+len(text)+1)
+	copy(tag, "desc")
+	binary.BigEndian.PutUint32(tag[8:], uint32(len(text)+1))
+	return append(append(tag, text...), 0)
+}
+
+// mlucTag builds an ICC v4 multiLocalizedUnicodeType tag from language/text pairs
+func mlucTag(records ...[2]string) []byte {
+	tag := make([]byte, 16+12*len(records))
+	copy(tag, "mluc")
+	binary.BigEndian.PutUint32(tag[8:], uint32(len(records)))
+	binary.BigEndian.PutUint32(tag[12:], 12)
+	for i, record := range records {
+		encoded := make([]byte, 0)
+		for _, unit := range utf16.Encode([]rune(record[1])) {
+			encoded = binary.BigEndian.AppendUint16(encoded, unit)
+		}
+		pos := 16 + 12*i
+		copy(tag[pos:], record[0])
+		binary.BigEndian.PutUint32(tag[pos+4:], uint32(len(encoded)))
+		binary.BigEndian.PutUint32(tag[pos+8:], uint32(len(tag)))
+		tag = append(tag, encoded...)
+	}
+	return tag
+}
+
+func TestParseICCProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     []byte
+		description string
+		colorSpace  string
+	}{
+		{"v2 desc", buildICCProfile("RGB ", textDescriptionTag("Adobe RGB (1998)")), "Adobe RGB (1998)", "RGB"},
+		{"v4 mluc prefers English", buildICCProfile("RGB ", mlucTag([2]string{"jaJP", "ディスプレイP3"}, [2]string{"enUS", "Display P3"})), "Display P3", "RGB"},
+		{"v4 mluc without English", buildICCProfile("CMYK", mlucTag([2]string{"jaJP", "ジャパンカラー"})), "ジャパンカラー", "CMYK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := parseICCProfile(tt.profile)
+			if !ok {
+				t.Fatal("Expected profile to parse")
+			}
+			if info.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, info.Description)
+			}
+			if info.ColorSpace != tt.colorSpace {
+				t.Errorf("Expected color space %q, got %q", tt.colorSpace, info.ColorSpace)
+			}
+			if info.Size != len(tt.profile) {
+				t.Errorf("Expected size %d, got %d", len(tt.profile), info.Size)
+			}
+		})
+	}
+
+	if _, ok := parseICCProfile(make([]byte, 200)); ok {
+		t.Error("Expected data without acsp signature to be rejected")
+	}
+}
+
+func TestAssembleICCProfile(t *testing.T) {
+	profile := buildICCProfile("RGB ", textDescriptionTag("sRGB IEC61966-2.1"))
+	half := len(profile) / 2
+	chunk := func(seq byte, data []byte) *jpegSegment {
+		payload := append(append([]byte{}, ICCHeader...), seq, 2)
+		return &jpegSegment{MarkerId: markerAPP2, Data: append(payload, data...)}
+	}
+
+	// Chunks stored out of order must be reassembled by sequence number
+	segments := []*jpegSegment{chunk(2, profile[half:]), chunk(1, profile[:half])}
+	assembled := assembleICCProfile(segments)
+	info, ok := parseICCProfile(assembled)
+	if !ok {
+		t.Fatal("Expected reassembled profile to parse")
+	}
+	if info.Description != "sRGB IEC61966-2.1" {
+		t.Errorf("Expected description from reassembled profile, got %q", info.Description)
+	}
+
+	if assembleICCProfile(nil) != nil {
+		t.Error("Expected nil profile when there are no APP2 segments")
+	}
+}
@@ -0,0 +1,232 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// sRGB colorants and white point adapted to D50, as stored in matrix/TRC profiles
+var (
+	srgbWhitePoint = [3]float64{0.9642, 1.0, 0.8249}
+	srgbRed        = [3]float64{0.4361, 0.2225, 0.0139}
+	srgbGreen      = [3]float64{0.3851, 0.7169, 0.0971}
+	srgbBlue       = [3]float64{0.1431, 0.0606, 0.7141}
+)
+
+// srgbColorantTolerance is the largest XYZ difference still treated as an sRGB colorant
+const srgbColorantTolerance = 0.002
+
+// iccMajorVersion returns the major version from the ICC profile header
+func iccMajorVersion(profile []byte) int {
+	if len(profile) < iccHeaderSize {
+		return 0
+	}
+	return int(profile[8])
+}
+
+// isSRGBLikeProfile reports whether an RGB profile describes sRGB, judged by its
+// colorant tags or, for LUT-based profiles without colorants, by its description
+func isSRGBLikeProfile(profile []byte, info *ICCProfile) bool {
+	if info.ColorSpace != "RGB" {
+		return false
+	}
+	red, okRed := iccXYZTag(profile, "rXYZ")
+	green, okGreen := iccXYZTag(profile, "gXYZ")
+	blue, okBlue := iccXYZTag(profile, "bXYZ")
+	if okRed && okGreen && okBlue {
+		return xyzClose(red, srgbRed) && xyzClose(green, srgbGreen) && xyzClose(blue, srgbBlue)
+	}
+	return strings.Contains(strings.ToLower(info.Description), "srgb")
+}
+
+// iccXYZTag reads the first value of an XYZType tag
+func iccXYZTag(profile []byte, signature string) ([3]float64, bool) {
+	var xyz [3]float64
+	tag, ok := iccTag(profile, signature)
+	if !ok || len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return xyz, false
+	}
+	for i := range xyz {
+		xyz[i] = float64(int32(binary.BigEndian.Uint32(tag[8+i*4:12+i*4]))) / 65536
+	}
+	return xyz, true
+}
+
+// iccTag returns the data of the tag with the given signature
+func iccTag(profile []byte, signature string) ([]byte, bool) {
+	if len(profile) < iccHeaderSize+4 {
+		return nil, false
+	}
+	count := int(binary.BigEndian.Uint32(profile[iccHeaderSize : iccHeaderSize+4]))
+	for i := 0; i < count; i++ {
+		pos := iccHeaderSize + 4 + i*12
+		if pos+12 > len(profile) {
+			break
+		}
+		if string(profile[pos:pos+4]) != signature {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(profile[pos+4 : pos+8]))
+		size := int(binary.BigEndian.Uint32(profile[pos+8 : pos+12]))
+		if offset < 0 || size < 0 || offset+size > len(profile) {
+			return nil, false
+		}
+		return profile[offset : offset+size], true
+	}
+	return nil, false
+}
+
+// xyzClose reports whether two XYZ values match within srgbColorantTolerance
+func xyzClose(a, b [3]float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > srgbColorantTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// srgbV2Profile is a compact ICC v2 matrix/TRC sRGB profile understood by legacy renderers
+var srgbV2Profile = buildSRGBV2Profile()
+
+// buildSRGBV2Profile assembles a v2 sRGB profile with a sampled IEC 61966-2.1 tone curve
+// shared by the three TRC tags
+func buildSRGBV2Profile() []byte {
+	type tag struct {
+		signature string
+		data      []byte
+	}
+	curve := new(bytes.Buffer)
+	curve.WriteString("curv\x00\x00\x00\x00")
+	const samples = 1024
+	binary.Write(curve, binary.BigEndian, uint32(samples))
+	for i := 0; i < samples; i++ {
+		v := float64(i) / (samples - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(curve, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+	tags := []tag{
+		{"desc", iccTextDescription("sRGB IEC61966-2.1")},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(srgbWhitePoint)},
+		{"rXYZ", iccXYZ(srgbRed)},
+		{"gXYZ", iccXYZ(srgbGreen)},
+		{"bXYZ", iccXYZ(srgbBlue)},
+		{"rTRC", curve.Bytes()},
+		{"gTRC", nil},
+		{"bTRC", nil},
+	}
+
+	table := new(bytes.Buffer)
+	body := new(bytes.Buffer)
+	binary.Write(table, binary.BigEndian, uint32(len(tags)))
+	dataStart := iccHeaderSize + 4 + len(tags)*12
+	var curveOffset, curveSize int
+	for _, t := range tags {
+		offset, size := dataStart+body.Len(), len(t.data)
+		if t.data == nil {
+			// gTRC and bTRC share the rTRC curve
+			offset, size = curveOffset, curveSize
+		} else {
+			body.Write(t.data)
+			for body.Len()%4 != 0 {
+				body.WriteByte(0)
+			}
+		}
+		if t.signature == "rTRC" {
+			curveOffset, curveSize = offset, size
+		}
+		table.WriteString(t.signature)
+		binary.Write(table, binary.BigEndian, uint32(offset))
+		binary.Write(table, binary.BigEndian, uint32(size))
+	}
+
+	header := make([]byte, iccHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], uint32(iccHeaderSize+table.Len()+body.Len()))
+	binary.BigEndian.PutUint32(header[8:12], 0x02100000)
+	copy(header[12:16], "mntr")
+	copy(header[16:20], "RGB ")
+	copy(header[20:24], "XYZ ")
+	copy(header[36:40], "acsp")
+	copy(header[68:80], iccXYZ(srgbWhitePoint)[8:])
+
+	profile := append(header, table.Bytes()...)
+	return append(profile, body.Bytes()...)
+}
+
+// iccXYZ encodes an XYZType tag
+func iccXYZ(xyz [3]float64) []byte {
+	data := []byte("XYZ \x00\x00\x00\x00")
+	for _, v := range xyz {
+		data = binary.BigEndian.AppendUint32(data, uint32(int32(math.Round(v*65536))))
+	}
+	return data
+}
+
+// iccText encodes a v2 textType tag
+func iccText(text string) []byte {
+	return append([]byte("text\x00\x00\x00\x00"), text+"\x00"...)
+}
+
+// iccTextDescription encodes a v2 textDescriptionType tag with empty Unicode and ScriptCode parts
+func iccTextDescription(text string) []byte {
+	data := []byte("desc\x00\x00\x00\x00")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(text)+1))
+	data = append(data, text+"\x00"...)
+	data = append(data, make([]byte, 4+4+2+1+67)...)
+	return data
+}
+
+// swapICCv4 replaces a v4 sRGB-like ICC profile in the kept segments with srgbV2Profile.
+// The first ICC chunk is replaced in place and the remaining chunks are dropped.
+func swapICCv4(segments []*jpegSegment) ([]*jpegSegment, bool) {
+	profile := assembleICCProfile(segments)
+	if profile == nil || iccMajorVersion(profile) < 4 {
+		return segments, false
+	}
+	info, ok := parseICCProfile(profile)
+	if !ok || !isSRGBLikeProfile(profile, info) {
+		return segments, false
+	}
+
+	chunk := append(append([]byte{}, ICCHeader...), 1, 1)
+	chunk = append(chunk, srgbV2Profile...)
+	swapped := make([]*jpegSegment, 0, len(segments))
+	replaced := false
+	for _, segment := range segments {
+		if segment.MarkerId != markerAPP2 || !bytes.HasPrefix(segment.Data, ICCHeader) {
+			swapped = append(swapped, segment)
+			continue
+		}
+		if replaced {
+			continue
+		}
+		replaced = true
+		swapped = append(swapped, &jpegSegment{
+			MarkerId:   segment.MarkerId,
+			MarkerName: segment.MarkerName,
+			Offset:     segment.Offset,
+			Data:       chunk,
+		})
+	}
+	return swapped, true
+}
+
+// iccv4Warning returns a WarningICCv4 when the kept segments carry an ICC v4 profile
+func iccv4Warning(segments []*jpegSegment) []Warning {
+	profile := assembleICCProfile(segments)
+	if iccMajorVersion(profile) < 4 {
+		return nil
+	}
+	description := ""
+	if info, ok := parseICCProfile(profile); ok {
+		description = info.Description
+	}
+	return []Warning{{Code: WarningICCv4, Source: "ICC profile", Text: description}}
+}
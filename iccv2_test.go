@@ -0,0 +1,107 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withICCProfile returns basic_copy.jpg with profile embedded as a single APP2 chunk
+func withICCProfile(t *testing.T, profile []byte) []byte {
+	t.Helper()
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	payload := append(append([]byte{}, ICCHeader...), 1, 1)
+	return insertSegment(jpegData, markerAPP2, append(payload, profile...))
+}
+
+// asV4 returns a copy of profile with its header version set to 4.3
+func asV4(profile []byte) []byte {
+	v4 := append([]byte{}, profile...)
+	v4[8], v4[9] = 4, 0x30
+	return v4
+}
+
+func TestSRGBV2Profile(t *testing.T) {
+	info, ok := parseICCProfile(srgbV2Profile)
+	if !ok {
+		t.Fatal("built profile does not parse")
+	}
+	if info.Version != "2.1" || info.Description != "sRGB IEC61966-2.1" || info.Size != len(srgbV2Profile) {
+		t.Errorf("unexpected profile info: %+v", info)
+	}
+	if !isSRGBLikeProfile(srgbV2Profile, info) {
+		t.Error("built profile is not recognized as sRGB")
+	}
+}
+
+func TestWithICCv2Swap(t *testing.T) {
+	jpegData := withICCProfile(t, asV4(srgbV2Profile))
+
+	cleaned, result, err := Strip(jpegData, WithICCv2Swap())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !result.ICCSwapped {
+		t.Error("expected ICCSwapped")
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %+v", result.Warnings)
+	}
+	summary, err := Inspect(cleaned)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if summary.ICC == nil || summary.ICC.Version != "2.1" {
+		t.Errorf("expected v2.1 profile, got %+v", summary.ICC)
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+
+	// Without the option the v4 profile is kept and reported
+	cleaned, result, err = Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.ICCSwapped || len(result.Warnings) != 1 || result.Warnings[0].Code != WarningICCv4 {
+		t.Errorf("expected a single ICCv4 warning, got swapped=%v warnings=%+v", result.ICCSwapped, result.Warnings)
+	}
+	if !bytes.Contains(cleaned, asV4(srgbV2Profile)) {
+		t.Error("v4 profile was not preserved")
+	}
+}
+
+func TestWithICCv2SwapKeepsWideGamut(t *testing.T) {
+	p3 := asV4(buildICCProfile("RGB ", mlucTag([2]string{"enUS", "Display P3"})))
+	jpegData := withICCProfile(t, p3)
+
+	cleaned, result, err := Strip(jpegData, WithICCv2Swap())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.ICCSwapped {
+		t.Error("Display P3 profile must not be swapped")
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Text != "Display P3" {
+		t.Errorf("expected ICCv4 warning for Display P3, got %+v", result.Warnings)
+	}
+	if !bytes.Contains(cleaned, p3) {
+		t.Error("Display P3 profile was not preserved")
+	}
+}
+
+func TestWithICCv2SwapIgnoresV2(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_icc_profile_srgb.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	_, result, err := Strip(jpegData, WithICCv2Swap())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.ICCSwapped || len(result.Warnings) != 0 {
+		t.Errorf("v2 profile must be left alone, got swapped=%v warnings=%+v", result.ICCSwapped, result.Warnings)
+	}
+}
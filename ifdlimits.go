@@ -0,0 +1,103 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Default limits applied to EXIF directory structures; see WithIFDLimits
+const (
+	DefaultMaxIFDDepth   = 8
+	DefaultMaxIFDEntries = 4096
+)
+
+// ifdPointerTags are the tags whose values point at sub-IFDs
+var ifdPointerTags = map[uint16]bool{
+	0x8769: true, // Exif IFD Pointer
+	0x8825: true, // GPS IFD Pointer
+	0xA005: true, // Interoperability IFD Pointer
+	0x014A: true, // SubIFDs
+}
+
+// checkIFDLimits walks every IFD reachable from IFD0, through next-IFD links and sub-IFD
+// pointers, and fails when the nesting depth or total entry count exceeds the limits or
+// when an IFD is reached twice. The walk itself is bounded by the limits.
+func checkIFDLimits(exifData []byte, maxDepth, maxEntries int) error {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil
+	}
+	w := &ifdLimitWalker{data: exifData, order: order, maxDepth: maxDepth, maxEntries: maxEntries, visited: make(map[int]bool)}
+	return w.walk(ifd0Pos, 1)
+}
+
+// ifdLimitWalker follows every IFD of an EXIF segment counting levels and entries
+type ifdLimitWalker struct {
+	data       []byte
+	order      binary.ByteOrder
+	maxDepth   int
+	maxEntries int
+	visited    map[int]bool
+	entries    int
+}
+
+// walk visits the IFD chain starting at pos and the IFDs its entries point to
+func (w *ifdLimitWalker) walk(pos, depth int) error {
+	for pos != 0 {
+		if depth > w.maxDepth {
+			return &IFDLimitError{Reason: fmt.Sprintf("IFD nesting exceeds depth %d", w.maxDepth)}
+		}
+		if w.visited[pos] {
+			return &IFDLimitError{Reason: fmt.Sprintf("IFD at %d is referenced more than once", pos)}
+		}
+		w.visited[pos] = true
+
+		if pos+2 > len(w.data) {
+			return nil
+		}
+		w.entries += int(ifdEntryCount(w.data, w.order, pos))
+		if w.entries > w.maxEntries {
+			return &IFDLimitError{Reason: fmt.Sprintf("IFDs hold more than %d entries", w.maxEntries)}
+		}
+		ifdEntries, next, _ := readIFD(w.data, w.order, pos)
+		for _, entry := range ifdEntries {
+			if err := w.walkPointer(entry, depth); err != nil {
+				return err
+			}
+		}
+
+		// A next-IFD link (IFD1, IFD2, ...) counts as one more level
+		if next == 0 {
+			return nil
+		}
+		pos, depth = tiffHeaderPos+int(next), depth+1
+	}
+	return nil
+}
+
+// walkPointer walks the IFDs entry points to, if it is an IFD pointer
+func (w *ifdLimitWalker) walkPointer(entry ifdEntry, depth int) error {
+	if !ifdPointerTags[entry.Tag] || entry.offset(w.order) == 0 {
+		return nil
+	}
+	if entry.Tag != 0x014A || entry.Count <= 1 {
+		return w.walk(tiffHeaderPos+int(entry.offset(w.order)), depth+1)
+	}
+
+	// SubIFDs with several offsets stores them out of line
+	values, ok := entry.valueBytes(w.data, w.order)
+	if !ok {
+		return nil
+	}
+	for i := 0; i+4 <= len(values); i += 4 {
+		if err := w.walk(tiffHeaderPos+int(w.order.Uint32(values[i:i+4])), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ifdEntryCount reads the entry count of the IFD at pos
+func ifdEntryCount(exifData []byte, order binary.ByteOrder, pos int) uint16 {
+	return order.Uint16(exifData[pos : pos+2])
+}
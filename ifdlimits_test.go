@@ -0,0 +1,72 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckIFDLimits(t *testing.T) {
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.short(0x0112, 1), e.ascii(0x010F, "TestCamera")}
+	e.exifIFD = []testTag{e.short(0xA001, 1)}
+	nested := e.build()
+
+	// IFD0 whose next-IFD link points back at itself
+	loop := newTestExif(binary.BigEndian)
+	loop.ifd0 = []testTag{loop.short(0x0112, 1)}
+	looping := loop.build()
+	binary.BigEndian.PutUint32(looping[tiffHeaderPos+8+2+12:], 8)
+
+	tests := []struct {
+		name       string
+		exif       []byte
+		maxDepth   int
+		maxEntries int
+		wantErr    bool
+	}{
+		{"within defaults", nested, DefaultMaxIFDDepth, DefaultMaxIFDEntries, false},
+		{"too deep", nested, 1, DefaultMaxIFDEntries, true},
+		{"too many entries", nested, DefaultMaxIFDDepth, 3, true},
+		{"loop", looping, DefaultMaxIFDDepth, DefaultMaxIFDEntries, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkIFDLimits(tt.exif, tt.maxDepth, tt.maxEntries)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkIFDLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIFDLimitsInStrip(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	cleaned, result, err := Strip(jpegData, WithIFDLimits(1, DefaultMaxIFDEntries))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	for _, segment := range parseTestSegments(t, cleaned) {
+		if isExifSegment(segment) {
+			t.Error("EXIF over the limits should have been dropped")
+		}
+	}
+	if len(result.Warnings) == 0 || result.Warnings[len(result.Warnings)-1].Code != WarningIFDLimit {
+		t.Errorf("Expected an IFD limit warning, got %+v", result.Warnings)
+	}
+	if result.Removed.Other == 0 {
+		t.Error("Expected the dropped EXIF to be counted")
+	}
+
+	_, _, err = Strip(jpegData, WithIFDLimits(1, DefaultMaxIFDEntries), WithStrictIFDLimits())
+	var limitErr *IFDLimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Expected *IFDLimitError, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package jpegmetawebstrip
+
+// Summary describes a JPEG and the metadata it carries, without modifying it
+type Summary struct {
+	// Size is the total size of the JPEG data in bytes
+	Size int
+	// ICC describes the embedded ICC profile, or is nil when there is none
+	ICC *ICCProfile
+	// Producer is a best-effort guess at the application or device that wrote the file,
+	// e.g. "Adobe Photoshop Lightroom Classic 12.0" or "iPhone 15 Pro"; "" when unknown
+	Producer string
+	// Width and Height are the stored dimensions from the frame header
+	Width  int
+	Height int
+	// Orientation is the EXIF orientation (1-8), or 0 when there is none
+	Orientation int
+	// DisplayWidth and DisplayHeight are the dimensions as shown, which are the stored
+	// ones swapped when the orientation (5-8) rotates the image by 90 degrees
+	DisplayWidth  int
+	DisplayHeight int
+	// ExifTags lists the IFD0 and Exif IFD tags present, in file order; see ExifTags for names
+	ExifTags []uint16
+}
+
+// Inspect parses a JPEG and reports what it carries, without modifying it
+func Inspect(data []byte) (*Summary, error) {
+	sl, err := parseSegmentList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(len(data), sl.Segments()), nil
+}
+
+// summarize builds the Summary of already parsed segments
+func summarize(size int, segments []*jpegSegment) *Summary {
+	summary := &Summary{Size: size}
+	if profile := assembleICCProfile(segments); profile != nil {
+		if icc, ok := parseICCProfile(profile); ok {
+			summary.ICC = icc
+		}
+	}
+	summary.Producer = detectProducer(segments)
+	for _, segment := range segments {
+		if segment.MarkerId == markerAPP1 && isExifSegment(segment) {
+			summary.ExifTags = append(summary.ExifTags, exifTagIDs(segment.Data)...)
+			if orientation, ok := exifOrientation(segment.Data); ok && summary.Orientation == 0 {
+				summary.Orientation = int(orientation)
+			}
+		}
+	}
+
+	if frame, ok := readFrameHeader(segments); ok {
+		summary.Width, summary.Height = int(frame.Width), int(frame.Height)
+	}
+	summary.DisplayWidth, summary.DisplayHeight = summary.Width, summary.Height
+	if summary.Orientation >= 5 && summary.Orientation <= 8 {
+		summary.DisplayWidth, summary.DisplayHeight = summary.Height, summary.Width
+	}
+	return summary
+}
+
+// exifTagIDs returns the tags of IFD0 and the Exif IFD, skipping cleared entries
+func exifTagIDs(exifData []byte) []uint16 {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil
+	}
+
+	tags := make([]uint16, 0)
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+	for _, entry := range entries {
+		if entry.Tag == 0 {
+			continue
+		}
+		tags = append(tags, entry.Tag)
+		if entry.Tag == 0x8769 { // Exif IFD Pointer
+			exifEntries, _, _ := readIFD(exifData, order, tiffHeaderPos+int(entry.offset(order)))
+			for _, exifEntry := range exifEntries {
+				if exifEntry.Tag != 0 {
+					tags = append(tags, exifEntry.Tag)
+				}
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,138 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/jpeg"
+	"os"
+	"testing"
+)
+
+func TestInspectICC(t *testing.T) {
+	tests := []struct {
+		file        string
+		description string
+	}{
+		{"with_icc_profile_srgb.jpg", "uRGB"},
+		{"with_icc_profile_p3.jpg", "uP3"},
+		{"basic_copy.jpg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			jpegData, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+
+			summary, err := Inspect(jpegData)
+			if err != nil {
+				t.Fatalf("Inspect failed: %v", err)
+			}
+			if summary.Size != len(jpegData) {
+				t.Errorf("Expected size %d, got %d", len(jpegData), summary.Size)
+			}
+
+			if tt.description == "" {
+				if summary.ICC != nil {
+					t.Errorf("Expected no ICC profile, got %+v", summary.ICC)
+				}
+				return
+			}
+			if summary.ICC == nil {
+				t.Fatal("Expected ICC profile")
+			}
+			if summary.ICC.Description != tt.description {
+				t.Errorf("Expected description %q, got %q", tt.description, summary.ICC.Description)
+			}
+			if summary.ICC.ColorSpace != "RGB" {
+				t.Errorf("Expected RGB color space, got %q", summary.ICC.ColorSpace)
+			}
+			if summary.ICC.Size == 0 {
+				t.Error("Expected non-zero profile size")
+			}
+		})
+	}
+}
+
+func TestInspectExifTags(t *testing.T) {
+	jpegData, err := os.ReadFile("testdata/with_comprehensive_mixed.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	summary, err := Inspect(jpegData)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	present := make(map[uint16]bool)
+	for _, tag := range summary.ExifTags {
+		present[tag] = true
+	}
+	for _, tag := range []uint16{0x010F, 0x0110, 0x8825, 0xA434} {
+		if !present[tag] {
+			t.Errorf("Expected tag 0x%04X (%s) in summary", tag, ExifTags[tag].Name)
+		}
+	}
+
+	cleaned, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	summary, err = Inspect(cleaned)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	for _, tag := range summary.ExifTags {
+		if tag == 0x010F || tag == 0x0110 {
+			t.Errorf("Expected removed tag 0x%04X to be absent after stripping", tag)
+		}
+	}
+}
+
+func TestInspectDimensions(t *testing.T) {
+	jpegData, err := os.ReadFile("testdata/basic_copy.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(jpegData))
+	if err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+
+	tests := []struct {
+		orientation uint16
+		swapped     bool
+	}{
+		{0, false}, {1, false}, {3, false}, {4, false},
+		{5, true}, {6, true}, {7, true}, {8, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("orientation %d", tt.orientation), func(t *testing.T) {
+			data := jpegData
+			if tt.orientation != 0 {
+				e := newTestExif(binary.LittleEndian)
+				e.ifd0 = []testTag{e.short(0x0112, tt.orientation)}
+				data = insertSegment(jpegData, 0xE1, e.build())
+			}
+
+			summary, err := Inspect(data)
+			if err != nil {
+				t.Fatalf("Inspect failed: %v", err)
+			}
+			if summary.Width != config.Width || summary.Height != config.Height || summary.Orientation != int(tt.orientation) {
+				t.Errorf("Expected %dx%d orientation %d, got %dx%d orientation %d",
+					config.Width, config.Height, tt.orientation, summary.Width, summary.Height, summary.Orientation)
+			}
+			wantWidth, wantHeight := config.Width, config.Height
+			if tt.swapped {
+				wantWidth, wantHeight = config.Height, config.Width
+			}
+			if summary.DisplayWidth != wantWidth || summary.DisplayHeight != wantHeight {
+				t.Errorf("Expected display %dx%d, got %dx%d", wantWidth, wantHeight, summary.DisplayWidth, summary.DisplayHeight)
+			}
+		})
+	}
+}
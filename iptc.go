@@ -0,0 +1,247 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// PhotoshopHeader starts an APP13 Photoshop IRB segment
+	PhotoshopHeader = "Photoshop 3.0\x00"
+
+	// photoshopIPTCResourceID is the image resource block holding IPTC-IIM data
+	photoshopIPTCResourceID = 0x0404
+)
+
+// photoshopResource is an 8BIM image resource block
+type photoshopResource struct {
+	ID   uint16
+	Name []byte
+	Data []byte
+}
+
+// iptcDataset is a single IPTC-IIM dataset such as 2:116 (CopyrightNotice)
+type iptcDataset struct {
+	Record  byte
+	Dataset byte
+	Value   []byte
+}
+
+// parsePhotoshopResources parses the 8BIM resource blocks of an APP13 payload
+func parsePhotoshopResources(data []byte) ([]photoshopResource, error) {
+	if !bytes.HasPrefix(data, []byte(PhotoshopHeader)) {
+		return nil, fmt.Errorf("invalid Photoshop header")
+	}
+
+	resources := make([]photoshopResource, 0)
+	pos := len(PhotoshopHeader)
+	for pos+4 <= len(data) {
+		if string(data[pos:pos+4]) != "8BIM" {
+			return resources, fmt.Errorf("invalid resource signature at %d", pos)
+		}
+		pos += 4
+		if pos+3 > len(data) {
+			return resources, fmt.Errorf("truncated resource header")
+		}
+		id := binary.BigEndian.Uint16(data[pos : pos+2])
+		pos += 2
+
+		// Pascal string name, padded so that length byte + name is even
+		nameLen := int(data[pos])
+		nameEnd := pos + 1 + nameLen
+		if nameEnd > len(data) {
+			return resources, fmt.Errorf("truncated resource name")
+		}
+		name := data[pos+1 : nameEnd]
+		pos += 1 + nameLen
+		if (1+nameLen)%2 == 1 {
+			pos++
+		}
+
+		if pos+4 > len(data) {
+			return resources, fmt.Errorf("truncated resource size")
+		}
+		size := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		pos += 4
+		if size < 0 || pos+size > len(data) {
+			return resources, fmt.Errorf("resource 0x%04X exceeds segment", id)
+		}
+		resources = append(resources, photoshopResource{ID: id, Name: name, Data: data[pos : pos+size]})
+		pos += size
+		if size%2 == 1 {
+			pos++
+		}
+	}
+	return resources, nil
+}
+
+// parseIPTC parses IPTC-IIM datasets from the data of a 0x0404 resource block
+func parseIPTC(data []byte) ([]iptcDataset, error) {
+	datasets := make([]iptcDataset, 0)
+	pos := 0
+	for pos < len(data) {
+		if data[pos] != 0x1C {
+			// Trailing padding is common after the last dataset
+			if isZeroPadding(data[pos:]) {
+				break
+			}
+			return datasets, fmt.Errorf("invalid IPTC tag marker at %d", pos)
+		}
+		if pos+5 > len(data) {
+			return datasets, fmt.Errorf("truncated IPTC dataset header")
+		}
+		record, dataset := data[pos+1], data[pos+2]
+		size := int(binary.BigEndian.Uint16(data[pos+3 : pos+5]))
+		pos += 5
+
+		// Extended datasets store the length in the following size&0x7FFF bytes
+		if size&0x8000 != 0 {
+			lengthBytes := size & 0x7FFF
+			if lengthBytes > 4 || pos+lengthBytes > len(data) {
+				return datasets, fmt.Errorf("unsupported extended IPTC dataset")
+			}
+			size = 0
+			for _, b := range data[pos : pos+lengthBytes] {
+				size = size<<8 | int(b)
+			}
+			pos += lengthBytes
+		}
+
+		if pos+size > len(data) {
+			return datasets, fmt.Errorf("IPTC dataset %d:%d exceeds block", record, dataset)
+		}
+		datasets = append(datasets, iptcDataset{Record: record, Dataset: dataset, Value: data[pos : pos+size]})
+		pos += size
+	}
+	return datasets, nil
+}
+
+// isZeroPadding reports whether data consists only of zero bytes
+func isZeroPadding(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// iptcDatasets returns the IPTC datasets stored in an APP13 payload, if any
+func iptcDatasets(app13Data []byte) []iptcDataset {
+	resources, _ := parsePhotoshopResources(app13Data)
+	for _, resource := range resources {
+		if resource.ID == photoshopIPTCResourceID {
+			datasets, _ := parseIPTC(resource.Data)
+			return datasets
+		}
+	}
+	return nil
+}
+
+// utf8CodedCharacterSet is the 1:90 CodedCharacterSet value (ESC % G) declaring UTF-8
+var utf8CodedCharacterSet = []byte{0x1B, 0x25, 0x47}
+
+// filterIPTCSegment rebuilds an APP13 payload that keeps only the wanted record 2 datasets.
+// The envelope is rewritten rather than copied so the result stays well-formed: 1:00 and
+// 2:00 versions are emitted, the 1:90 coded character set is carried over and dataset
+// lengths are recomputed. Without a 1:90, UTF-8 is declared only when the kept values hold
+// non-ASCII text that is all valid UTF-8; other legacy values, usually Latin-1 or CP1252,
+// stay undeclared. It returns nil when none of the wanted datasets are present.
+func filterIPTCSegment(app13Data []byte, wanted []iptcDataset) []byte {
+	var charset []byte
+	kept := make([]iptcDataset, 0)
+	for _, dataset := range iptcDatasets(app13Data) {
+		if dataset.Record == 1 && dataset.Dataset == 90 {
+			charset = dataset.Value
+			continue
+		}
+		for _, w := range wanted {
+			if dataset.Record == w.Record && dataset.Dataset == w.Dataset && w.Record == 2 {
+				kept = append(kept, dataset)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	if charset == nil && !isASCIIDatasets(kept) && isUTF8Datasets(kept) {
+		charset = utf8CodedCharacterSet
+	}
+	return buildPhotoshopSegment([]photoshopResource{
+		{ID: photoshopIPTCResourceID, Data: buildIPTC(charset, kept)},
+	})
+}
+
+// buildIPTC serializes record 2 datasets behind a record 1 envelope
+func buildIPTC(charset []byte, datasets []iptcDataset) []byte {
+	version := []byte{0x00, 0x04}
+	b := new(bytes.Buffer)
+	writeIPTCDataset(b, iptcDataset{Record: 1, Dataset: 0, Value: version})
+	if charset != nil {
+		writeIPTCDataset(b, iptcDataset{Record: 1, Dataset: 90, Value: charset})
+	}
+	writeIPTCDataset(b, iptcDataset{Record: 2, Dataset: 0, Value: version})
+	for _, dataset := range datasets {
+		writeIPTCDataset(b, dataset)
+	}
+	return b.Bytes()
+}
+
+// writeIPTCDataset writes one dataset, using an extended length for values over 32767 bytes
+func writeIPTCDataset(b *bytes.Buffer, dataset iptcDataset) {
+	b.Write([]byte{0x1C, dataset.Record, dataset.Dataset})
+	if len(dataset.Value) <= 0x7FFF {
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(dataset.Value))))
+	} else {
+		b.Write([]byte{0x80, 0x04})
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(len(dataset.Value))))
+	}
+	b.Write(dataset.Value)
+}
+
+// buildPhotoshopSegment serializes resource blocks into an APP13 payload
+func buildPhotoshopSegment(resources []photoshopResource) []byte {
+	b := new(bytes.Buffer)
+	b.WriteString(PhotoshopHeader)
+	for _, resource := range resources {
+		b.WriteString("8BIM")
+		b.Write(binary.BigEndian.AppendUint16(nil, resource.ID))
+		b.WriteByte(byte(len(resource.Name)))
+		b.Write(resource.Name)
+		if (1+len(resource.Name))%2 == 1 {
+			b.WriteByte(0)
+		}
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(len(resource.Data))))
+		b.Write(resource.Data)
+		if len(resource.Data)%2 == 1 {
+			b.WriteByte(0)
+		}
+	}
+	return b.Bytes()
+}
+
+// isASCIIDatasets reports whether every dataset value is 7-bit ASCII
+func isASCIIDatasets(datasets []iptcDataset) bool {
+	for _, dataset := range datasets {
+		for _, c := range dataset.Value {
+			if c >= 0x80 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isUTF8Datasets reports whether every dataset value is valid UTF-8
+func isUTF8Datasets(datasets []iptcDataset) bool {
+	for _, dataset := range datasets {
+		if !utf8.Valid(dataset.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIPTC(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_iptc.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	app13 := getSegmentPayloads(t, jpegData, 0xED)
+	if len(app13) != 1 {
+		t.Fatalf("Expected one APP13 segment, got %d", len(app13))
+	}
+
+	resources, err := parsePhotoshopResources(app13[0])
+	if err != nil {
+		t.Fatalf("parsePhotoshopResources failed: %v", err)
+	}
+	if len(resources) != 1 || resources[0].ID != photoshopIPTCResourceID {
+		t.Fatalf("Expected a single IPTC resource, got %+v", resources)
+	}
+
+	values := map[byte]string{}
+	for _, dataset := range iptcDatasets(app13[0]) {
+		if dataset.Record == 2 {
+			values[dataset.Dataset] = string(dataset.Value)
+		}
+	}
+	expected := map[byte]string{
+		80:  "Test Photographer",
+		116: "Copyright 2024 Test",
+		120: "Test Caption",
+	}
+	for dataset, value := range expected {
+		if values[dataset] != value {
+			t.Errorf("Expected 2:%d = %q, got %q", dataset, value, values[dataset])
+		}
+	}
+}
+
+func TestParseIPTCInvalid(t *testing.T) {
+	if _, err := parsePhotoshopResources([]byte("not photoshop")); err == nil {
+		t.Error("Expected error for missing Photoshop header")
+	}
+	if _, err := parseIPTC([]byte{0x1C, 0x02, 0x74, 0x00, 0x10, 'x'}); err == nil {
+		t.Error("Expected error for dataset exceeding block")
+	}
+	datasets, err := parseIPTC([]byte{0x1C, 0x02, 0x74, 0x00, 0x01, 'x', 0x00, 0x00})
+	if err != nil || len(datasets) != 1 {
+		t.Errorf("Expected trailing padding to be ignored, got %v, %v", datasets, err)
+	}
+}
+
+func TestFilterIPTCSegment(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_iptc.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	app13 := getSegmentPayloads(t, jpegData, 0xED)[0]
+
+	filtered := filterIPTCSegment(app13, []iptcDataset{{Record: 2, Dataset: 116}})
+	if filtered == nil {
+		t.Fatal("Expected CopyrightNotice to be kept")
+	}
+
+	resources, err := parsePhotoshopResources(filtered)
+	if err != nil || len(resources) != 1 {
+		t.Fatalf("Expected one resource block, got %d (%v)", len(resources), err)
+	}
+	datasets, err := parseIPTC(resources[0].Data)
+	if err != nil {
+		t.Fatalf("Rebuilt IPTC does not parse: %v", err)
+	}
+
+	expected := []struct {
+		record, dataset byte
+		value           string
+	}{
+		{1, 0, "\x00\x04"},
+		{2, 0, "\x00\x04"},
+		{2, 116, "Copyright 2024 Test"},
+	}
+	if len(datasets) != len(expected) {
+		t.Fatalf("Expected %d datasets, got %+v", len(expected), datasets)
+	}
+	for i, e := range expected {
+		d := datasets[i]
+		if d.Record != e.record || d.Dataset != e.dataset || string(d.Value) != e.value {
+			t.Errorf("Dataset %d: expected %d:%d %q, got %d:%d %q", i, e.record, e.dataset, e.value, d.Record, d.Dataset, d.Value)
+		}
+	}
+
+	if filterIPTCSegment(app13, []iptcDataset{{Record: 2, Dataset: 105}}) != nil {
+		t.Error("Expected nil when no wanted dataset is present")
+	}
+}
+
+func TestFilterIPTCSegmentCharset(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 40000)
+	app13 := buildPhotoshopSegment([]photoshopResource{{
+		ID:   photoshopIPTCResourceID,
+		Name: []byte("x"),
+		Data: buildIPTC(nil, []iptcDataset{
+			{Record: 2, Dataset: 80, Value: []byte("山田太郎")},
+			{Record: 2, Dataset: 120, Value: long},
+		}),
+	}})
+
+	filtered := filterIPTCSegment(app13, []iptcDataset{{Record: 2, Dataset: 80}, {Record: 2, Dataset: 120}})
+	datasets := iptcDatasets(filtered)
+	values := make(map[[2]byte][]byte)
+	for _, d := range datasets {
+		values[[2]byte{d.Record, d.Dataset}] = d.Value
+	}
+	if !bytes.Equal(values[[2]byte{1, 90}], utf8CodedCharacterSet) {
+		t.Errorf("Expected UTF-8 coded character set for non-ASCII values, got %q", values[[2]byte{1, 90}])
+	}
+	if string(values[[2]byte{2, 80}]) != "山田太郎" {
+		t.Errorf("Expected By-line to survive, got %q", values[[2]byte{2, 80}])
+	}
+	if !bytes.Equal(values[[2]byte{2, 120}], long) {
+		t.Errorf("Expected extended-length caption to survive, got %d bytes", len(values[[2]byte{2, 120}]))
+	}
+}
+
+func TestFilterIPTCSegmentLegacyCharset(t *testing.T) {
+	// "Müller" in Latin-1, as written by legacy tools without a 1:90
+	app13 := buildPhotoshopSegment([]photoshopResource{{
+		ID:   photoshopIPTCResourceID,
+		Data: buildIPTC(nil, []iptcDataset{{Record: 2, Dataset: 80, Value: []byte("M\xFCller")}}),
+	}})
+
+	filtered := filterIPTCSegment(app13, []iptcDataset{{Record: 2, Dataset: 80}})
+	for _, d := range iptcDatasets(filtered) {
+		if d.Record == 1 && d.Dataset == 90 {
+			t.Errorf("Expected no coded character set for Latin-1 values, got %q", d.Value)
+		}
+		if d.Record == 2 && d.Dataset == 80 && string(d.Value) != "M\xFCller" {
+			t.Errorf("Expected By-line to survive byte for byte, got %q", d.Value)
+		}
+	}
+}
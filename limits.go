@@ -0,0 +1,39 @@
+package jpegmetawebstrip
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Structural limits of the JPEGs Strip reads and writes. Inputs beyond them are rejected
+// when parsed, and outputs are checked against them before they are written, so a cleaned
+// file never carries a segment its length field cannot describe.
+const (
+	// MaxSegments is the largest number of marker segments accepted in an input or written
+	// to an output. Real images carry a few dozen; the limit bounds per-segment work.
+	MaxSegments = 4096
+
+	// MaxSegmentSize is the largest payload of a marker segment: the 16-bit length field
+	// minus the two bytes of the field itself
+	MaxSegmentSize = 65533
+)
+
+// ErrLimitExceeded is wrapped by errors about inputs or outputs beyond the structural limits
+var ErrLimitExceeded = errors.New("JPEG structure limit exceeded")
+
+// checkSegmentLimits verifies segments against MaxSegments and MaxSegmentSize. Entropy-coded
+// scan data (marker 0) is not a marker segment and is not limited.
+func checkSegmentLimits(segments []*jpegSegment) error {
+	if len(segments) > MaxSegments {
+		return fmt.Errorf("%w: %d segments (max %d)", ErrLimitExceeded, len(segments), MaxSegments)
+	}
+	for _, segment := range segments {
+		if segment.MarkerId == 0 {
+			continue
+		}
+		if len(segment.Data) > MaxSegmentSize {
+			return fmt.Errorf("%w: segment 0x%02X of %d bytes (max %d)", ErrLimitExceeded, segment.MarkerId, len(segment.Data), MaxSegmentSize)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSegmentLimits(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_xmp.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	t.Run("too many segments", func(t *testing.T) {
+		data := jpegData
+		for i := 0; i <= MaxSegments; i++ {
+			data = insertSegment(data, 0xFE, []byte("c"))
+		}
+		if _, _, err := Strip(data); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("Strip error = %v, want ErrLimitExceeded", err)
+		}
+		if _, err := StripStream(bytes.NewReader(data), new(bytes.Buffer)); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("StripStream error = %v, want ErrLimitExceeded", err)
+		}
+		if _, err := Inspect(data); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("Inspect error = %v, want ErrLimitExceeded", err)
+		}
+	})
+
+	t.Run("segment too large", func(t *testing.T) {
+		oversized := []*jpegSegment{{MarkerId: 0xE1, Data: append([]byte(ExifHeader), make([]byte, MaxSegmentSize)...)}}
+		if err := checkSegmentLimits(oversized); !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("checkSegmentLimits error = %v, want ErrLimitExceeded", err)
+		}
+		scan := []*jpegSegment{{MarkerId: 0, Data: make([]byte, MaxSegmentSize+1)}}
+		if err := checkSegmentLimits(scan); err != nil {
+			t.Errorf("scan data must not be limited: %v", err)
+		}
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		if _, _, err := Strip(jpegData, WithKeepXMPRating(), WithXMPPadding(2048)); err != nil {
+			t.Errorf("Strip failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package jpegmetawebstrip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxMIMEDepth bounds nesting of multipart and message/rfc822 entities
+const maxMIMEDepth = 32
+
+// contentLengthHeader matches a Content-Length header line so it can be updated
+var contentLengthHeader = regexp.MustCompile(`(?mi)^(Content-Length:[ \t]*)\d+`)
+
+// StripMail copies a MIME message (RFC 5322 with MIME parts) from r to w, stripping every
+// JPEG attachment and inline image. Parts are re-encoded with their original transfer
+// encoding and Content-Length headers are updated; all other bytes, including header
+// order and boundaries, are copied unchanged. Entries counts the leaf parts seen.
+func StripMail(r io.Reader, w io.Writer, opts ...Option) (*ArchiveResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	result := &ArchiveResult{}
+	out, err := stripMIMEEntity(data, result, opts, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(out); err != nil {
+		return nil, fmt.Errorf("failed to write message: %w", err)
+	}
+	return result, nil
+}
+
+// stripMIMEEntity processes one entity (header and body) and returns it re-serialized
+func stripMIMEEntity(entity []byte, result *ArchiveResult, opts []Option, depth int) ([]byte, error) {
+	if depth > maxMIMEDepth {
+		return nil, fmt.Errorf("MIME nesting exceeds %d levels", maxMIMEDepth)
+	}
+
+	headerEnd, bodyStart := splitMIMEHeader(entity)
+	rawHeader, body := entity[:headerEnd], entity[bodyStart:]
+	header, err := textproto.NewReader(bufio.NewReader(io.MultiReader(
+		bytes.NewReader(rawHeader), strings.NewReader("\r\n\r\n")))).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MIME header: %w", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	var newBody []byte
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		newBody, err = stripMultipart(body, params["boundary"], result, opts, depth)
+	case mediaType == "message/rfc822":
+		newBody, err = stripMIMEEntity(body, result, opts, depth+1)
+	default:
+		result.Entries++
+		if !isJPEGPart(mediaType, header) {
+			return entity, nil
+		}
+		newBody, err = stripMIMEJPEG(partName(mediaType, header), body, header.Get("Content-Transfer-Encoding"), lineEnding(entity), result, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newBody) != len(body) {
+		rawHeader = contentLengthHeader.ReplaceAll(rawHeader, []byte("${1}"+strconv.Itoa(len(newBody))))
+	}
+	out := make([]byte, 0, len(rawHeader)+len(newBody)+4)
+	out = append(out, rawHeader...)
+	out = append(out, entity[headerEnd:bodyStart]...)
+	return append(out, newBody...), nil
+}
+
+// splitMIMEHeader returns the end of the header and the start of the body
+func splitMIMEHeader(entity []byte) (int, int) {
+	crlf := bytes.Index(entity, []byte("\r\n\r\n"))
+	lf := bytes.Index(entity, []byte("\n\n"))
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf < lf):
+		return crlf, crlf + 4
+	case lf >= 0:
+		return lf, lf + 2
+	}
+	return len(entity), len(entity)
+}
+
+// lineEnding returns the line ending used by an entity
+func lineEnding(entity []byte) string {
+	if i := bytes.IndexByte(entity, '\n'); i > 0 && entity[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// isJPEGPart reports whether a leaf part carries a JPEG image
+func isJPEGPart(mediaType string, header textproto.MIMEHeader) bool {
+	switch mediaType {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return true
+	case "application/octet-stream", "":
+		for _, h := range []string{"Content-Disposition", "Content-Type"} {
+			if _, params, err := mime.ParseMediaType(header.Get(h)); err == nil {
+				if IsJPEGName(params["filename"]) || IsJPEGName(params["name"]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// partName names a leaf part for ArchiveResult.Failed by its file name, or its media type
+// when it has none
+func partName(mediaType string, header textproto.MIMEHeader) string {
+	for _, h := range []string{"Content-Disposition", "Content-Type"} {
+		if _, params, err := mime.ParseMediaType(header.Get(h)); err == nil {
+			if name := params["filename"] + params["name"]; name != "" {
+				return name
+			}
+		}
+	}
+	return mediaType
+}
+
+// stripMultipart processes each part of a multipart body, keeping preamble, delimiters
+// and epilogue byte-for-byte
+func stripMultipart(body []byte, boundary string, result *ArchiveResult, opts []Option, depth int) ([]byte, error) {
+	delimiter := []byte("--" + boundary)
+
+	// Delimiter lines start at the beginning of the body or after a line break
+	starts := make([]int, 0)
+	for pos := 0; pos < len(body); {
+		i := bytes.Index(body[pos:], delimiter)
+		if i < 0 {
+			break
+		}
+		at := pos + i
+		if at == 0 || body[at-1] == '\n' {
+			starts = append(starts, at)
+		}
+		pos = at + len(delimiter)
+	}
+
+	out := make([]byte, 0, len(body))
+	copied := 0
+	for i, start := range starts {
+		if bytes.HasPrefix(body[start+len(delimiter):], []byte("--")) || i == len(starts)-1 {
+			break
+		}
+		lineEnd := bytes.IndexByte(body[start:], '\n')
+		if lineEnd < 0 {
+			break
+		}
+		partStart := start + lineEnd + 1
+
+		// The line break before the next delimiter belongs to the delimiter
+		partEnd := starts[i+1] - 1
+		if partEnd > partStart && body[partEnd-1] == '\r' {
+			partEnd--
+		}
+		if partEnd < partStart {
+			continue
+		}
+
+		part, err := stripMIMEEntity(body[partStart:partEnd], result, opts, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, body[copied:partStart]...)
+		out = append(out, part...)
+		copied = partEnd
+	}
+	return append(out, body[copied:]...), nil
+}
+
+// stripMIMEJPEG decodes a JPEG part body, strips it and encodes it again
+func stripMIMEJPEG(name string, body []byte, encoding, eol string, result *ArchiveResult, opts []Option) ([]byte, error) {
+	var data []byte
+	var err error
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		data, err = base64.StdEncoding.DecodeString(string(bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, body)))
+	case "quoted-printable":
+		data, err = io.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+	default:
+		data = body
+	}
+	if err != nil {
+		// The part is left as it is, metadata included, so it must be reported
+		result.fail(name, fmt.Errorf("failed to decode %s body: %w", strings.TrimSpace(encoding), err))
+		return body, nil
+	}
+
+	cleaned := stripEntry(name, data, result, opts)
+	if len(cleaned) == len(data) {
+		return body, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		encoded := base64.StdEncoding.EncodeToString(cleaned)
+		b := new(bytes.Buffer)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + eol)
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + trailingLineBreak(body, eol))
+		return b.Bytes(), nil
+	case "quoted-printable":
+		b := new(bytes.Buffer)
+		qw := quotedprintable.NewWriter(b)
+		qw.Binary = true
+		if _, err := qw.Write(cleaned); err != nil {
+			return nil, fmt.Errorf("failed to encode part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode part: %w", err)
+		}
+		return b.Bytes(), nil
+	}
+	return cleaned, nil
+}
+
+// trailingLineBreak returns eol when the original encoded body ended with a line break
+func trailingLineBreak(body []byte, eol string) string {
+	if bytes.HasSuffix(body, []byte("\n")) {
+		return eol
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// base64Lines encodes data as base64 wrapped at 76 columns with CRLF line breaks
+func base64Lines(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	lines := make([]string, 0)
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	return strings.Join(append(lines, encoded), "\r\n")
+}
+
+func TestStripMail(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	message := strings.Join([]string{
+		"From: alice@example.com",
+		"To: bob@example.com",
+		"Subject: Holiday photos",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"This is a multi-part message in MIME format.",
+		"--outer",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"See attached.",
+		"--outer",
+		`Content-Type: multipart/related; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/html",
+		"",
+		`<img src="cid:photo1">`,
+		"--inner",
+		"Content-Type: application/octet-stream; name=\"inline.jpg\"",
+		"Content-Transfer-Encoding: base64",
+		"Content-ID: <photo1>",
+		"",
+		base64Lines(jpegData),
+		"--inner--",
+		"--outer",
+		"Content-Type: image/jpeg",
+		`Content-Disposition: attachment; filename="beach.jpg"`,
+		"Content-Transfer-Encoding: base64",
+		"X-Custom: keep me",
+		"",
+		base64Lines(jpegData),
+		"--outer--",
+		"epilogue",
+		"",
+	}, "\r\n")
+
+	output := new(bytes.Buffer)
+	result, err := StripMail(strings.NewReader(message), output)
+	if err != nil {
+		t.Fatalf("StripMail failed: %v", err)
+	}
+	if result.Entries != 4 || result.Stripped != 2 {
+		t.Errorf("Expected 4 leaf parts with 2 stripped, got %+v", result)
+	}
+	if result.Total != int64(2*(len(jpegData)-len(expected))) {
+		t.Errorf("Expected %d bytes removed, got %d", 2*(len(jpegData)-len(expected)), result.Total)
+	}
+
+	out := output.String()
+	for _, unchanged := range []string{
+		"From: alice@example.com\r\nTo: bob@example.com\r\nSubject: Holiday photos\r\n",
+		"This is a multi-part message in MIME format.\r\n--outer\r\n",
+		"Content-Transfer-Encoding: base64\r\nX-Custom: keep me\r\n\r\n",
+		"--outer--\r\nepilogue\r\n",
+	} {
+		if !strings.Contains(out, unchanged) {
+			t.Errorf("Expected output to keep %q", unchanged)
+		}
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("Output is not a valid message: %v", err)
+	}
+	images := collectJPEGParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 JPEG parts, got %d", len(images))
+	}
+	for i, image := range images {
+		if !bytes.Equal(image, expected) {
+			t.Errorf("JPEG part %d does not match the stripped image", i)
+		}
+	}
+}
+
+// collectJPEGParts decodes all base64 JPEG parts of a multipart body
+func collectJPEGParts(t *testing.T, contentType string, body io.Reader) [][]byte {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil
+	}
+	images := make([][]byte, 0)
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "multipart/") {
+			images = append(images, collectJPEGParts(t, part.Header.Get("Content-Type"), part)...)
+			continue
+		}
+		if part.Header.Get("Content-Transfer-Encoding") != "base64" {
+			continue
+		}
+		data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatalf("Failed to decode part: %v", err)
+		}
+		images = append(images, data)
+	}
+	return images
+}
+
+func TestStripMailContentLength(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	message := "Content-Type: image/jpeg\nContent-Transfer-Encoding: binary\nContent-Length: " +
+		strconv.Itoa(len(jpegData)) + "\n\n" + string(jpegData)
+
+	output := new(bytes.Buffer)
+	if _, err := StripMail(strings.NewReader(message), output); err != nil {
+		t.Fatalf("StripMail failed: %v", err)
+	}
+	msg, err := mail.ReadMessage(output)
+	if err != nil {
+		t.Fatalf("Output is not a valid message: %v", err)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if msg.Header.Get("Content-Length") != strconv.Itoa(len(body)) {
+		t.Errorf("Expected Content-Length %d, got %s", len(body), msg.Header.Get("Content-Length"))
+	}
+	if len(body) >= len(jpegData) {
+		t.Errorf("Expected binary JPEG body to shrink, got %d bytes", len(body))
+	}
+}
+
+func TestStripMailUndecodable(t *testing.T) {
+	message := "Content-Type: image/jpeg\r\n" +
+		"Content-Disposition: attachment; filename=\"bad.jpg\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		"/9j/not*base64!\r\n"
+
+	output := new(bytes.Buffer)
+	result, err := StripMail(strings.NewReader(message), output)
+	if err != nil {
+		t.Fatalf("StripMail failed: %v", err)
+	}
+	if output.String() != message {
+		t.Error("Expected the undecodable part to be left as it was")
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Name != "bad.jpg" || result.Failed[0].Err == nil {
+		t.Errorf("Expected the undecodable part to be reported as failed, got %+v", result.Failed)
+	}
+}
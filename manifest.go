@@ -0,0 +1,104 @@
+package jpegmetawebstrip
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrManifestSignature is returned by SignedManifest.Verify when the signature does not
+// match the manifest and key
+var ErrManifestSignature = errors.New("manifest signature is invalid")
+
+// ManifestEntry records that one asset was sanitized, with which policy and to what effect
+type ManifestEntry struct {
+	Name         string  `json:"name,omitempty"`
+	InputSHA256  string  `json:"input_sha256"`
+	OutputSHA256 string  `json:"output_sha256"`
+	Policy       string  `json:"policy"`
+	Result       *Result `json:"result,omitempty"`
+}
+
+// Manifest lists sanitized assets for compliance records
+type Manifest struct {
+	Created time.Time       `json:"created"`
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// SignedManifest is the JSON encoding of a Manifest with an Ed25519 signature over exactly
+// those bytes, so verification does not depend on how the JSON is re-encoded
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature []byte          `json:"signature"`
+}
+
+// NewManifestEntry describes the stripping of input into output with policy; a nil policy
+// is recorded as "default"
+func NewManifestEntry(name string, input, output []byte, policy *Policy, result *Result) ManifestEntry {
+	return ManifestEntry{
+		Name:         name,
+		InputSHA256:  sha256Hex(input),
+		OutputSHA256: sha256Hex(output),
+		Policy:       policy.name(),
+		Result:       result,
+	}
+}
+
+// sha256Hex returns the hex-encoded SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Add appends an entry to the manifest
+func (m *Manifest) Add(entry ManifestEntry) {
+	m.Entries = append(m.Entries, entry)
+}
+
+// AddBatch appends an entry for every file BatchStrip stripped without error. policy is
+// recorded as the policy whose options were passed to BatchStrip; a nil policy is
+// recorded as "default".
+func (m *Manifest) AddBatch(results []FileResult, policy *Policy) {
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		m.Add(ManifestEntry{
+			Name:         r.Path,
+			InputSHA256:  r.InputSHA256,
+			OutputSHA256: r.OutputSHA256,
+			Policy:       policy.name(),
+			Result:       r.Result,
+		})
+	}
+}
+
+// Sign encodes the manifest as JSON and signs it with key
+func (m *Manifest) Sign(key ed25519.PrivateKey) (*SignedManifest, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("failed to sign manifest: invalid Ed25519 private key")
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return &SignedManifest{Manifest: encoded, Signature: ed25519.Sign(key, encoded)}, nil
+}
+
+// Verify checks the signature against the trusted public key and returns the decoded
+// manifest. It fails with ErrManifestSignature when the manifest was altered or signed
+// with another key.
+func (s *SignedManifest) Verify(key ed25519.PublicKey) (*Manifest, error) {
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, s.Manifest, s.Signature) {
+		return nil, ErrManifestSignature
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(s.Manifest, m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,108 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSignedManifest(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	policy := NewPolicy("web")
+	cleaned, result, err := Strip(jpegData, policy.Options...)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	manifest := &Manifest{Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	manifest.Add(NewManifestEntry("photos/a.jpg", jpegData, cleaned, policy, result))
+	manifest.Add(NewManifestEntry("photos/b.jpg", jpegData, cleaned, nil, result))
+
+	public, private, err := ed25519.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{7}, 64)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := manifest.Sign(private)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// The signed manifest survives a JSON round trip and verifies
+	decoded := decodeSignedManifest(t, signed)
+	verified, err := decoded.Verify(public)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	entry := verified.Entries[0]
+	inputSum := sha256.Sum256(jpegData)
+	if entry.InputSHA256 != hex.EncodeToString(inputSum[:]) || entry.Policy != "web" || entry.Result.Total != result.Total {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if verified.Entries[1].Policy != "default" || !verified.Created.Equal(manifest.Created) {
+		t.Errorf("Unexpected manifest: %+v", verified)
+	}
+
+	// Tampering and foreign keys are detected
+	tampered := decoded
+	tampered.Manifest = bytes.Replace(decoded.Manifest, []byte(`"web"`), []byte(`"raw"`), 1)
+	if _, err := tampered.Verify(public); !errors.Is(err, ErrManifestSignature) {
+		t.Errorf("Expected ErrManifestSignature for a tampered manifest, got %v", err)
+	}
+	other, _, _ := ed25519.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{9}, 64)))
+	if _, err := decoded.Verify(other); !errors.Is(err, ErrManifestSignature) {
+		t.Errorf("Expected ErrManifestSignature for another key, got %v", err)
+	}
+	if _, err := manifest.Sign(private[:10]); err == nil {
+		t.Error("Expected an error for an invalid private key")
+	}
+}
+
+func TestManifestAddBatch(t *testing.T) {
+	paths, originals := copyTestFiles(t, t.TempDir(), []string{"with_xmp.jpg", "basic_copy.jpg"})
+	paths = append(paths, filepath.Join(t.TempDir(), "missing.jpg"))
+	policy := NewPolicy("web")
+	results, err := BatchStrip(context.Background(), paths, 2, policy.Options...)
+	if err != nil {
+		t.Fatalf("BatchStrip failed: %v", err)
+	}
+
+	manifest := &Manifest{}
+	manifest.AddBatch(results, policy)
+	if len(manifest.Entries) != len(originals) {
+		t.Fatalf("Expected entries for the %d stripped files only, got %+v", len(originals), manifest.Entries)
+	}
+	for i, entry := range manifest.Entries {
+		written, err := os.ReadFile(paths[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := NewManifestEntry(paths[i], originals[i], written, policy, results[i].Result)
+		if entry != want {
+			t.Errorf("Entry %d = %+v, want %+v", i, entry, want)
+		}
+	}
+}
+
+// decodeSignedManifest round-trips a signed manifest through JSON
+func decodeSignedManifest(t *testing.T, signed *SignedManifest) SignedManifest {
+	encoded, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SignedManifest
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
@@ -0,0 +1,31 @@
+package jpegmetawebstrip
+
+import (
+	"path"
+	"strings"
+)
+
+// JPEGExtensions lists the file extensions treated as JPEG, including the .jfif files
+// exported by Windows Photos and the older .jpe and .jif
+var JPEGExtensions = []string{".jpg", ".jpeg", ".jpe", ".jfif", ".jif"}
+
+// IsJPEGName reports whether a file name or path has a JPEG extension, ignoring case
+func IsJPEGName(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, jpegExt := range JPEGExtensions {
+		if ext == jpegExt {
+			return true
+		}
+	}
+	return false
+}
+
+// WebJPEGName returns name with its JPEG extension replaced by ".jpg", for tools that
+// publish stripped files under the extension every browser and CDN recognizes.
+// Names that already end in ".jpg" or are not JPEG names are returned unchanged.
+func WebJPEGName(name string) string {
+	if !IsJPEGName(name) || path.Ext(name) == ".jpg" {
+		return name
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + ".jpg"
+}
@@ -0,0 +1,31 @@
+package jpegmetawebstrip
+
+import "testing"
+
+func TestJPEGNames(t *testing.T) {
+	testCases := []struct {
+		name   string
+		isJPEG bool
+		web    string
+	}{
+		{"photo.jpg", true, "photo.jpg"},
+		{"photo.JPEG", true, "photo.jpg"},
+		{"exports/Photos 2024.jfif", true, "exports/Photos 2024.jpg"},
+		{"scan.jpe", true, "scan.jpg"},
+		{"old.JIF", true, "old.jpg"},
+		{"archive.jpg.zip", false, "archive.jpg.zip"},
+		{"image.png", false, "image.png"},
+		{"jfif", false, "jfif"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsJPEGName(tc.name); got != tc.isJPEG {
+				t.Errorf("IsJPEGName(%q) = %v, expected %v", tc.name, got, tc.isJPEG)
+			}
+			if got := WebJPEGName(tc.name); got != tc.web {
+				t.Errorf("WebJPEGName(%q) = %q, expected %q", tc.name, got, tc.web)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Option configures how Strip processes a JPEG
+type Option func(*options)
+
+// options holds the settings collected from Option values
+type options struct {
+	strictUnknown bool
+	timeout       time.Duration
+	resourceStats bool
+
+	// keepSoftware keeps the EXIF Software and ProcessingSoftware tags
+	keepSoftware bool
+
+	// keepXMPRating keeps xmp:Rating and xmp:Label in a minimal XMP packet
+	keepXMPRating bool
+
+	// processedComment is the marker COM payload written to the output; "" writes none
+	processedComment string
+
+	// trustProcessed returns input carrying the processing markers of this policy unchanged
+	trustProcessed bool
+
+	// processedMarker is the APP15 processing marker written to the output; nil writes none
+	processedMarker *ProcessedMarker
+
+	// preserved lists APPn segments kept regardless of any other setting
+	preserved []PreservedSegment
+
+	// removeRadiometric removes FLIR thermal measurement data
+	removeRadiometric bool
+
+	// keepCopyright keeps EXIF, IPTC and XMP attribution in minimal rebuilt blocks
+	keepCopyright bool
+
+	// xmpPadding is the whitespace added to rebuilt writable XMP packets
+	xmpPadding int
+
+	// iccV2Swap replaces v4 sRGB-like ICC profiles with a v2 sRGB profile
+	iccV2Swap bool
+
+	// densityDPI normalizes conflicting JFIF and EXIF resolution to this value; 0 disables
+	densityDPI int
+
+	// minimizeAdobe trims vendor padding from APP14 Adobe segments
+	minimizeAdobe bool
+
+	// captureComments records removed COM segments in Result.RemovedComments
+	captureComments bool
+
+	// colorVerify decodes input and output and compares channel histograms
+	colorVerify    bool
+	colorTolerance float64
+
+	// maxIFDDepth and maxIFDEntries bound the work spent on EXIF directory structures
+	maxIFDDepth   int
+	maxIFDEntries int
+	// strictIFDLimits fails with *IFDLimitError instead of dropping EXIF over the limits
+	strictIFDLimits bool
+
+	// trimTrailer makes StripStream drop bytes after EOI
+	trimTrailer bool
+
+	// canonicalExif rebuilds EXIF with sorted entries and normalized value placement
+	canonicalExif bool
+	// byteOrder converts rebuilt EXIF to this byte order; nil keeps the source order
+	byteOrder binary.ByteOrder
+
+	// embeddedPreview strips the largest JPEG preview of raw input instead of failing
+	embeddedPreview bool
+
+	// thumbnailOnly removes the EXIF thumbnail and trailing data and leaves all other metadata
+	thumbnailOnly bool
+
+	// removeComments also removes COM segments in thumbnail-only mode
+	removeComments bool
+
+	// privacyOnly removes only GPS, serial numbers, owner names and unique IDs
+	privacyOnly bool
+
+	// thumbnailMaxBytes keeps IFD1 thumbnails up to this size; 0 removes all thumbnails
+	thumbnailMaxBytes int64
+
+	// whitelist removes every metadata marker and EXIF tag not listed below
+	whitelist         bool
+	whitelistMarkers  map[byte]bool
+	whitelistExifTags map[uint16]bool
+
+	// deadline is derived from timeout when processing starts
+	deadline time.Time
+}
+
+// newOptions applies opts on top of the default settings
+func newOptions(opts []Option) *options {
+	o := &options{maxIFDDepth: DefaultMaxIFDDepth, maxIFDEntries: DefaultMaxIFDEntries}
+	setWhitelist(o, defaultWhitelistMarkers[:], defaultWhitelistExifTags[:])
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithStrictUnknown makes Strip fail with an *UnknownMarkerError when the JPEG
+// contains segments the library does not recognize, instead of passing them through
+func WithStrictUnknown() Option {
+	return func(o *options) {
+		o.strictUnknown = true
+	}
+}
+
+// WithTimeout limits how long Strip may spend on a single image.
+// When the limit is exceeded Strip returns ErrTimeout. This is intended for
+// callers that cannot plumb a context, such as cgo or WASM bindings. The work is
+// abandoned, not cancelled: it stops at its next deadline check, which may come
+// after Strip has returned.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithIFDLimits bounds the worst-case work on adversarial EXIF: IFDs may nest at most
+// maxDepth levels (counting next-IFD links) and hold at most maxEntries entries in total.
+// EXIF segments over the limits are dropped, or rejected with WithStrictIFDLimits.
+// The defaults are DefaultMaxIFDDepth and DefaultMaxIFDEntries.
+func WithIFDLimits(maxDepth, maxEntries int) Option {
+	return func(o *options) {
+		o.maxIFDDepth = maxDepth
+		o.maxIFDEntries = maxEntries
+	}
+}
+
+// WithStrictIFDLimits makes Strip fail with an *IFDLimitError when EXIF exceeds the
+// IFD limits, instead of dropping the EXIF segment
+func WithStrictIFDLimits() Option {
+	return func(o *options) {
+		o.strictIFDLimits = true
+	}
+}
+
+// WithTrimTrailer makes StripStream drop any bytes after the EOI marker, such as appended
+// archives or vendor trailers. Strip always drops them because it rewrites the whole file.
+func WithTrimTrailer() Option {
+	return func(o *options) {
+		o.trimTrailer = true
+	}
+}
+
+// WithThumbnailOnly is a conservative mode that removes only the EXIF IFD1 thumbnail and
+// any data after EOI (also in StripStream), leaving every tag and segment intact, for the
+// size win of thumbnail removal without changing what metadata is published.
+// WithThumbnailMaxBytes still applies; custom segment handlers still run.
+func WithThumbnailOnly() Option {
+	return func(o *options) {
+		o.thumbnailOnly = true
+		o.trimTrailer = true
+	}
+}
+
+// WithNormalizeByteOrder rebuilds the cleaned EXIF as WithCanonicalExif does and converts
+// it to little-endian (II) byte order, so output does not depend on the camera brand.
+// EXIF that cannot be rebuilt safely, such as EXIF with a MakerNote, keeps its byte order.
+func WithNormalizeByteOrder() Option {
+	return func(o *options) {
+		o.byteOrder = binary.LittleEndian
+	}
+}
+
+// WithEmbeddedPreview makes Strip accept raw camera files by cleaning and returning their
+// largest embedded JPEG preview; Result.EmbeddedPreview names the raw format. Without it raw
+// input fails with *RawImageError. StripStream always refuses raw input.
+func WithEmbeddedPreview() Option {
+	return func(o *options) {
+		o.embeddedPreview = true
+	}
+}
+
+// WithCanonicalExif rebuilds the cleaned EXIF in canonical form: entries sorted by tag ID,
+// values of up to four bytes stored inline and larger ones word-aligned, with cleared
+// entries left out. Camera originals often have out-of-order tags that tools such as
+// exiv2 warn about. EXIF with a MakerNote or other unrelocatable offsets is left as is.
+func WithCanonicalExif() Option {
+	return func(o *options) {
+		o.canonicalExif = true
+	}
+}
+
+// WithResourceStats records parse/process/write durations and allocated bytes into Result.Resources
+func WithResourceStats() Option {
+	return func(o *options) {
+		o.resourceStats = true
+	}
+}
+
+// WithThumbnailMaxBytes keeps EXIF thumbnails whose size is at most n bytes and removes larger ones.
+// Kept thumbnails lose any GPS and camera tags duplicated into IFD1, and their orientation
+// is aligned with the main image.
+func WithThumbnailMaxBytes(n int64) Option {
+	return func(o *options) {
+		o.thumbnailMaxBytes = n
+	}
+}
+
+// WithWhitelistMode switches to deny-by-default: only markers and EXIF tags in the
+// whitelist are kept (DefaultWhitelistMarkers() and DefaultWhitelistExifTags() unless
+// overridden with WithWhitelist). Structural image segments are always kept.
+func WithWhitelistMode() Option {
+	return func(o *options) {
+		o.whitelist = true
+	}
+}
+
+// WithWhitelist replaces the markers and EXIF tags allowed in whitelist mode
+func WithWhitelist(markers []byte, exifTags []uint16) Option {
+	return func(o *options) {
+		setWhitelist(o, markers, exifTags)
+	}
+}
+
+// setWhitelist stores the allowed markers and tags as lookup sets
+func setWhitelist(o *options, markers []byte, exifTags []uint16) {
+	o.whitelistMarkers = make(map[byte]bool, len(markers))
+	for _, marker := range markers {
+		o.whitelistMarkers[marker] = true
+	}
+	o.whitelistExifTags = make(map[uint16]bool, len(exifTags))
+	for _, tag := range exifTags {
+		o.whitelistExifTags[tag] = true
+	}
+}
+
+// WithCaptureComments records the decoded text and detected encoding of every removed
+// comment in Result.RemovedComments, so reports show Japanese comments without mojibake
+func WithCaptureComments() Option {
+	return func(o *options) {
+		o.captureComments = true
+	}
+}
+
+// WithKeepSoftware keeps the EXIF Software and ProcessingSoftware tags, which are
+// otherwise removed because they leak editing workflow information
+func WithKeepSoftware() Option {
+	return func(o *options) {
+		o.keepSoftware = true
+	}
+}
+
+// WithKeepXMPRating keeps xmp:Rating and xmp:Label by rebuilding a minimal XMP packet
+// that contains only those properties; everything else in the XMP is still removed.
+// A tiff:Orientation is carried over but made to agree with EXIF.
+func WithKeepXMPRating() Option {
+	return func(o *options) {
+		o.keepXMPRating = true
+	}
+}
+
+// WithProcessedComment writes a tiny COM segment, "meta-stripped" or "meta-stripped:<policyID>",
+// so later stages can recognize processed files with DetectProcessed. A marker already in
+// the input, of this or another policy, is replaced; the input is still stripped unless
+// WithTrustProcessedMarkers is given.
+func WithProcessedComment(policyID string) Option {
+	return func(o *options) {
+		o.processedComment = processedComment(policyID)
+	}
+}
+
+// WithTrustProcessedMarkers returns input that already carries every processing marker this
+// call writes (WithProcessedComment, WithProcessedMarker) unchanged, with
+// Result.AlreadyProcessed set, instead of stripping it again. The markers are not
+// authenticated: anyone can add them to a file that still holds all its metadata. Use this
+// only when every input comes from stages you control, never for uploads or third-party files.
+func WithTrustProcessedMarkers() Option {
+	return func(o *options) {
+		o.trustProcessed = true
+	}
+}
+
+// WithProcessedMarker writes a 16-byte APP15 segment recording version and a digest of
+// policyID instead of, or in addition to, the COM of WithProcessedComment, so that stages
+// agreeing on the policy can label their output without the policy name. The marker is a
+// hint like the COM, not evidence: any marker already in the input is replaced and the input
+// is still stripped unless WithTrustProcessedMarkers is given.
+func WithProcessedMarker(policyID string, version uint16) Option {
+	return func(o *options) {
+		m := NewProcessedMarker(policyID, version)
+		o.processedMarker = &m
+	}
+}
+
+// WithPreserveSegments keeps every APPn segment matching one of the given signatures
+// untouched, whatever the other options, registered handlers or whitelist say, so that
+// business-critical embedded data cannot be stripped by accident. Matching segments also
+// count as known in strict mode. It may be given several times; the lists are combined.
+func WithPreserveSegments(segments ...PreservedSegment) Option {
+	return func(o *options) {
+		o.preserved = append(o.preserved, segments...)
+	}
+}
+
+// WithRemoveRadiometric removes FLIR radiometric data, e.g. before sharing thermal images
+// publicly. It is kept by default, with a RadiometricData warning, because removing it
+// destroys the temperature measurements.
+func WithRemoveRadiometric() Option {
+	return func(o *options) {
+		o.removeRadiometric = true
+	}
+}
+
+// WithKeepCopyright keeps legal attribution: EXIF Artist and Copyright, IPTC By-line and
+// CopyrightNotice, and XMP dc:rights and dc:creator. IPTC and XMP are rebuilt as minimal
+// blocks holding only those fields, and where EXIF would be dropped a minimal EXIF with
+// just the attribution tags is written instead.
+func WithKeepCopyright() Option {
+	return func(o *options) {
+		o.keepCopyright = true
+	}
+}
+
+// WithXMPPadding adds n bytes of whitespace padding to rebuilt XMP packets (see
+// WithKeepXMPRating) so Adobe tools can update them in place. The default is no padding.
+// Packets marked read-only (end="r") are rebuilt without padding.
+func WithXMPPadding(n int) Option {
+	return func(o *options) {
+		o.xmpPadding = n
+	}
+}
+
+// WithMinimizeAdobe rewrites APP14 Adobe segments to their minimal 12-byte payload,
+// dropping vendor padding while keeping the version, flags and color transform byte
+func WithMinimizeAdobe() Option {
+	return func(o *options) {
+		o.minimizeAdobe = true
+	}
+}
+
+// WithICCv2Swap replaces an ICC v4 profile that describes sRGB with a compact v2 sRGB
+// profile, because some older Android and Internet Explorer renderers ignore v4 profiles.
+// Other v4 profiles, such as Display P3, are kept and reported with WarningICCv4.
+func WithICCv2Swap() Option {
+	return func(o *options) {
+		o.iccV2Swap = true
+	}
+}
+
+// WithNormalizeDensity rewrites the JFIF density and EXIF resolution to dpi when both
+// are declared and disagree, since mismatches confuse some CMS import tools.
+// Values outside 1..65535 disable normalization.
+func WithNormalizeDensity(dpi int) Option {
+	return func(o *options) {
+		o.densityDPI = dpi
+	}
+}
+
+// WithColorVerify decodes the input and output after stripping and fails with a
+// *ColorShiftError when any R/G/B histogram differs by more than tolerance (0 to 1).
+// This catches color-shifting bugs such as accidental APP14 loss at the cost of two decodes.
+func WithColorVerify(tolerance float64) Option {
+	return func(o *options) {
+		o.colorVerify = true
+		o.colorTolerance = tolerance
+	}
+}
+
+// keptXMPProperties returns the XMP properties that must survive stripping
+func (o *options) keptXMPProperties() []xmpProperty {
+	wanted := make([]xmpProperty, 0)
+	if o.keepXMPRating {
+		wanted = append(wanted, ratingProperties...)
+	}
+	if o.keepCopyright {
+		wanted = append(wanted, copyrightXMPProperties...)
+	}
+	if len(wanted) > 0 {
+		wanted = append(wanted, orientationProperty)
+	}
+	return wanted
+}
+
+// allowedExifTags returns the EXIF tags kept in whitelist mode, including attribution
+// tags when WithKeepCopyright is set
+func (o *options) allowedExifTags() map[uint16]bool {
+	if !o.keepCopyright {
+		return o.whitelistExifTags
+	}
+	allowed := make(map[uint16]bool, len(o.whitelistExifTags)+len(copyrightExifTags))
+	for tag := range o.whitelistExifTags {
+		allowed[tag] = true
+	}
+	for tag := range copyrightExifTags {
+		allowed[tag] = true
+	}
+	return allowed
+}
+
+// expired reports whether the processing deadline has passed
+func (o *options) expired() bool {
+	return !o.deadline.IsZero() && time.Now().After(o.deadline)
+}
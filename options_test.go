@@ -0,0 +1,297 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithStrictUnknown(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	t.Run("Known markers pass", func(t *testing.T) {
+		if _, _, err := Strip(jpegData, WithStrictUnknown()); err != nil {
+			t.Errorf("Expected no error for known markers, got %v", err)
+		}
+	})
+
+	t.Run("Unknown marker fails", func(t *testing.T) {
+		withAPP5 := insertSegment(jpegData, 0xE5, []byte("vendor data"))
+
+		_, _, err := Strip(withAPP5, WithStrictUnknown())
+		var unknownErr *UnknownMarkerError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("Expected UnknownMarkerError, got %v", err)
+		}
+		if len(unknownErr.Markers) != 1 || unknownErr.Markers[0] != 0xE5 {
+			t.Errorf("Expected unknown markers [0xE5], got %v", unknownErr.Markers)
+		}
+	})
+
+	t.Run("Unknown marker kept without strict mode", func(t *testing.T) {
+		withAPP5 := insertSegment(jpegData, 0xE5, []byte("vendor data"))
+
+		cleanedData, _, err := Strip(withAPP5)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		if len(getSegmentPayloads(t, cleanedData, 0xE5)) != 1 {
+			t.Error("Expected unknown segment to be passed through")
+		}
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	t.Run("Generous timeout succeeds", func(t *testing.T) {
+		if _, _, err := Strip(jpegData, WithTimeout(time.Minute)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Expired timeout fails", func(t *testing.T) {
+		_, _, err := Strip(jpegData, WithTimeout(time.Nanosecond))
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+	})
+}
+
+func TestWithThumbnailMaxBytes(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_exif_thumbnail.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	exifData := getSegmentPayloads(t, jpegData, 0xE1)[0]
+	thumbLength := getThumbnailLength(exifData)
+	if thumbLength <= 0 {
+		t.Fatalf("Expected test file to contain a thumbnail, got length %d", thumbLength)
+	}
+
+	testCases := []struct {
+		name        string
+		maxBytes    int64
+		expectKept  bool
+		description string
+	}{
+		{"No threshold", 0, false, "thumbnails are removed by default"},
+		{"Below threshold", thumbLength + 1, true, "small thumbnail survives"},
+		{"Exact threshold", thumbLength, true, "threshold is inclusive"},
+		{"Above threshold", thumbLength - 1, false, "large thumbnail is removed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, result, err := Strip(jpegData, WithThumbnailMaxBytes(tc.maxBytes))
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			kept := result.Removed.ExifThumbnail == 0
+			if kept != tc.expectKept {
+				t.Errorf("Expected kept=%v (%s), removed %d bytes", tc.expectKept, tc.description, result.Removed.ExifThumbnail)
+			}
+		})
+	}
+}
+
+func TestKeepsThumbnailUnreadableLength(t *testing.T) {
+	// An IFD0 offset past the end makes the thumbnail length unreadable
+	exifData := append([]byte(ExifHeader), 'I', 'I', 0x2A, 0x00, 0xFF, 0x00, 0x00, 0x00)
+	if length := getThumbnailLength(exifData); length >= 0 {
+		t.Fatalf("Expected an unreadable thumbnail length, got %d", length)
+	}
+	if keepsThumbnail(exifData, newOptions([]Option{WithThumbnailMaxBytes(1 << 20)})) {
+		t.Error("Expected a thumbnail of unknown length not to be kept")
+	}
+}
+
+func TestWithWhitelistMode(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_thumbnail_and_icc.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	withAPP5 := insertSegment(jpegData, 0xE5, []byte("vendor data"))
+
+	t.Run("Default whitelist", func(t *testing.T) {
+		cleanedData, result, err := Strip(withAPP5, WithWhitelistMode())
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		if len(getSegmentPayloads(t, cleanedData, 0xE5)) != 0 {
+			t.Error("Expected APP5 segment to be removed in whitelist mode")
+		}
+		if len(getSegmentPayloads(t, cleanedData, 0xE2)) == 0 {
+			t.Error("Expected ICC profile to be kept by the default whitelist")
+		}
+		if result.Removed.Other < int64(len("vendor data")) {
+			t.Errorf("Expected removed APP5 to be counted under Other, got %d", result.Removed.Other)
+		}
+		assertPixelsUnchanged(t, jpegData, cleanedData)
+	})
+
+	t.Run("Defaults are copies", func(t *testing.T) {
+		DefaultWhitelistMarkers()[0] = 0xE5
+		DefaultWhitelistExifTags()[0] = 0x010F
+		if DefaultWhitelistMarkers()[0] != markerAPP0 || DefaultWhitelistExifTags()[0] != 0x0100 {
+			t.Error("Expected the default whitelists to be returned as copies")
+		}
+	})
+
+	t.Run("Default mode keeps unknown segments", func(t *testing.T) {
+		cleanedData, result, err := Strip(withAPP5)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		if len(getSegmentPayloads(t, cleanedData, 0xE5)) != 1 {
+			t.Error("Expected APP5 segment to be kept without whitelist mode")
+		}
+		if result.Removed.Other != 0 {
+			t.Errorf("Expected nothing under Other, got %d", result.Removed.Other)
+		}
+	})
+
+	t.Run("Empty whitelist", func(t *testing.T) {
+		cleanedData, _, err := Strip(withAPP5, WithWhitelistMode(), WithWhitelist(nil, nil))
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		for _, marker := range []byte{0xE0, 0xE1, 0xE2, 0xE5} {
+			if len(getSegmentPayloads(t, cleanedData, marker)) != 0 {
+				t.Errorf("Expected marker 0x%02X to be removed with an empty whitelist", marker)
+			}
+		}
+		assertPixelsUnchanged(t, jpegData, cleanedData)
+	})
+}
+
+func TestWithKeepSoftware(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	e := newTestExif(binary.LittleEndian)
+	e.ifd0 = []testTag{
+		e.ascii(0x000B, "ProcessingTool 1.0"),
+		e.short(0x0112, 1),
+		e.ascii(0x0131, "Adobe Photoshop 25.0"),
+	}
+	jpegData = insertSegment(jpegData, 0xE1, e.build())
+
+	cleanedData, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	exifData := getSegmentPayloads(t, cleanedData, 0xE1)[0]
+	for _, tag := range []uint16{0x000B, 0x0131} {
+		if _, ok := findTag(t, exifData, tag); ok {
+			t.Errorf("Expected tag 0x%04X to be removed", tag)
+		}
+	}
+	if _, ok := findTag(t, exifData, 0x0112); !ok {
+		t.Error("Expected Orientation to be preserved")
+	}
+	expected := int64(len("ProcessingTool 1.0") + 1 + len("Adobe Photoshop 25.0") + 1)
+	if result.Removed.Software != expected {
+		t.Errorf("Expected %d bytes under Software, got %d", expected, result.Removed.Software)
+	}
+
+	cleanedData, result, err = Strip(jpegData, WithKeepSoftware())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	exifData = getSegmentPayloads(t, cleanedData, 0xE1)[0]
+	if _, ok := findTag(t, exifData, 0x0131); !ok {
+		t.Error("Expected Software to be kept with WithKeepSoftware")
+	}
+	if result.Removed.Software != 0 {
+		t.Errorf("Expected nothing under Software, got %d", result.Removed.Software)
+	}
+}
+
+func TestWithMinimizeAdobe(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	// Adobe, version 100, flags0, flags1, transform=1 (YCbCr), then vendor padding
+	adobe := []byte{'A', 'd', 'o', 'b', 'e', 0x00, 0x64, 0x80, 0x00, 0x00, 0x00, 0x01}
+	padded := append(append([]byte{}, adobe...), make([]byte, 20)...)
+	jpegData = insertSegment(jpegData, 0xEE, padded)
+
+	cleanedData, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if payloads := getSegmentPayloads(t, cleanedData, 0xEE); len(payloads) != 1 || len(payloads[0]) != len(padded) {
+		t.Error("Expected APP14 to be kept verbatim by default")
+	}
+	if result.Removed.Adobe != 0 {
+		t.Errorf("Expected no Adobe savings by default, got %d", result.Removed.Adobe)
+	}
+
+	cleanedData, result, err = Strip(jpegData, WithMinimizeAdobe())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	payloads := getSegmentPayloads(t, cleanedData, 0xEE)
+	if len(payloads) != 1 || !bytes.Equal(payloads[0], adobe) {
+		t.Errorf("Expected minimal APP14 %v, got %v", adobe, payloads)
+	}
+	if result.Removed.Adobe != 20 {
+		t.Errorf("Expected 20 bytes under Adobe, got %d", result.Removed.Adobe)
+	}
+	assertPixelsUnchanged(t, jpegData, cleanedData)
+}
+
+func TestWithColorVerify(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	cleanedData, result, err := Strip(jpegData, WithColorVerify(0))
+	if err != nil {
+		t.Fatalf("Expected metadata-only strip to pass color verification, got %v", err)
+	}
+	if result.Total == 0 {
+		t.Error("Expected metadata to be removed")
+	}
+	assertPixelsUnchanged(t, jpegData, cleanedData)
+}
+
+func TestWithXMPPadding(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_xmp.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	plain, _, err := Strip(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	padded, _, err := Strip(jpegData, WithKeepXMPRating(), WithXMPPadding(2048))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	plainXMP := getSegmentPayloads(t, plain, 0xE1)
+	paddedXMP := getSegmentPayloads(t, padded, 0xE1)
+	if len(plainXMP) != 1 || len(paddedXMP) != 1 {
+		t.Fatalf("Expected one rebuilt XMP segment each, got %d and %d", len(plainXMP), len(paddedXMP))
+	}
+	if diff := len(paddedXMP[0]) - len(plainXMP[0]); diff != 2048 {
+		t.Errorf("Expected 2048 bytes of padding, got %d", diff)
+	}
+}
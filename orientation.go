@@ -0,0 +1,76 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// exifOrientationTag is the IFD0 Orientation tag
+const exifOrientationTag = 0x0112
+
+// exifOrientation returns the IFD0 Orientation value (1-8) of an EXIF payload
+func exifOrientation(exifData []byte) (uint16, bool) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return 0, false
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+	for _, entry := range entries {
+		if entry.Tag == exifOrientationTag && entry.Type == 3 {
+			value := order.Uint16(entry.Value[0:2])
+			return value, value >= 1 && value <= 8
+		}
+	}
+	return 0, false
+}
+
+// reconcileOrientation makes a kept XMP tiff:Orientation agree with EXIF, which browsers
+// honor: the XMP value is updated to the EXIF one, or removed when EXIF has none.
+// Kept XMP segments are replaced rather than modified.
+func reconcileOrientation(segments []*jpegSegment, wanted []xmpProperty, padding int) ([]*jpegSegment, bool) {
+	var orientation uint16
+	var hasOrientation bool
+	for _, segment := range segments {
+		if segment.MarkerId == markerAPP1 && isExifSegment(segment) {
+			orientation, hasOrientation = exifOrientation(segment.Data)
+			break
+		}
+	}
+
+	reconciled := segments
+	changed := false
+	for i, segment := range segments {
+		if segment.MarkerId != markerAPP1 || !isXMPSegment(segment) {
+			continue
+		}
+		packet := bytes.TrimPrefix(segment.Data, []byte(XMPHeader))
+		properties := extractXMPProperties(packet, wanted)
+
+		updated := make([]xmpProperty, 0, len(properties))
+		conflict := false
+		for _, p := range properties {
+			if p.Namespace == orientationProperty.Namespace && p.Name == orientationProperty.Name {
+				if !hasOrientation {
+					conflict = true
+					continue
+				}
+				if want := strconv.Itoa(int(orientation)); p.Value != want {
+					conflict = true
+					p.Value = want
+				}
+			}
+			updated = append(updated, p)
+		}
+		if !conflict {
+			continue
+		}
+
+		if !changed {
+			reconciled = append([]*jpegSegment{}, segments...)
+			changed = true
+		}
+		data := append([]byte(XMPHeader), buildXMPPacket(updated, padding, xpacketEnd(packet))...)
+		reconciled[i] = replaceSegmentData(segment, data)
+	}
+	return reconciled, changed
+}
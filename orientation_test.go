@@ -0,0 +1,78 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReconcileOrientation(t *testing.T) {
+	basic, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	xmpPacket := func(attributes string) []byte {
+		return []byte(XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+			`<rdf:Description xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:tiff="http://ns.adobe.com/tiff/1.0/" ` +
+			attributes + `/></rdf:RDF></x:xmpmeta>`)
+	}
+	exifWithOrientation := func(orientation uint16) []byte {
+		e := newTestExif(binary.BigEndian)
+		e.ifd0 = []testTag{e.short(0x0112, orientation)}
+		return e.build()
+	}
+
+	tests := []struct {
+		name        string
+		exif        []byte
+		xmp         []byte
+		reconciled  bool
+		contains    string
+		notContains string
+	}{
+		{"XMP updated to EXIF", exifWithOrientation(6), xmpPacket(`xmp:Rating="3" tiff:Orientation="8"`), true, `tiff:Orientation="6"`, `"8"`},
+		{"agreeing values kept", exifWithOrientation(6), xmpPacket(`xmp:Rating="3" tiff:Orientation="6"`), false, `tiff:Orientation="6"`, ""},
+		{"removed without EXIF orientation", nil, xmpPacket(`xmp:Rating="3" tiff:Orientation="8"`), true, `xmp:Rating="3"`, "Orientation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jpegData := insertSegment(basic, 0xE1, tt.xmp)
+			if tt.exif != nil {
+				jpegData = insertSegment(jpegData, 0xE1, tt.exif)
+			}
+			cleaned, result, err := Strip(jpegData, WithKeepXMPRating())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if result.OrientationReconciled != tt.reconciled {
+				t.Errorf("Expected OrientationReconciled=%v", tt.reconciled)
+			}
+
+			var packet string
+			for _, payload := range getSegmentPayloads(t, cleaned, 0xE1) {
+				if bytes.HasPrefix(payload, []byte(XMPHeader)) {
+					packet = string(payload)
+				}
+			}
+			if !strings.Contains(packet, tt.contains) {
+				t.Errorf("Expected packet to contain %s, got %s", tt.contains, packet)
+			}
+			if tt.notContains != "" && strings.Contains(packet, tt.notContains) {
+				t.Errorf("Expected packet not to contain %s, got %s", tt.notContains, packet)
+			}
+		})
+	}
+}
+
+func TestOrientationAloneDoesNotKeepXMP(t *testing.T) {
+	packet := XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:tiff="http://ns.adobe.com/tiff/1.0/" tiff:Orientation="6"/></rdf:RDF></x:xmpmeta>`
+	wanted := append(append([]xmpProperty{}, ratingProperties...), orientationProperty)
+	if filtered := filterXMPSegment([]byte(packet), wanted, 0); filtered != nil {
+		t.Errorf("Expected no packet for orientation alone, got %s", filtered)
+	}
+}
@@ -0,0 +1,265 @@
+// Package pdfimages strips metadata from JPEG images embedded in PDF files.
+//
+// Exported brochures and reports frequently embed camera photos as DCTDecode image
+// streams that still carry GPS coordinates and camera details. Strip rewrites each such
+// stream with jpegmetawebstrip.Strip and fixes up stream lengths and cross-reference
+// offsets so the PDF stays valid.
+package pdfimages
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	jpegmetawebstrip "github.com/ideamans/go-jpeg-meta-web-strip"
+)
+
+// ErrXRefStream is returned for PDFs whose cross-reference data is stored in compressed
+// xref streams (PDF 1.5+), whose offsets cannot be fixed up in place
+var ErrXRefStream = errors.New("PDF cross-reference streams are not supported")
+
+// Result summarizes the images processed in a PDF
+type Result struct {
+	// Images is the number of DCTDecode image streams found
+	Images int
+	// Stripped is the number of images whose metadata was removed
+	Stripped int
+	// Total is the number of bytes removed across all images
+	Total int64
+}
+
+var (
+	objectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	lengthEntry  = regexp.MustCompile(`/Length\s+(\d+)(\s+(\d+)\s+R)?`)
+	dctFilter    = regexp.MustCompile(`/Filter\s*(/DCTDecode|\[\s*/DCTDecode\s*\])`)
+	xrefSection  = regexp.MustCompile(`(?m)(^|[\r\n])xref[ \t]*\r?\n`)
+	xrefSubsect  = regexp.MustCompile(`^(\d+)[ \t]+(\d+)[ \t]*\r?\n`)
+	startXRef    = regexp.MustCompile(`startxref\s+(\d+)`)
+	prevEntry    = regexp.MustCompile(`/Prev\s+(\d+)`)
+	xrefStream   = regexp.MustCompile(`/Type\s*/XRef\b`)
+	streamStart  = regexp.MustCompile(`\bstream\r?\n`)
+)
+
+// edit replaces data[pos:pos+length] with replacement
+type edit struct {
+	pos         int
+	length      int
+	replacement []byte
+}
+
+// Strip removes metadata from every JPEG image stream in a PDF and returns the rewritten PDF.
+// Images that fail to strip or would not shrink are left untouched. Numbers that change
+// (stream lengths and offsets) are padded to their original width, so only image data
+// changes size. Linearization hints, if any, are not updated.
+func Strip(pdf []byte, opts ...jpegmetawebstrip.Option) ([]byte, *Result, error) {
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		return nil, nil, fmt.Errorf("invalid PDF header")
+	}
+	if xrefStream.Match(pdf) {
+		return nil, nil, ErrXRefStream
+	}
+
+	result := &Result{}
+	edits := make([]edit, 0)
+	skipUntil := 0
+	for _, loc := range objectHeader.FindAllIndex(pdf, -1) {
+		// Ignore header-like bytes inside image data already handled
+		if loc[0] < skipUntil {
+			continue
+		}
+		objEdits, dataEnd, found, removed := stripImageObject(pdf, loc[1], opts)
+		if found {
+			result.Images++
+			skipUntil = dataEnd
+		}
+		if removed > 0 {
+			result.Stripped++
+			result.Total += removed
+			edits = append(edits, objEdits...)
+		}
+	}
+	if len(edits) == 0 {
+		return pdf, result, nil
+	}
+
+	offsetEdits, err := fixOffsets(pdf, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyEdits(pdf, append(edits, offsetEdits...)), result, nil
+}
+
+// stripImageObject strips the object body starting at pos if it is a DCTDecode stream.
+// It returns the edits to apply, the end of the stream data, whether an image was found
+// and the bytes removed.
+func stripImageObject(pdf []byte, pos int, opts []jpegmetawebstrip.Option) ([]edit, int, bool, int64) {
+	end := bytes.Index(pdf[pos:], []byte("endobj"))
+	if end < 0 {
+		return nil, 0, false, 0
+	}
+	streamKeyword := bytes.Index(pdf[pos:pos+end], []byte("stream"))
+	if streamKeyword < 0 {
+		return nil, 0, false, 0
+	}
+	dict := pdf[pos : pos+streamKeyword]
+	if !dctFilter.Match(dict) {
+		return nil, 0, false, 0
+	}
+
+	length := lengthEntry.FindSubmatchIndex(dict)
+	if length == nil {
+		return nil, pos, true, 0
+	}
+	dataStart := pos + streamKeyword + len("stream")
+	if bytes.HasPrefix(pdf[dataStart:], []byte("\r\n")) {
+		dataStart += 2
+	} else if bytes.HasPrefix(pdf[dataStart:], []byte("\n")) {
+		dataStart++
+	}
+
+	// The length is either direct or a reference to an integer object
+	lengthPos, lengthLen := pos+length[2], length[3]-length[2]
+	if length[4] >= 0 {
+		var ok bool
+		lengthPos, lengthLen, ok = findIndirectInteger(pdf, dict[length[2]:length[3]], dict[length[6]:length[7]])
+		if !ok {
+			return nil, pos, true, 0
+		}
+	}
+	dataLen, err := strconv.Atoi(string(pdf[lengthPos : lengthPos+lengthLen]))
+	if err != nil || dataStart+dataLen > len(pdf) {
+		return nil, pos, true, 0
+	}
+	dataEnd := dataStart + dataLen
+
+	stripped, _, err := jpegmetawebstrip.Strip(pdf[dataStart:dataEnd], opts...)
+	if err != nil || len(stripped) >= dataLen {
+		// Not a JPEG we can clean (or nothing to gain); leave the image untouched
+		return nil, dataEnd, true, 0
+	}
+
+	return []edit{
+		{pos: lengthPos, length: lengthLen, replacement: padNumber(len(stripped), lengthLen)},
+		{pos: dataStart, length: dataLen, replacement: stripped},
+	}, dataEnd, true, int64(dataLen - len(stripped))
+}
+
+// findIndirectInteger locates the value of an "N G obj <integer> endobj" object
+func findIndirectInteger(pdf, number, generation []byte) (int, int, bool) {
+	header := regexp.MustCompile(`(^|[^\d])` + string(number) + `\s+` + string(generation) + `\s+obj\s+(\d+)\s+endobj`)
+	// The last definition wins in incrementally updated files
+	matches := header.FindAllSubmatchIndex(pdf, -1)
+	if len(matches) == 0 {
+		return 0, 0, false
+	}
+	m := matches[len(matches)-1]
+	return m[4], m[5] - m[4], true
+}
+
+// fixOffsets returns edits that update xref entries, startxref and /Prev for the content edits.
+// Matches inside stream data, such as a content stream that happens to hold these keywords,
+// are not offsets and are left alone.
+func fixOffsets(pdf []byte, edits []edit) ([]edit, error) {
+	newOffset := func(old int) int {
+		shifted := old
+		for _, e := range edits {
+			if e.pos+e.length <= old {
+				shifted += len(e.replacement) - e.length
+			}
+		}
+		return shifted
+	}
+
+	streams := streamRanges(pdf)
+	fixes := make([]edit, 0)
+	for _, loc := range xrefSection.FindAllIndex(pdf, -1) {
+		if inStream(streams, loc[1]-1) {
+			continue
+		}
+		pos := loc[1]
+		for {
+			sub := xrefSubsect.FindSubmatchIndex(pdf[pos:])
+			if sub == nil {
+				break
+			}
+			count, _ := strconv.Atoi(string(pdf[pos+sub[4] : pos+sub[5]]))
+			pos += sub[1]
+			for i := 0; i < count; i++ {
+				if pos+20 > len(pdf) {
+					return nil, fmt.Errorf("truncated xref table")
+				}
+				entry := pdf[pos : pos+20]
+				if entry[17] == 'n' {
+					old, err := strconv.Atoi(string(entry[0:10]))
+					if err != nil {
+						return nil, fmt.Errorf("invalid xref entry at %d", pos)
+					}
+					fixes = append(fixes, edit{pos: pos, length: 10, replacement: []byte(fmt.Sprintf("%010d", newOffset(old)))})
+				}
+				pos += 20
+			}
+		}
+	}
+
+	for _, re := range []*regexp.Regexp{startXRef, prevEntry} {
+		for _, m := range re.FindAllSubmatchIndex(pdf, -1) {
+			if inStream(streams, m[0]) {
+				continue
+			}
+			old, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+			fixes = append(fixes, edit{pos: m[2], length: m[3] - m[2], replacement: padNumber(newOffset(old), m[3]-m[2])})
+		}
+	}
+	return fixes, nil
+}
+
+// streamRanges returns the [start, end) ranges of stream data, from the line after each
+// stream keyword up to the following endstream, in file order
+func streamRanges(pdf []byte) [][2]int {
+	ranges := make([][2]int, 0)
+	pos := 0
+	for {
+		m := streamStart.FindIndex(pdf[pos:])
+		if m == nil {
+			return ranges
+		}
+		start := pos + m[1]
+		end := bytes.Index(pdf[start:], []byte("endstream"))
+		if end < 0 {
+			return append(ranges, [2]int{start, len(pdf)})
+		}
+		ranges = append(ranges, [2]int{start, start + end})
+		pos = start + end + len("endstream")
+	}
+}
+
+// inStream reports whether pos lies inside one of the stream data ranges
+func inStream(ranges [][2]int, pos int) bool {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i][1] > pos })
+	return i < len(ranges) && ranges[i][0] <= pos
+}
+
+// padNumber formats n padded with trailing spaces to width, which PDF treats as whitespace
+func padNumber(n, width int) []byte {
+	s := []byte(strconv.Itoa(n))
+	for len(s) < width {
+		s = append(s, ' ')
+	}
+	return s
+}
+
+// applyEdits returns a copy of data with the non-overlapping edits applied
+func applyEdits(data []byte, edits []edit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].pos < edits[j].pos })
+	out := make([]byte, 0, len(data))
+	pos := 0
+	for _, e := range edits {
+		out = append(out, data[pos:e.pos]...)
+		out = append(out, e.replacement...)
+		pos = e.pos + e.length
+	}
+	return append(out, data[pos:]...)
+}
@@ -0,0 +1,211 @@
+package pdfimages
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	jpegmetawebstrip "github.com/ideamans/go-jpeg-meta-web-strip"
+)
+
+// buildPDF assembles objects (numbered from 1) into a PDF with a classic xref table.
+// When prev is non-nil the objects are appended to it as an incremental update.
+func buildPDF(prev []byte, first int, objects ...[]byte) []byte {
+	b := new(bytes.Buffer)
+	prevXRef := -1
+	if prev == nil {
+		b.WriteString("%PDF-1.4\n")
+	} else {
+		b.Write(prev)
+		m := regexp.MustCompile(`startxref\s+(\d+)`).FindAllSubmatch(prev, -1)
+		prevXRef, _ = strconv.Atoi(string(m[len(m)-1][1]))
+	}
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(b, "%d 0 obj\n", first+i)
+		b.Write(object)
+		b.WriteString("\nendobj\n")
+	}
+
+	xref := b.Len()
+	if prev == nil {
+		fmt.Fprintf(b, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	} else {
+		fmt.Fprintf(b, "xref\n%d %d\n", first, len(objects))
+	}
+	for _, offset := range offsets {
+		fmt.Fprintf(b, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(b, "trailer\n<< /Size %d /Root 1 0 R", first+len(objects))
+	if prevXRef >= 0 {
+		fmt.Fprintf(b, " /Prev %d", prevXRef)
+	}
+	fmt.Fprintf(b, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return b.Bytes()
+}
+
+// imageObject returns an image XObject dictionary and stream for a JPEG
+func imageObject(jpegData []byte, length string) []byte {
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "<< /Type /XObject /Subtype /Image /Width 8 /Height 8 /BitsPerComponent 8 /ColorSpace /DeviceRGB /Filter /DCTDecode /Length %s >>\nstream\n", length)
+	b.Write(jpegData)
+	b.WriteString("\nendstream")
+	return b.Bytes()
+}
+
+// verifyXRef checks that every xref entry and startxref point at the right places
+func verifyXRef(t *testing.T, pdf []byte) {
+	t.Helper()
+	for _, m := range regexp.MustCompile(`startxref\s+(\d+)`).FindAllSubmatch(pdf, -1) {
+		offset, _ := strconv.Atoi(string(m[1]))
+		if !bytes.HasPrefix(pdf[offset:], []byte("xref")) {
+			t.Errorf("startxref %d does not point at an xref table", offset)
+		}
+	}
+	for _, m := range regexp.MustCompile(`/Prev\s+(\d+)`).FindAllSubmatch(pdf, -1) {
+		offset, _ := strconv.Atoi(string(m[1]))
+		if !bytes.HasPrefix(pdf[offset:], []byte("xref")) {
+			t.Errorf("/Prev %d does not point at an xref table", offset)
+		}
+	}
+
+	sections := regexp.MustCompile(`xref\n(\d+) (\d+)\n((?:\d{10} \d{5} [nf]\r\n)+)`).FindAllSubmatch(pdf, -1)
+	if len(sections) == 0 {
+		t.Fatal("No xref table found")
+	}
+	for _, section := range sections {
+		first, _ := strconv.Atoi(string(section[1]))
+		for i, entry := range bytes.SplitAfter(section[3], []byte("\r\n")) {
+			if len(entry) < 20 || entry[17] != 'n' {
+				continue
+			}
+			offset, _ := strconv.Atoi(string(entry[:10]))
+			expected := fmt.Sprintf("%d 0 obj", first+i)
+			if !bytes.HasPrefix(pdf[offset:], []byte(expected)) {
+				t.Errorf("xref entry for object %d points at %q", first+i, pdf[offset:offset+len(expected)])
+			}
+		}
+	}
+}
+
+// streamData returns the data of each DCTDecode stream
+func streamData(t *testing.T, pdf []byte) [][]byte {
+	t.Helper()
+	streams := make([][]byte, 0)
+	for _, m := range regexp.MustCompile(`/DCTDecode /Length (\d+)(?: 0 R)? *>>\nstream\n`).FindAllSubmatchIndex(pdf, -1) {
+		length, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+		if bytes.HasSuffix(pdf[m[2]:m[3]+4], []byte(" 0 R")) {
+			ref := regexp.MustCompile(string(pdf[m[2]:m[3]]) + ` 0 obj\n(\d+) *\nendobj`).FindSubmatch(pdf)
+			length, _ = strconv.Atoi(string(ref[1]))
+		}
+		streams = append(streams, pdf[m[1]:m[1]+length])
+	}
+	return streams
+}
+
+func TestStrip(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("..", "testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	basic, err := os.ReadFile(filepath.Join("..", "testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := jpegmetawebstrip.Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	pdf := buildPDF(nil, 1,
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte("<< /Type /Pages /Kids [] /Count 0 >>"),
+		imageObject(jpegData, strconv.Itoa(len(jpegData))),
+		imageObject(jpegData, "5 0 R"),
+		[]byte(strconv.Itoa(len(jpegData))),
+	)
+	pdf = buildPDF(pdf, 6, imageObject(basic, strconv.Itoa(len(basic))))
+
+	cleaned, result, err := Strip(pdf)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Images != 3 || result.Stripped != 2 {
+		t.Errorf("Expected 3 images with 2 stripped, got %+v", result)
+	}
+	if expectedTotal := int64(2 * (len(jpegData) - len(expected))); result.Total != expectedTotal {
+		t.Errorf("Expected %d bytes removed, got %d", expectedTotal, result.Total)
+	}
+	if int64(len(pdf)-len(cleaned)) != result.Total {
+		t.Errorf("Expected PDF to shrink by %d bytes, shrank by %d", result.Total, len(pdf)-len(cleaned))
+	}
+
+	streams := streamData(t, cleaned)
+	if len(streams) != 3 {
+		t.Fatalf("Expected 3 image streams, got %d", len(streams))
+	}
+	for i, want := range [][]byte{expected, expected, basic} {
+		if !bytes.Equal(streams[i], want) {
+			t.Errorf("Stream %d does not match the expected JPEG", i)
+		}
+	}
+	verifyXRef(t, cleaned)
+}
+
+func TestStripKeywordsInStreams(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("..", "testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	// A content stream whose text looks like an xref table, startxref and /Prev
+	content := "% startxref\n99999999\n/Prev 99999999\nxref\n0 1\n0099999999 00000 n\r\n"
+	pdf := buildPDF(nil, 1,
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)),
+		imageObject(jpegData, strconv.Itoa(len(jpegData))),
+	)
+
+	cleaned, result, err := Strip(pdf)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Stripped != 1 {
+		t.Fatalf("Expected the image to be stripped, got %+v", result)
+	}
+	if !bytes.Contains(cleaned, []byte("stream\n"+content+"\nendstream")) {
+		t.Error("Expected the content stream to be left byte for byte")
+	}
+	m := regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF`).FindSubmatch(cleaned)
+	if offset, _ := strconv.Atoi(string(m[1])); !bytes.HasPrefix(cleaned[offset:], []byte("xref")) {
+		t.Errorf("startxref %d does not point at the xref table", offset)
+	}
+	if offset := bytes.Index(cleaned, []byte("3 0 obj")); !bytes.Contains(cleaned, []byte(fmt.Sprintf("%010d 00000 n", offset))) {
+		t.Errorf("Expected the xref entry of the image to point at offset %d", offset)
+	}
+}
+
+func TestStripErrors(t *testing.T) {
+	if _, _, err := Strip([]byte("not a pdf")); err == nil {
+		t.Error("Expected error for non-PDF input")
+	}
+
+	pdf := []byte("%PDF-1.5\n1 0 obj\n<< /Type /XRef /Size 1 >>\nstream\n\nendstream\nendobj\n")
+	if _, _, err := Strip(pdf); !errors.Is(err, ErrXRefStream) {
+		t.Errorf("Expected ErrXRefStream, got %v", err)
+	}
+
+	// A PDF without images is returned unchanged
+	plain := buildPDF(nil, 1, []byte("<< /Type /Catalog >>"))
+	out, result, err := Strip(plain)
+	if err != nil || !bytes.Equal(out, plain) || result.Images != 0 {
+		t.Errorf("Expected unchanged output for a PDF without images, got %+v, %v", result, err)
+	}
+}
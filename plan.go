@@ -0,0 +1,224 @@
+package jpegmetawebstrip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// RewriteOp covers one range of the input. Kept ranges are copied verbatim; other ranges are
+// replaced by Data, which is empty when the range is simply dropped. A replacement with
+// Length 0 inserts Data at Offset.
+type RewriteOp struct {
+	Offset int64
+	Length int64
+	Keep   bool
+	Data   []byte
+}
+
+// RewritePlan describes how Strip rewrites an input as ops that cover it from start to end,
+// so integrators can assemble the output themselves, e.g. from ranged reads at a CDN edge
+type RewritePlan struct {
+	Ops []RewriteOp
+	// InputSize and OutputSize are the sizes of the input and of the planned output
+	InputSize  int64
+	OutputSize int64
+	Result     *Result
+}
+
+// Plan computes the rewrite Strip would perform on data without producing the output.
+// Like StripStream, only the segments before the first scan are cleaned, and the output is
+// not validated; the scan data is always kept verbatim up to EOI, and any trailer is dropped.
+func Plan(data []byte, opts ...Option) (*RewritePlan, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		o.deadline = time.Now().Add(o.timeout)
+	}
+	if format, ok := detectRaw(data); ok {
+		return nil, &RawImageError{Format: format}
+	}
+
+	segments, sos, headerSize, err := readHeaderSegments(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{OriginalSize: int64(len(data))}
+	cleaned, err := cleanSegments(segments, result, o)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RewritePlan{InputSize: int64(len(data)), Result: result}
+	inputs := make(map[int]*jpegSegment, len(segments))
+	for _, segment := range segments {
+		inputs[segment.Offset] = segment
+	}
+
+	// SOI is always kept; every other input segment is kept, replaced or dropped in turn
+	plan.add(RewriteOp{Offset: 0, Length: 2, Keep: true})
+	pos := int64(2)
+	for _, segment := range cleaned {
+		if segment.MarkerId == markerSOI {
+			continue
+		}
+		original, found := inputs[segment.Offset]
+		if !found || int64(segment.Offset) < pos {
+			// A segment without an input counterpart is inserted where we are
+			plan.add(RewriteOp{Offset: pos, Data: encodeSegment(segment)})
+			continue
+		}
+
+		start, length := int64(original.Offset), int64(4+len(original.Data))
+		if start > pos {
+			plan.add(RewriteOp{Offset: pos, Length: start - pos})
+		}
+		if segment == original {
+			plan.add(RewriteOp{Offset: start, Length: length, Keep: true})
+		} else {
+			plan.add(RewriteOp{Offset: start, Length: length, Data: encodeSegment(segment)})
+		}
+		pos = start + length
+	}
+
+	sosStart := headerSize - int64(len(sos))
+	if sosStart > pos {
+		plan.add(RewriteOp{Offset: pos, Length: sosStart - pos})
+	}
+	end := findEOI(data, headerSize)
+	plan.add(RewriteOp{Offset: sosStart, Length: end - sosStart, Keep: true})
+	if end < int64(len(data)) {
+		plan.add(RewriteOp{Offset: end, Length: int64(len(data)) - end})
+	}
+	return plan, nil
+}
+
+// add appends op, merging it into the previous op when both are contiguous and of the same kind
+func (p *RewritePlan) add(op RewriteOp) {
+	p.OutputSize += int64(len(op.Data))
+	if op.Keep {
+		p.OutputSize += op.Length
+	}
+	if n := len(p.Ops); n > 0 {
+		last := &p.Ops[n-1]
+		if last.Keep == op.Keep && last.Offset+last.Length == op.Offset {
+			last.Length += op.Length
+			last.Data = append(last.Data, op.Data...)
+			return
+		}
+	}
+	p.Ops = append(p.Ops, op)
+}
+
+// Apply writes the planned output to w, reading kept ranges from input
+func (p *RewritePlan) Apply(w io.Writer, input io.ReaderAt) (int64, error) {
+	var written int64
+	for _, op := range p.Ops {
+		var n int64
+		var err error
+		if op.Keep {
+			n, err = io.Copy(w, io.NewSectionReader(input, op.Offset, op.Length))
+			if err == nil && n < op.Length {
+				err = io.ErrUnexpectedEOF
+			}
+		} else {
+			var m int
+			m, err = w.Write(op.Data)
+			n = int64(m)
+		}
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("failed to apply rewrite plan at %d: %w", op.Offset, err)
+		}
+	}
+	return written, nil
+}
+
+// OutputReader returns the planned output as a reader of OutputSize bytes whose ranges are
+// resolved against input on demand. A proxy can strip once, cache the plan and answer HTTP
+// Range requests from it, e.g. with http.ServeContent, without materializing the output;
+// each read touches only the input ranges it covers. The plan must not be changed while
+// the reader is in use.
+func (p *RewritePlan) OutputReader(input io.ReaderAt) *io.SectionReader {
+	r := &planReader{plan: p, input: input, starts: make([]int64, len(p.Ops))}
+	var pos int64
+	for i, op := range p.Ops {
+		r.starts[i] = pos
+		pos += op.outputLength()
+	}
+	return io.NewSectionReader(r, 0, pos)
+}
+
+// outputLength returns the number of output bytes the op produces
+func (op RewriteOp) outputLength() int64 {
+	if op.Keep {
+		return op.Length
+	}
+	return int64(len(op.Data))
+}
+
+// planReader implements io.ReaderAt over the output of a RewritePlan
+type planReader struct {
+	plan  *RewritePlan
+	input io.ReaderAt
+	// starts holds the output offset of each op
+	starts []int64
+}
+
+// ReadAt fills b from the planned output at off, reading kept ranges from the input
+func (r *planReader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("failed to read planned output: negative offset %d", off)
+	}
+	// The op holding off is the last one starting at or before it
+	i := sort.Search(len(r.starts), func(i int) bool { return r.starts[i] > off }) - 1
+	n := 0
+	for ; n < len(b) && i >= 0 && i < len(r.plan.Ops); i++ {
+		op := r.plan.Ops[i]
+		skip := off + int64(n) - r.starts[i]
+		if skip >= op.outputLength() {
+			continue
+		}
+		want := b[n:]
+		if rest := op.outputLength() - skip; int64(len(want)) > rest {
+			want = want[:rest]
+		}
+		if !op.Keep {
+			n += copy(want, op.Data[skip:])
+			continue
+		}
+		m, err := r.input.ReadAt(want, op.Offset+skip)
+		n += m
+		if m < len(want) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, fmt.Errorf("failed to read planned output at %d: %w", off+int64(n), err)
+		}
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// encodeSegment returns the marker, length and payload of a segment as written to a file
+func encodeSegment(segment *jpegSegment) []byte {
+	b := make([]byte, 4, 4+len(segment.Data))
+	b[0], b[1] = 0xFF, segment.MarkerId
+	binary.BigEndian.PutUint16(b[2:], uint16(2+len(segment.Data)))
+	return append(b, segment.Data...)
+}
+
+// findEOI returns the position just past the EOI marker ending the scan data that starts
+// at from, or the length of data when there is none. See scanWalker for how it is found.
+func findEOI(data []byte, from int64) int64 {
+	var walker scanWalker
+	if n, done := walker.walk(data[from:]); done {
+		return from + int64(n)
+	}
+	return int64(len(data))
+}
@@ -0,0 +1,193 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPlanMatchesStrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	optionSets := map[string][]Option{
+		"default":    nil,
+		"rewritten":  {WithKeepXMPRating(), WithNormalizeDensity(72), WithICCv2Swap(), WithCanonicalExif()},
+		"thumbnails": {WithThumbnailMaxBytes(1 << 20)},
+	}
+
+	for _, file := range files {
+		for name, opts := range optionSets {
+			t.Run(filepath.Base(file)+"/"+name, func(t *testing.T) {
+				jpegData, err := os.ReadFile(file)
+				if err != nil {
+					t.Fatalf("Failed to read test file: %v", err)
+				}
+				expected, expectedResult, err := Strip(jpegData, opts...)
+				if err != nil {
+					t.Fatalf("Strip failed: %v", err)
+				}
+
+				plan, err := Plan(jpegData, opts...)
+				if err != nil {
+					t.Fatalf("Plan failed: %v", err)
+				}
+				assertPlanCoversInput(t, plan)
+
+				out := new(bytes.Buffer)
+				n, err := plan.Apply(out, bytes.NewReader(jpegData))
+				if err != nil {
+					t.Fatalf("Apply failed: %v", err)
+				}
+				if !bytes.Equal(out.Bytes(), expected) {
+					t.Errorf("planned output differs from Strip: %d vs %d bytes", out.Len(), len(expected))
+				}
+				if n != plan.OutputSize {
+					t.Errorf("Apply wrote %d bytes, plan promised %d", n, plan.OutputSize)
+				}
+				if plan.Result.Total != expectedResult.Total {
+					t.Errorf("Expected total %d, got %d", expectedResult.Total, plan.Result.Total)
+				}
+			})
+		}
+	}
+}
+
+// assertPlanCoversInput checks that ops cover the input in order without gaps or overlaps
+func assertPlanCoversInput(t *testing.T, plan *RewritePlan) {
+	t.Helper()
+	pos := int64(0)
+	for i, op := range plan.Ops {
+		if op.Offset != pos {
+			t.Fatalf("op %d starts at %d, expected %d", i, op.Offset, pos)
+		}
+		if op.Keep && len(op.Data) > 0 {
+			t.Errorf("op %d is kept but carries replacement data", i)
+		}
+		pos += op.Length
+	}
+	if pos != plan.InputSize {
+		t.Errorf("ops cover %d bytes of %d", pos, plan.InputSize)
+	}
+}
+
+func TestPlanDropsTrailer(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	withTrailer := append(append([]byte{}, jpegData...), []byte("PK\x03\x04 appended archive")...)
+
+	plan, err := Plan(withTrailer)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	assertPlanCoversInput(t, plan)
+	last := plan.Ops[len(plan.Ops)-1]
+	if last.Keep || last.Offset != int64(len(jpegData)) {
+		t.Errorf("Expected the trailer to be dropped as the last op, got %+v", last)
+	}
+
+	out := new(bytes.Buffer)
+	if _, err := plan.Apply(out, bytes.NewReader(withTrailer)); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Error("planned output differs from Strip")
+	}
+
+	// Applying to a shorter object than planned fails instead of writing a truncated JPEG
+	if _, err := plan.Apply(new(bytes.Buffer), bytes.NewReader(jpegData[:len(jpegData)/2])); err == nil {
+		t.Error("Expected Apply to fail on a truncated input")
+	}
+}
+
+func TestPlanOutputReader(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_mixed_metadata.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	plan, err := Plan(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	r := plan.OutputReader(bytes.NewReader(jpegData))
+	if r.Size() != plan.OutputSize {
+		t.Errorf("Reader size %d, plan promised %d", r.Size(), plan.OutputSize)
+	}
+	all, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(all, expected) {
+		t.Fatalf("reader output differs from Strip: %d vs %d bytes", len(all), len(expected))
+	}
+
+	// Ranges that start or end inside replaced and kept ops read the same bytes
+	size := int64(len(expected))
+	for _, rng := range [][2]int64{{0, 1}, {0, 100}, {3, 4000}, {size / 3, size / 2}, {size - 10, size}, {1, size}} {
+		b := make([]byte, rng[1]-rng[0])
+		if _, err := r.ReadAt(b, rng[0]); err != nil {
+			t.Fatalf("ReadAt(%d..%d) failed: %v", rng[0], rng[1], err)
+		}
+		if !bytes.Equal(b, expected[rng[0]:rng[1]]) {
+			t.Errorf("ReadAt(%d..%d) differs from the stripped output", rng[0], rng[1])
+		}
+	}
+	if n, err := r.ReadAt(make([]byte, 10), size-4); n != 4 || err != io.EOF {
+		t.Errorf("Expected 4 bytes and EOF at the end, got %d, %v", n, err)
+	}
+}
+
+func TestPlanOutputReaderHTTP(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_mixed_metadata.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	plan, err := Plan(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	// A cached plan answers HTTP Range requests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.ServeContent(w, req, "image.jpg", time.Time{}, plan.OutputReader(bytes.NewReader(jpegData)))
+	}))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Range", "bytes=100-199")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Range request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || !bytes.Equal(body, expected[100:200]) {
+		t.Errorf("Expected 206 with bytes 100-199, got %d with %d bytes", resp.StatusCode, len(body))
+	}
+
+	// A shorter object than planned is reported instead of read as zeros
+	truncated := plan.OutputReader(bytes.NewReader(jpegData[:len(jpegData)/2]))
+	if _, err := io.ReadAll(truncated); err == nil {
+		t.Error("Expected reading past the input to fail")
+	}
+}
@@ -0,0 +1,165 @@
+package jpegmetawebstrip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Policy is a named, reusable set of options, such as "web", "privacy" or "legal"
+type Policy struct {
+	Name    string
+	Options []Option
+}
+
+// NewPolicy creates a policy that applies opts when used
+func NewPolicy(name string, opts ...Option) *Policy {
+	return &Policy{Name: name, Options: opts}
+}
+
+// options resolves the policy into settings; a nil policy uses the defaults
+func (p *Policy) options() *options {
+	if p == nil {
+		return newOptions(nil)
+	}
+	return newOptions(p.Options)
+}
+
+// name returns the policy name for messages, tolerating a nil policy
+func (p *Policy) name() string {
+	if p == nil {
+		return "default"
+	}
+	return p.Name
+}
+
+// policyChecks are the rules Validate applies to a resolved policy, grouped by mode. Each
+// returns a description of the problem, or "" when the settings pass.
+var policyChecks = []func(o *options) string{
+	// Limits
+	func(o *options) string { return problemIf(o.timeout < 0, "timeout must not be negative") },
+	func(o *options) string {
+		return problemIf(o.thumbnailMaxBytes < 0, "thumbnail max bytes must not be negative")
+	},
+	func(o *options) string {
+		return problemIf(o.maxIFDDepth < 1 || o.maxIFDEntries < 1, "IFD limits must allow at least one level and one entry")
+	},
+	func(o *options) string {
+		return problemIf(o.densityDPI < 0 || o.densityDPI > math.MaxUint16, "density %d DPI is outside 1..65535", o.densityDPI)
+	},
+	func(o *options) string {
+		return problemIf(o.colorVerify && (o.colorTolerance < 0 || o.colorTolerance > 1), "color verify tolerance %v is outside 0..1", o.colorTolerance)
+	},
+
+	// Modes
+	func(o *options) string {
+		return problemIf(o.privacyOnly && (o.whitelist || o.thumbnailOnly), "the privacy preset keeps all non-identifying metadata, which contradicts whitelist and thumbnail-only modes")
+	},
+	func(o *options) string {
+		return problemIf(o.thumbnailOnly && o.whitelist, "WithThumbnailOnly keeps all metadata, which contradicts whitelist mode")
+	},
+
+	// Whitelist
+	func(o *options) string {
+		return problemIf(o.whitelist && !hasWhitelist(o), "whitelist mode with an empty whitelist removes all metadata including ICC profiles; use WithWhitelist to list what to keep")
+	},
+	func(o *options) string {
+		return problemIf(hasWhitelist(o) && !o.whitelistMarkers[markerAPP2], "whitelist drops APP2, which removes ICC profiles and shifts colors of wide-gamut images")
+	},
+	func(o *options) string {
+		return problemIf(hasWhitelist(o) && !o.whitelistMarkers[markerAPP14], "whitelist drops APP14, which carries the color transform of CMYK/YCCK images")
+	},
+	func(o *options) string {
+		return problemIf(hasWhitelist(o) && o.minimizeAdobe && !o.whitelistMarkers[markerAPP14], "WithMinimizeAdobe has no effect because the whitelist drops APP14")
+	},
+	func(o *options) string {
+		return problemIf(hasWhitelist(o) && o.keepXMPRating && !o.whitelistMarkers[markerAPP1], "WithKeepXMPRating has no effect because the whitelist drops APP1")
+	},
+	func(o *options) string {
+		return problemIf(hasWhitelist(o) && o.keepSoftware && !o.whitelistExifTags[0x0131], "WithKeepSoftware has no effect because the whitelist drops the Software tag")
+	},
+}
+
+// problemIf formats a Validate problem when failed is true, and returns "" otherwise
+func problemIf(failed bool, format string, args ...any) string {
+	if !failed {
+		return ""
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// hasWhitelist reports whether whitelist mode is on with at least one marker or tag listed
+func hasWhitelist(o *options) bool {
+	return o.whitelist && (len(o.whitelistMarkers) > 0 || len(o.whitelistExifTags) > 0)
+}
+
+// Validate reports contradictory or dangerous settings in the policy, such as a whitelist
+// that drops the APP14 color transform or an option that the whitelist makes impossible.
+// All problems are joined into a single error; nil means the policy is consistent.
+func (p *Policy) Validate() error {
+	o := p.options()
+	problems := make([]error, 0)
+	problem := func(message string) {
+		problems = append(problems, fmt.Errorf("policy %q: %s", p.name(), message))
+	}
+
+	for _, check := range policyChecks {
+		if message := check(o); message != "" {
+			problem(message)
+		}
+	}
+	for _, segment := range o.preserved {
+		if !isAPPMarker(segment.Marker) {
+			problem(fmt.Sprintf("preserved segment %q has marker 0x%02X, which is not APP0..APP15", segment.Name, segment.Marker))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// SimulatePolicies parses the JPEG once and reports what each policy would remove,
+// without producing output. Nothing is written, validated or color-verified, so Unchanged
+// and FellBack are never set. Results are returned in the order of policies.
+func SimulatePolicies(data []byte, policies ...*Policy) ([]*Result, error) {
+	sl, err := parseSegmentList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, 0, len(policies))
+	for _, policy := range policies {
+		result := &Result{OriginalSize: int64(len(data))}
+		if _, err := cleanSegments(sl.Segments(), result, policy.options()); err != nil {
+			return nil, fmt.Errorf("failed to simulate policy %q: %w", policy.name(), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Variant is the output produced for one policy by StripVariants
+type Variant struct {
+	Policy *Policy
+	Data   []byte
+	Result *Result
+}
+
+// StripVariants parses the JPEG once and strips it with each policy, returning one
+// variant per policy in order. WithTimeout is not applied; resource stats, when requested,
+// cover only processing and writing because the parse is shared.
+func StripVariants(data []byte, policies ...*Policy) ([]*Variant, error) {
+	sl, err := parseSegmentList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]*Variant, 0, len(policies))
+	for _, policy := range policies {
+		o := policy.options()
+		output, result, err := stripSegments(data, sl, o, newResourceTracker(o.resourceStats))
+		if err != nil {
+			return nil, fmt.Errorf("failed to strip variant %q: %w", policy.name(), err)
+		}
+		variants = append(variants, &Variant{Policy: policy, Data: output, Result: result})
+	}
+	return variants, nil
+}
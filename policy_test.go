@@ -0,0 +1,138 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimulatePolicies(t *testing.T) {
+	jpegData, err := os.ReadFile("testdata/with_comprehensive_mixed.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	policies := []*Policy{
+		NewPolicy("web"),
+		NewPolicy("keep-software", WithKeepSoftware()),
+		NewPolicy("privacy", WithWhitelistMode()),
+		nil,
+	}
+
+	results, err := SimulatePolicies(jpegData, policies...)
+	if err != nil {
+		t.Fatalf("SimulatePolicies failed: %v", err)
+	}
+	if len(results) != len(policies) {
+		t.Fatalf("Expected %d results, got %d", len(policies), len(results))
+	}
+
+	// Each simulated result must match what Strip reports for the same options
+	for i, policy := range policies {
+		var opts []Option
+		if policy != nil {
+			opts = policy.Options
+		}
+		_, expected, err := Strip(jpegData, opts...)
+		if err != nil {
+			t.Fatalf("Strip failed for policy %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(results[i], expected) {
+			t.Errorf("Policy %d: simulated result %+v differs from Strip result %+v", i, results[i], expected)
+		}
+	}
+
+	if results[2].Total < results[0].Total {
+		t.Errorf("Expected whitelist policy to remove at least as much as the default (%d < %d)", results[2].Total, results[0].Total)
+	}
+}
+
+func TestSimulatePoliciesInvalidJPEG(t *testing.T) {
+	if _, err := SimulatePolicies([]byte("not a jpeg"), NewPolicy("web")); err == nil {
+		t.Error("Expected error for invalid JPEG")
+	}
+}
+
+func TestStripVariants(t *testing.T) {
+	jpegData, err := os.ReadFile("testdata/with_comprehensive_mixed.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	web := NewPolicy("web")
+	archive := NewPolicy("archive-min", WithThumbnailMaxBytes(1<<20), WithKeepSoftware())
+	variants, err := StripVariants(jpegData, web, archive)
+	if err != nil {
+		t.Fatalf("StripVariants failed: %v", err)
+	}
+	if len(variants) != 2 {
+		t.Fatalf("Expected 2 variants, got %d", len(variants))
+	}
+
+	for _, variant := range variants {
+		expected, expectedResult, err := Strip(jpegData, variant.Policy.Options...)
+		if err != nil {
+			t.Fatalf("Strip failed for %s: %v", variant.Policy.Name, err)
+		}
+		if !bytes.Equal(variant.Data, expected) {
+			t.Errorf("%s: variant output differs from Strip output", variant.Policy.Name)
+		}
+		if !reflect.DeepEqual(variant.Result, expectedResult) {
+			t.Errorf("%s: variant result %+v differs from Strip result %+v", variant.Policy.Name, variant.Result, expectedResult)
+		}
+		assertPixelsUnchanged(t, jpegData, variant.Data)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		problems []string
+	}{
+		{"default", NewPolicy("web"), nil},
+		{"nil policy", nil, nil},
+		{"default whitelist", NewPolicy("privacy", WithWhitelistMode()), nil},
+		{
+			name:     "empty whitelist",
+			policy:   NewPolicy("strict", WithWhitelistMode(), WithWhitelist(nil, nil)),
+			problems: []string{"empty whitelist"},
+		},
+		{
+			name: "whitelist drops APP14 and Software",
+			policy: NewPolicy("cmyk", WithWhitelistMode(), WithMinimizeAdobe(), WithKeepSoftware(),
+				WithWhitelist([]byte{0xE1, 0xE2}, []uint16{0x0112})),
+			problems: []string{"drops APP14", "WithMinimizeAdobe", "WithKeepSoftware"},
+		},
+		{
+			name:     "invalid values",
+			policy:   NewPolicy("bad", WithTimeout(-1), WithColorVerify(2), WithNormalizeDensity(70000)),
+			problems: []string{"timeout", "tolerance", "density"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Errorf("Expected valid policy, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected validation error")
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Expected error to mention %q, got %v", problem, err)
+				}
+			}
+			if n := strings.Count(err.Error(), "\n") + 1; n != len(tt.problems) {
+				t.Errorf("Expected %d problems, got %d: %v", len(tt.problems), n, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+)
+
+// PreservedSegment identifies an APPn segment that must never be removed, such as a
+// proprietary measurement overlay. It can be loaded from JSON configuration; the
+// signature is matched against the start of the payload byte for byte.
+type PreservedSegment struct {
+	// Name describes the data for operators, e.g. "ACME caliper overlay"
+	Name string `json:"name"`
+	// Marker is the APPn marker byte, 0xE0 through 0xEF
+	Marker byte `json:"marker"`
+	// Signature is the payload prefix; an empty signature matches every segment of Marker
+	Signature string `json:"signature"`
+}
+
+// isPreservedSegment reports whether segment matches one of the preserved signatures
+func isPreservedSegment(segment *jpegSegment, preserved []PreservedSegment) bool {
+	for _, p := range preserved {
+		if segment.MarkerId == p.Marker && bytes.HasPrefix(segment.Data, []byte(p.Signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAPPMarker reports whether marker is APP0 through APP15
+func isAPPMarker(marker byte) bool {
+	return marker >= markerAPP0 && marker <= markerAPP15
+}
@@ -0,0 +1,72 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithPreserveSegments(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	overlay := []byte("ACMEOVL\x00caliper 12.5mm")
+	gopro := []byte("GoPro\x00 telemetry")
+	withOverlay := insertSegment(insertSegment(jpegData, 0xE9, overlay), 0xE6, gopro)
+
+	// Preservation lists are meant to come from deployment configuration
+	var preserved []PreservedSegment
+	config := `[{"name": "ACME caliper overlay", "marker": 233, "signature": "ACMEOVL\u0000"}, {"name": "GoPro", "marker": 230, "signature": "GoPro"}]`
+	if err := json.Unmarshal([]byte(config), &preserved); err != nil {
+		t.Fatalf("Failed to load preservation list: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"Whitelist", []Option{WithWhitelistMode()}},
+		{"Strict", []Option{WithStrictUnknown()}},
+		{"Aggressive", PresetAggressive.Options},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cleaned, _, err := Strip(withOverlay, append(tc.opts, WithPreserveSegments(preserved...))...)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			assertPixelsUnchanged(t, jpegData, cleaned)
+			if payloads := getSegmentPayloads(t, cleaned, 0xE9); len(payloads) != 1 || !bytes.Equal(payloads[0], overlay) {
+				t.Error("Expected the overlay segment to be kept byte for byte")
+			}
+			if payloads := getSegmentPayloads(t, cleaned, 0xE6); len(payloads) != 1 || !bytes.Equal(payloads[0], gopro) {
+				t.Error("Expected the preserved telemetry segment to be kept")
+			}
+		})
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		withHandlers(t)
+		registerTestHandler(t, 0xE9, []byte("ACME"), HandlerFunc(func([]byte) ([]byte, bool) { return nil, false }))
+		cleaned, _, err := Strip(withOverlay, WithPreserveSegments(preserved...))
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		if len(getSegmentPayloads(t, cleaned, 0xE9)) != 1 {
+			t.Error("Expected the preservation list to take precedence over a registered handler")
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		policy := NewPolicy("bad", WithPreserveSegments(PreservedSegment{Name: "frame", Marker: 0xC0}))
+		if err := policy.Validate(); err == nil {
+			t.Error("Expected a non-APPn marker to be reported")
+		}
+		if err := NewPolicy("good", WithPreserveSegments(preserved...)).Validate(); err != nil {
+			t.Errorf("Expected a valid preservation list, got %v", err)
+		}
+	})
+}
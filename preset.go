@@ -0,0 +1,87 @@
+package jpegmetawebstrip
+
+import "regexp"
+
+// Presets are ready-made policies for common stripping goals. They are ordinary policies,
+// so they work with SimulatePolicies, StripVariants and Policy.Validate as well.
+var (
+	// PresetPrivacy removes only what identifies a person or a device: GPS data, serial
+	// numbers (including the MakerNote, where most cameras store the body serial), the owner
+	// name and unique image IDs, including XMP packets that carry them.
+	// Everything else, such as thumbnails, camera model, IPTC and comments, is kept.
+	PresetPrivacy = NewPolicy("privacy", privacyOnly())
+
+	// PresetAggressive drops all metadata not needed for display, keeping only JFIF, ICC,
+	// Adobe color transform data and the EXIF tags in DefaultWhitelistExifTags()
+	PresetAggressive = NewPolicy("aggressive", WithWhitelistMode(), WithMinimizeAdobe(), WithRemoveRadiometric())
+
+	// PresetConservative removes only EXIF thumbnails, comments and data after EOI
+	PresetConservative = NewPolicy("conservative", WithThumbnailOnly(), removeComments())
+)
+
+// privacyExifTags are the Exif IFD tags removed by PresetPrivacy besides GPS, counted as
+// Result.Removed.Identifiers
+var privacyExifTags = []uint16{
+	0xA420, // ImageUniqueID
+	0xA430, // CameraOwnerName
+	0xA431, // BodySerialNumber
+	0xA435, // LensSerialNumber
+}
+
+// privacyXMPProperty matches a prefixed XMP property whose presence makes PresetPrivacy drop
+// the packet, written as an element or an attribute. Only whole local names match, so text
+// that merely mentions them, such as a description, does not.
+var privacyXMPProperty = regexp.MustCompile(`[<\s][A-Za-z][\w.-]*:(?:` +
+	`GPSLatitude|GPSLongitude|` +
+	`SerialNumber|BodySerialNumber|LensSerialNumber|` + // aux:, exifEX:
+	`OwnerName|CameraOwnerName|` + // aux:, exifEX:
+	`ImageUniqueID|DocumentID|OriginalDocumentID|InstanceID` + // exif:, xmpMM:
+	`)(?:\s*=|[\s/>])`)
+
+// StripWithPreset strips data with the options of a preset such as PresetPrivacy; a nil
+// preset uses the defaults
+func StripWithPreset(data []byte, preset *Policy) ([]byte, *Result, error) {
+	if preset == nil {
+		return Strip(data)
+	}
+	return Strip(data, preset.Options...)
+}
+
+// privacyOnly limits removal to location and identifying data
+func privacyOnly() Option {
+	return func(o *options) {
+		o.privacyOnly = true
+	}
+}
+
+// removeComments removes COM segments in WithThumbnailOnly mode
+func removeComments() Option {
+	return func(o *options) {
+		o.removeComments = true
+	}
+}
+
+// hasPrivacyXMP reports whether an XMP packet carries location or identifying properties
+func hasPrivacyXMP(xmpData []byte) bool {
+	return privacyXMPProperty.Match(xmpData)
+}
+
+// cleanPrivacyExif removes GPS data, including a kept thumbnail's, the identifying Exif IFD
+// tags and the MakerNote, where most vendors keep the body serial number, then rebuilds the
+// EXIF to reclaim their space
+func cleanPrivacyExif(exifData []byte, result *Result) ([]byte, bool, int64) {
+	exifData, totalRemoved := applyExifRemovals(exifData, []exifRemoval{
+		{&result.Removed.ExifGPS, removeGPSFromExif},
+		{&result.Removed.ExifGPS, func(data []byte) ([]byte, bool, int64) { return removeIFD1Tags(data, []uint16{0x8825}) }},
+		{&result.Removed.Identifiers, func(data []byte) ([]byte, bool, int64) { return removeExifIFDTags(data, privacyExifTags) }},
+		{&result.Removed.Identifiers, removeMakerNote},
+	})
+
+	if totalRemoved == 0 {
+		return exifData, false, 0
+	}
+	if rebuilt, ok := rebuildExif(exifData, nil); ok && len(rebuilt) < len(exifData) {
+		exifData = rebuilt
+	}
+	return exifData, true, totalRemoved
+}
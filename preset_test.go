@@ -0,0 +1,164 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildPresetTestJPEG returns a JPEG with EXIF (camera, GPS, serial, owner, a MakerNote
+// holding a serial and a thumbnail),
+// an XMP packet with a document ID, and a comment
+func buildPresetTestJPEG(t *testing.T) []byte {
+	t.Helper()
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.ascii(0x010F, "ExampleMaker"), e.short(0x0112, 1)}
+	e.exifIFD = []testTag{e.ascii(0xA430, "Jane Example"), e.ascii(0xA431, "SN-000123456"), e.short(0xA001, 1),
+		e.undefined(0x927C, []byte("Nikon\x00\x02\x10\x00\x00BODY-SN-987654"))}
+	e.gpsIFD = []testTag{e.ascii(0x0001, "N"), e.rational(0x0002, 35, 1)}
+	e.ifd1 = []testTag{e.short(0x0103, 6)}
+	e.thumbnail = []byte{0xFF, 0xD8, 0xFF, 0xD9}
+
+	xmp := XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/" xmpMM:DocumentID="xmp.did:1234"/></rdf:RDF></x:xmpmeta>`
+
+	jpegData = insertSegment(jpegData, 0xFE, []byte("team review"))
+	jpegData = insertSegment(jpegData, 0xE1, []byte(xmp))
+	return insertSegment(jpegData, 0xE1, e.build())
+}
+
+// presetTestExif returns the EXIF payload of jpegData, or nil
+func presetTestExif(t *testing.T, jpegData []byte) []byte {
+	t.Helper()
+	for _, payload := range getSegmentPayloads(t, jpegData, 0xE1) {
+		if bytes.HasPrefix(payload, []byte(ExifHeader)) {
+			return payload
+		}
+	}
+	return nil
+}
+
+func TestPresetPrivacy(t *testing.T) {
+	jpegData := buildPresetTestJPEG(t)
+
+	cleaned, result, err := StripWithPreset(jpegData, PresetPrivacy)
+	if err != nil {
+		t.Fatalf("StripWithPreset failed: %v", err)
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+
+	exifData := presetTestExif(t, cleaned)
+	for _, removed := range []string{"Jane Example", "SN-000123456", "BODY-SN-987654"} {
+		if bytes.Contains(exifData, []byte(removed)) {
+			t.Errorf("%q should have been removed", removed)
+		}
+	}
+	if tags := exifTagIDs(exifData); containsTag(tags, 0x8825) || !containsTag(tags, 0x010F) {
+		t.Errorf("Expected GPS removed and Make kept, got tags %v", tags)
+	}
+	if getThumbnailLength(exifData) != 4 || len(getSegmentPayloads(t, cleaned, 0xFE)) != 1 {
+		t.Error("Expected the thumbnail and the comment to be kept")
+	}
+	if bytes.Contains(cleaned, []byte(XMPHeader)) {
+		t.Error("Expected the XMP packet with a document ID to be removed")
+	}
+	if result.Removed.ExifGPS == 0 || result.Removed.Identifiers == 0 || result.Removed.XMP == 0 {
+		t.Errorf("Expected GPS, identifier and XMP savings, got %+v", result.Removed)
+	}
+	if result.Removed.ExifThumbnail != 0 || result.Removed.CameraInfo != 0 || result.Removed.Comments != 0 {
+		t.Errorf("Expected nothing else to be removed, got %+v", result.Removed)
+	}
+}
+
+func TestHasPrivacyXMP(t *testing.T) {
+	tests := map[string]bool{
+		`<rdf:Description xmpMM:InstanceID="xmp.iid:1"/>`:                   true,
+		`<rdf:Description><xmpMM:DocumentID>x</xmpMM:DocumentID>`:           true,
+		`<exifEX:BodySerialNumber>123</exifEX:BodySerialNumber>`:            true,
+		`<aux:SerialNumber>123</aux:SerialNumber>`:                          true,
+		`<exif:GPSLatitude>35,40.1N</exif:GPSLatitude>`:                     true,
+		`<dc:description>Find the InstanceID field</dc:description>`:        false,
+		`<photoshop:SerialNumberPolicy>none</photoshop:SerialNumberPolicy>`: false,
+		`<xmp:Rating>5</xmp:Rating>`:                                        false,
+	}
+	for packet, want := range tests {
+		if got := hasPrivacyXMP([]byte(packet)); got != want {
+			t.Errorf("hasPrivacyXMP(%s) = %v, want %v", packet, got, want)
+		}
+	}
+}
+
+func TestPresetConservative(t *testing.T) {
+	jpegData := buildPresetTestJPEG(t)
+
+	cleaned, result, err := StripWithPreset(jpegData, PresetConservative)
+	if err != nil {
+		t.Fatalf("StripWithPreset failed: %v", err)
+	}
+	if result.Removed.ExifThumbnail == 0 || result.Removed.Comments == 0 {
+		t.Errorf("Expected thumbnail and comment savings, got %+v", result.Removed)
+	}
+	if result.Total != result.Removed.ExifThumbnail+result.Removed.Comments {
+		t.Errorf("Expected nothing else to be removed, got %+v", result.Removed)
+	}
+	if exifData := presetTestExif(t, cleaned); !bytes.Contains(exifData, []byte("SN-000123456")) {
+		t.Error("Expected EXIF tags to be kept")
+	}
+}
+
+func TestPresetAggressive(t *testing.T) {
+	jpegData := buildPresetTestJPEG(t)
+
+	cleaned, result, err := StripWithPreset(jpegData, PresetAggressive)
+	if err != nil {
+		t.Fatalf("StripWithPreset failed: %v", err)
+	}
+	expected, _, err := Strip(jpegData, WithWhitelistMode(), WithMinimizeAdobe(), WithRemoveRadiometric())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !bytes.Equal(cleaned, expected) {
+		t.Error("Expected the aggressive preset to match whitelist mode")
+	}
+	if result.Total == 0 {
+		t.Error("Expected metadata to be removed")
+	}
+}
+
+func TestPresetsValidate(t *testing.T) {
+	for _, preset := range []*Policy{PresetPrivacy, PresetAggressive, PresetConservative} {
+		if err := preset.Validate(); err != nil {
+			t.Errorf("preset %q: %v", preset.Name, err)
+		}
+	}
+
+	combined := NewPolicy("combined", append(append([]Option{}, PresetPrivacy.Options...), WithWhitelistMode())...)
+	if err := combined.Validate(); err == nil {
+		t.Error("Expected the privacy preset combined with whitelist mode to be reported")
+	}
+}
+
+func TestStripWithNilPreset(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, _, err := StripWithPreset(jpegData, nil)
+	if err != nil {
+		t.Fatalf("StripWithPreset failed: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !bytes.Equal(cleaned, expected) {
+		t.Error("Expected a nil preset to strip with the defaults")
+	}
+}
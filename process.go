@@ -0,0 +1,75 @@
+package jpegmetawebstrip
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+// ProcessFlags selects what Process computes
+type ProcessFlags uint
+
+const (
+	// ProcessStrip strips metadata and fills Processed.Data and Processed.Result
+	ProcessStrip ProcessFlags = 1 << iota
+	// ProcessHash fills Processed.SHA256 with the hash of the output, or of the input
+	// when ProcessStrip is not set
+	ProcessHash
+	// ProcessDimensions fills Processed.Width and Processed.Height from the frame header
+	ProcessDimensions
+	// ProcessSummary fills Processed.Summary as Inspect would for the input
+	ProcessSummary
+
+	// ProcessAll computes everything
+	ProcessAll = ProcessStrip | ProcessHash | ProcessDimensions | ProcessSummary
+)
+
+// Processed holds what Process computed; fields not requested are left zero
+type Processed struct {
+	Data    []byte
+	Result  *Result
+	SHA256  [sha256.Size]byte
+	Width   int
+	Height  int
+	Summary *Summary
+}
+
+// Process parses data once and computes the stripped output, its SHA-256, the image
+// dimensions and the Summary as selected by want, for ingestion services that would
+// otherwise parse the same JPEG for each of them. opts apply to stripping as in Strip.
+func Process(data []byte, want ProcessFlags, opts ...Option) (*Processed, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		o.deadline = time.Now().Add(o.timeout)
+	}
+
+	tracker := newResourceTracker(o.resourceStats)
+	sl, err := parseSegmentList(data)
+	if err != nil {
+		return nil, err
+	}
+	tracker.parsed()
+
+	processed := &Processed{}
+	if want&ProcessSummary != 0 {
+		processed.Summary = summarize(len(data), sl.Segments())
+	}
+	if want&ProcessDimensions != 0 {
+		if frame, ok := readFrameHeader(sl.Segments()); ok {
+			processed.Width, processed.Height = int(frame.Width), int(frame.Height)
+		}
+	}
+
+	hashed := data
+	if want&ProcessStrip != 0 {
+		output, result, err := stripSegments(data, sl, o, tracker)
+		if err != nil {
+			return nil, err
+		}
+		processed.Data, processed.Result = output, result
+		hashed = output
+	}
+	if want&ProcessHash != 0 {
+		processed.SHA256 = sha256.Sum256(hashed)
+	}
+	return processed, nil
+}
@@ -0,0 +1,79 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProcessMatchesSeparateCalls(t *testing.T) {
+	for _, name := range []string{"with_comprehensive_mixed.jpg", "with_icc_profile_p3.jpg", "with_gps.jpg"} {
+		t.Run(name, func(t *testing.T) {
+			jpegData, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+
+			processed, err := Process(jpegData, ProcessAll, WithKeepSoftware())
+			if err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			expected, expectedResult, err := Strip(jpegData, WithKeepSoftware())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if !bytes.Equal(processed.Data, expected) || processed.Result.Total != expectedResult.Total {
+				t.Error("Expected stripped output to match Strip")
+			}
+			if processed.SHA256 != sha256.Sum256(expected) {
+				t.Error("Expected the hash of the stripped output")
+			}
+
+			config, err := jpeg.DecodeConfig(bytes.NewReader(jpegData))
+			if err != nil {
+				t.Fatalf("Failed to decode config: %v", err)
+			}
+			if processed.Width != config.Width || processed.Height != config.Height {
+				t.Errorf("Expected %dx%d, got %dx%d", config.Width, config.Height, processed.Width, processed.Height)
+			}
+
+			summary, err := Inspect(jpegData)
+			if err != nil {
+				t.Fatalf("Inspect failed: %v", err)
+			}
+			if !reflect.DeepEqual(processed.Summary, summary) {
+				t.Errorf("Expected summary %+v, got %+v", summary, processed.Summary)
+			}
+		})
+	}
+}
+
+func TestProcessFlags(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	processed, err := Process(jpegData, ProcessHash|ProcessDimensions)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if processed.Data != nil || processed.Result != nil || processed.Summary != nil {
+		t.Error("Expected unrequested fields to stay empty")
+	}
+	if processed.SHA256 != sha256.Sum256(jpegData) {
+		t.Error("Expected the hash of the input when not stripping")
+	}
+	if processed.Width == 0 || processed.Height == 0 {
+		t.Error("Expected dimensions")
+	}
+
+	if _, err := Process([]byte("not a jpeg"), ProcessAll); err == nil {
+		t.Error("Expected an error for invalid input")
+	}
+}
@@ -0,0 +1,209 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+)
+
+// ProcessedCommentPrefix starts the COM segment written by WithProcessedComment. The full
+// payload is the prefix alone or the prefix, a colon and a policy ID.
+const ProcessedCommentPrefix = "meta-stripped"
+
+// processedComment returns the marker payload for policyID
+func processedComment(policyID string) string {
+	if policyID == "" {
+		return ProcessedCommentPrefix
+	}
+	return ProcessedCommentPrefix + ":" + policyID
+}
+
+// isProcessedComment reports whether a COM payload is a processing marker
+func isProcessedComment(payload []byte) bool {
+	return bytes.Equal(payload, []byte(ProcessedCommentPrefix)) || bytes.HasPrefix(payload, []byte(ProcessedCommentPrefix+":"))
+}
+
+// DetectProcessed reports whether data carries a marker written by WithProcessedComment and
+// returns its policy ID. Only the segment headers before the first scan are read, so it is
+// far cheaper than hashing or analyzing the file.
+func DetectProcessed(data []byte) (policyID string, ok bool) {
+	payload, ok := findHeaderSegment(data, func(marker byte, payload []byte) bool {
+		return marker == markerCOM && isProcessedComment(payload)
+	})
+	if !ok {
+		return "", false
+	}
+	id := strings.TrimPrefix(string(payload), ProcessedCommentPrefix)
+	return strings.TrimPrefix(id, ":"), true
+}
+
+// ProcessedMarkerSignature starts the 16-byte APP15 payload written by WithProcessedMarker.
+// It is followed by the big-endian policy version and the first 8 bytes of the SHA-256 of
+// the policy ID.
+const ProcessedMarkerSignature = "MSTRIP"
+
+// processedMarkerSize is the APP15 payload size of a processing marker
+const processedMarkerSize = len(ProcessedMarkerSignature) + 2 + 8
+
+// ProcessedMarker is the policy recorded in the APP15 marker of WithProcessedMarker. It is a
+// label, not a proof: the digest is computed from the policy ID alone, so anyone who knows or
+// guesses the ID can write a matching marker into a file that was never stripped.
+type ProcessedMarker struct {
+	// Version is the policy version, bumped by its owners whenever the policy changes
+	Version uint16
+	// Digest is the first 8 bytes of the SHA-256 of the policy ID; it only keeps the policy
+	// name out of the file and authenticates nothing
+	Digest [8]byte
+}
+
+// NewProcessedMarker returns the marker identifying version of the policy policyID
+func NewProcessedMarker(policyID string, version uint16) ProcessedMarker {
+	sum := sha256.Sum256([]byte(policyID))
+	m := ProcessedMarker{Version: version}
+	copy(m.Digest[:], sum[:])
+	return m
+}
+
+// payload encodes the marker as an APP15 payload
+func (m ProcessedMarker) payload() []byte {
+	b := make([]byte, 0, processedMarkerSize)
+	b = append(b, ProcessedMarkerSignature...)
+	b = binary.BigEndian.AppendUint16(b, m.Version)
+	return append(b, m.Digest[:]...)
+}
+
+// isProcessedMarker reports whether an APP15 payload is a processing marker
+func isProcessedMarker(payload []byte) bool {
+	return len(payload) == processedMarkerSize && bytes.HasPrefix(payload, []byte(ProcessedMarkerSignature))
+}
+
+// DetectProcessedMarker reports whether data carries an APP15 marker in the format of
+// WithProcessedMarker and returns it. Like DetectProcessed, only the segment headers
+// before the first scan are read. A marker does not prove the file was stripped.
+func DetectProcessedMarker(data []byte) (ProcessedMarker, bool) {
+	payload, ok := findHeaderSegment(data, func(marker byte, payload []byte) bool {
+		return marker == markerAPP15 && isProcessedMarker(payload)
+	})
+	if !ok {
+		return ProcessedMarker{}, false
+	}
+	m := ProcessedMarker{Version: binary.BigEndian.Uint16(payload[len(ProcessedMarkerSignature):])}
+	copy(m.Digest[:], payload[len(ProcessedMarkerSignature)+2:])
+	return m, true
+}
+
+// findHeaderSegment walks the segment headers before the first scan and returns the payload
+// of the first segment matching match, without parsing the rest of the file
+func findHeaderSegment(data []byte, match func(marker byte, payload []byte) bool) ([]byte, bool) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != markerSOI {
+		return nil, false
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, false
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF: // Fill byte
+			pos++
+			continue
+		case isStandaloneMarker(marker):
+			pos += 2
+			continue
+		case marker == markerSOS, marker == markerEOI:
+			return nil, false
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, false
+		}
+		if payload := data[pos+4 : end]; match(marker, payload) {
+			return payload, true
+		}
+		pos = end
+	}
+	return nil, false
+}
+
+// isMarkedProcessed reports whether data carries every processing marker o writes and o
+// trusts them, so Strip can return it without parsing. It is false when o writes no marker.
+func isMarkedProcessed(data []byte, o *options) bool {
+	if !o.trustProcessed || (o.processedComment == "" && o.processedMarker == nil) {
+		return false
+	}
+	if o.processedComment != "" {
+		if policyID, ok := DetectProcessed(data); !ok || processedComment(policyID) != o.processedComment {
+			return false
+		}
+	}
+	if o.processedMarker != nil {
+		if m, ok := DetectProcessedMarker(data); !ok || m != *o.processedMarker {
+			return false
+		}
+	}
+	return true
+}
+
+// hasProcessedMarkers reports whether segments carry every processing marker o writes and o
+// trusts them. It is false when o writes no marker.
+func hasProcessedMarkers(segments []*jpegSegment, o *options) bool {
+	if !o.trustProcessed || (o.processedComment == "" && o.processedMarker == nil) {
+		return false
+	}
+	if o.processedComment != "" && !hasSegment(segments, markerCOM, []byte(o.processedComment)) {
+		return false
+	}
+	if o.processedMarker != nil && !hasSegment(segments, markerAPP15, o.processedMarker.payload()) {
+		return false
+	}
+	return true
+}
+
+// isReplacedProcessingMarker reports whether segment is a processing marker of the kind o
+// writes; it is dropped so that the current marker replaces it
+func isReplacedProcessingMarker(segment *jpegSegment, o *options) bool {
+	switch {
+	case o.processedComment != "" && segment.MarkerId == markerCOM:
+		return isProcessedComment(segment.Data)
+	case o.processedMarker != nil && segment.MarkerId == markerAPP15:
+		return isProcessedMarker(segment.Data)
+	}
+	return false
+}
+
+// insertProcessingMarkers adds the markers o writes after the leading APPn segments
+func insertProcessingMarkers(segments []*jpegSegment, o *options) []*jpegSegment {
+	if o.processedMarker != nil {
+		segments = insertAfterAPPn(segments, &jpegSegment{MarkerId: markerAPP15, MarkerName: "APP15", Data: o.processedMarker.payload()})
+	}
+	if o.processedComment != "" {
+		segments = insertAfterAPPn(segments, &jpegSegment{MarkerId: markerCOM, MarkerName: "COM", Data: []byte(o.processedComment)})
+	}
+	return segments
+}
+
+// hasSegment reports whether segments contain one with this marker and payload
+func hasSegment(segments []*jpegSegment, marker byte, payload []byte) bool {
+	for _, segment := range segments {
+		if segment.MarkerId == marker && bytes.Equal(segment.Data, payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertAfterAPPn adds segment after SOI and the leading APPn segments, so JFIF and EXIF
+// stay at the front of the file
+func insertAfterAPPn(segments []*jpegSegment, segment *jpegSegment) []*jpegSegment {
+	at := 0
+	for at < len(segments) && (segments[at].MarkerId == markerSOI || isAPPMarker(segments[at].MarkerId)) {
+		at++
+	}
+	inserted := make([]*jpegSegment, 0, len(segments)+1)
+	inserted = append(inserted, segments[:at]...)
+	inserted = append(inserted, segment)
+	return append(inserted, segments[at:]...)
+}
@@ -0,0 +1,264 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithProcessedComment(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if _, ok := DetectProcessed(jpegData); ok {
+		t.Fatal("Expected an unmarked input")
+	}
+
+	cleaned, result, err := Strip(jpegData, WithProcessedComment("web-v1"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed {
+		t.Error("Expected the first run to process the file")
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+	if policyID, ok := DetectProcessed(cleaned); !ok || policyID != "web-v1" {
+		t.Errorf("Expected policy ID web-v1 to be detected, got %q, %v", policyID, ok)
+	}
+	comments := getSegmentPayloads(t, cleaned, 0xFE)
+	if len(comments) != 1 || string(comments[0]) != "meta-stripped:web-v1" {
+		t.Errorf("Expected a single marker COM, got %q", comments)
+	}
+
+	// Without a policy ID the bare prefix is written
+	bare, _, err := Strip(jpegData, WithProcessedComment(""))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if policyID, ok := DetectProcessed(bare); !ok || policyID != "" {
+		t.Errorf("Expected an empty policy ID, got %q, %v", policyID, ok)
+	}
+
+	// Without the option an existing marker is treated like any other comment
+	_, result, err = Strip(cleaned)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed || result.Removed.Comments == 0 {
+		t.Errorf("Expected the marker to be removed as a comment, got %+v", result.Removed)
+	}
+}
+
+func TestProcessedCommentRerun(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, _, err := Strip(jpegData, WithProcessedComment("web-v1"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	// Without trusting markers, the same policy processes the file again and keeps one marker
+	again, result, err := Strip(cleaned, WithProcessedComment("web-v1"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed || !bytes.Equal(again, cleaned) {
+		t.Errorf("Expected the file to be stripped again to the same bytes, got AlreadyProcessed=%v", result.AlreadyProcessed)
+	}
+
+	// Trusting markers, the same policy short-circuits and returns the input as is
+	again, result, err = Strip(cleaned, WithProcessedComment("web-v1"), WithTrustProcessedMarkers())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !result.AlreadyProcessed || result.Total != 0 || !bytes.Equal(again, cleaned) {
+		t.Errorf("Expected the marked file to be returned unchanged, got AlreadyProcessed=%v Total=%d", result.AlreadyProcessed, result.Total)
+	}
+
+	// Another policy replaces the marker instead of adding a second one
+	replaced, result, err := Strip(cleaned, WithProcessedComment("web-v2"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed {
+		t.Error("Expected a different policy to process the file again")
+	}
+	comments := getSegmentPayloads(t, replaced, 0xFE)
+	if len(comments) != 1 || string(comments[0]) != "meta-stripped:web-v2" {
+		t.Errorf("Expected the marker to be replaced, got %q", comments)
+	}
+
+}
+
+func TestForgedProcessedComment(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	forged := insertSegment(jpegData, 0xFE, []byte("meta-stripped:web-v1"))
+
+	cleaned, result, err := Strip(forged, WithProcessedComment("web-v1"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed || result.Total == 0 || bytes.Equal(cleaned, forged) {
+		t.Errorf("Expected a forged marker not to skip stripping, got AlreadyProcessed=%v Total=%d", result.AlreadyProcessed, result.Total)
+	}
+}
+
+func TestProcessedCommentPlacement(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, _, err := Strip(jpegData, WithProcessedComment("web-v1"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	sl, err := parseSegmentList(cleaned)
+	if err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	seenComment := false
+	for _, segment := range sl.Segments() {
+		switch {
+		case segment.MarkerId == 0xFE:
+			seenComment = true
+		case isAPPMarker(segment.MarkerId) && seenComment:
+			t.Errorf("Expected the marker after all APPn segments, found %s after it", segment.MarkerName)
+		}
+	}
+	if !seenComment {
+		t.Error("Expected a marker COM in the output")
+	}
+}
+
+func TestDetectProcessedMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"not jpeg":  []byte("meta-stripped"),
+		"truncated": {0xFF, 0xD8, 0xFF, 0xFE, 0x00, 0x20, 'm'},
+		"after sos": {0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xFE, 0x00, 0x0F, 'm', 'e', 't', 'a', '-', 's', 't', 'r', 'i', 'p', 'p', 'e', 'd'},
+		"other com": {0xFF, 0xD8, 0xFF, 0xFE, 0x00, 0x07, 'h', 'e', 'l', 'l', 'o', 0xFF, 0xD9},
+	} {
+		if _, ok := DetectProcessed(data); ok {
+			t.Errorf("%s: expected no marker", name)
+		}
+	}
+}
+
+func TestWithProcessedMarker(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	want := NewProcessedMarker("web", 3)
+
+	cleaned, result, err := Strip(jpegData, WithProcessedMarker("web", 3))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed {
+		t.Error("Expected the first run to process the file")
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+	if m, ok := DetectProcessedMarker(cleaned); !ok || m != want {
+		t.Errorf("Expected marker %+v to be detected, got %+v, %v", want, m, ok)
+	}
+	markers := getSegmentPayloads(t, cleaned, 0xEF)
+	if len(markers) != 1 || len(markers[0]) != 16 {
+		t.Fatalf("Expected a single 16-byte APP15 marker, got %q", markers)
+	}
+	if _, ok := DetectProcessed(cleaned); ok {
+		t.Error("Expected no marker COM without WithProcessedComment")
+	}
+
+	// A new policy version replaces the marker
+	replaced, result, err := Strip(cleaned, WithProcessedMarker("web", 4))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed || result.Removed.Other != 16 {
+		t.Errorf("Expected the old marker to be removed, got AlreadyProcessed=%v Other=%d", result.AlreadyProcessed, result.Removed.Other)
+	}
+	if m, ok := DetectProcessedMarker(replaced); !ok || m != NewProcessedMarker("web", 4) || len(getSegmentPayloads(t, replaced, 0xEF)) != 1 {
+		t.Errorf("Expected the marker to be replaced by version 4, got %+v, %v", m, ok)
+	}
+}
+
+func TestProcessedMarkerRerun(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, _, err := Strip(jpegData, WithProcessedMarker("web", 3))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	// Another stage with the same policy and version short-circuits
+	again, result, err := Strip(cleaned, WithProcessedMarker("web", 3), WithTrustProcessedMarkers())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !result.AlreadyProcessed || !bytes.Equal(again, cleaned) {
+		t.Errorf("Expected the marked file to be returned unchanged, got AlreadyProcessed=%v", result.AlreadyProcessed)
+	}
+
+	// Strict mode understands the marker
+	if _, _, err := Strip(cleaned, WithStrictUnknown(), WithProcessedMarker("web", 4)); err != nil {
+		t.Errorf("Strict Strip failed on a processing marker: %v", err)
+	}
+
+	// With both markers, a file carrying only one of them is processed again
+	both, result, err := Strip(cleaned, WithProcessedMarker("web", 3), WithProcessedComment("web"), WithTrustProcessedMarkers())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed {
+		t.Error("Expected a file without the marker COM to be processed")
+	}
+	if _, ok := DetectProcessed(both); !ok {
+		t.Error("Expected the marker COM to be added")
+	}
+	if _, result, _ := Strip(both, WithProcessedMarker("web", 3), WithProcessedComment("web"), WithTrustProcessedMarkers()); !result.AlreadyProcessed {
+		t.Error("Expected a file with both markers to short-circuit")
+	}
+}
+
+func TestForgedProcessedMarker(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	forged := insertSegment(jpegData, 0xEF, NewProcessedMarker("web", 3).payload())
+
+	cleaned, result, err := Strip(forged, WithProcessedMarker("web", 3))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.AlreadyProcessed || result.Total == 0 || bytes.Equal(cleaned, forged) {
+		t.Errorf("Expected a forged marker not to skip stripping, got AlreadyProcessed=%v Total=%d", result.AlreadyProcessed, result.Total)
+	}
+	if markers := getSegmentPayloads(t, cleaned, 0xEF); len(markers) != 1 {
+		t.Errorf("Expected the forged marker to be replaced by one marker, got %d", len(markers))
+	}
+}
+
+func TestDetectProcessedMarkerMalformed(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":       nil,
+		"short":       {0xFF, 0xD8, 0xFF, 0xEF, 0x00, 0x08, 'M', 'S', 'T', 'R', 'I', 'P', 0xFF, 0xD9},
+		"other app15": {0xFF, 0xD8, 0xFF, 0xEF, 0x00, 0x12, 'O', 'T', 'H', 'E', 'R', '!', 0, 1, 1, 2, 3, 4, 5, 6, 7, 8, 0xFF, 0xD9},
+		"com":         {0xFF, 0xD8, 0xFF, 0xFE, 0x00, 0x12, 'M', 'S', 'T', 'R', 'I', 'P', 0, 1, 1, 2, 3, 4, 5, 6, 7, 8, 0xFF, 0xD9},
+	} {
+		if _, ok := DetectProcessedMarker(data); ok {
+			t.Errorf("%s: expected no marker", name)
+		}
+	}
+}
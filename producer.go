@@ -0,0 +1,152 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// makerNoteProducers maps MakerNote signatures to the producer they identify
+var makerNoteProducers = []struct {
+	Prefix   string
+	Producer string
+}{
+	{"Apple iOS\x00", "Apple iOS"},
+	{"Nikon\x00", "Nikon"},
+	{"OLYMPUS\x00", "Olympus"},
+	{"FUJIFILM", "Fujifilm"},
+	{"Panasonic\x00", "Panasonic"},
+	{"SONY", "Sony"},
+	{"PENTAX ", "Pentax"},
+	{"LEICA", "Leica"},
+}
+
+// creatorToolProperty is the XMP property naming the application that created the file
+var creatorToolProperty = []xmpProperty{
+	{Namespace: xmpBasicNS, Prefix: "xmp", Name: "CreatorTool"},
+}
+
+// detectProducer makes a best-effort guess at the application or device that wrote the JPEG.
+// It prefers EXIF Software, then XMP CreatorTool, then the camera Make/Model and MakerNote,
+// and finally structural hints. It returns "" when nothing identifies the producer.
+func detectProducer(segments []*jpegSegment) string {
+	hints := collectProducerHints(segments)
+	switch {
+	// Phones write an OS version such as "17.1" as Software; the model is more telling
+	case hints.software != "" && !isVersionString(hints.software):
+		return hints.software
+	case hints.creatorTool != "":
+		return hints.creatorTool
+	case hints.camera != "":
+		return hints.camera
+	case hints.makerNote != "":
+		return hints.makerNote
+	case hints.software != "":
+		return hints.software
+	case hints.hasPhotoshopIRB:
+		return "Adobe Photoshop"
+	}
+	return ""
+}
+
+// producerHints are the values detectProducer chooses from
+type producerHints struct {
+	software, creatorTool, camera, makerNote string
+	hasPhotoshopIRB                          bool
+}
+
+// collectProducerHints gathers the producer hints of the EXIF, XMP and Photoshop segments
+func collectProducerHints(segments []*jpegSegment) producerHints {
+	var hints producerHints
+	for _, segment := range segments {
+		switch {
+		case segment.MarkerId == markerAPP1 && isExifSegment(segment):
+			hints.software, hints.camera, hints.makerNote = exifProducerHints(segment.Data)
+		case segment.MarkerId == markerAPP1 && isXMPSegment(segment):
+			packet := bytes.TrimPrefix(segment.Data, []byte(XMPHeader))
+			if props := extractXMPProperties(packet, creatorToolProperty); len(props) > 0 {
+				hints.creatorTool = strings.TrimSpace(props[0].Value)
+			}
+		case segment.MarkerId == markerAPP13 && bytes.HasPrefix(segment.Data, []byte(PhotoshopHeader)):
+			hints.hasPhotoshopIRB = true
+		}
+	}
+	return hints
+}
+
+// exifProducerHints returns the Software, the combined Make/Model and the MakerNote
+// signature of an EXIF segment
+func exifProducerHints(exifData []byte) (software, camera, makerNote string) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return "", "", ""
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+
+	var cameraMake, model string
+	for _, entry := range entries {
+		switch entry.Tag {
+		case 0x0131: // Software
+			software = asciiValue(exifData, order, entry)
+		case 0x010F: // Make
+			cameraMake = asciiValue(exifData, order, entry)
+		case 0x0110: // Model
+			model = asciiValue(exifData, order, entry)
+		case 0x8769: // Exif IFD Pointer
+			exifEntries, _, _ := readIFD(exifData, order, tiffHeaderPos+int(entry.offset(order)))
+			for _, exifEntry := range exifEntries {
+				if exifEntry.Tag != 0x927C { // MakerNote
+					continue
+				}
+				if value, ok := exifEntry.valueBytes(exifData, order); ok {
+					makerNote = makerNoteProducer(value)
+				}
+			}
+		}
+	}
+
+	switch {
+	case model == "":
+		camera = cameraMake
+	case cameraMake == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(cameraMake)):
+		camera = model
+	case strings.EqualFold(cameraMake, "Apple"):
+		// Apple models ("iPhone 15 Pro") are recognizable without the make
+		camera = model
+	default:
+		camera = cameraMake + " " + model
+	}
+	return software, camera, makerNote
+}
+
+// asciiValue returns the trimmed ASCII value of an IFD entry
+func asciiValue(exifData []byte, order binary.ByteOrder, entry ifdEntry) string {
+	value, ok := entry.valueBytes(exifData, order)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+}
+
+// makerNoteProducer identifies a producer from the signature of a MakerNote
+func makerNoteProducer(makerNote []byte) string {
+	for _, known := range makerNoteProducers {
+		if bytes.HasPrefix(makerNote, []byte(known.Prefix)) {
+			return known.Producer
+		}
+	}
+	return ""
+}
+
+// isVersionString reports whether s is a bare version number such as "17.1" or "1.0.2"
+func isVersionString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
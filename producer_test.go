@@ -0,0 +1,94 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestDetectProducer(t *testing.T) {
+	exifSegment := func(build func(e *testExif)) *jpegSegment {
+		e := newTestExif(binary.BigEndian)
+		build(e)
+		return &jpegSegment{MarkerId: markerAPP1, Data: e.build()}
+	}
+	xmpSegment := &jpegSegment{
+		MarkerId: markerAPP1,
+		Data: []byte(XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+			`<rdf:Description xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:CreatorTool="Adobe Photoshop Lightroom Classic 12.0"/>` +
+			`</rdf:RDF></x:xmpmeta>`),
+	}
+
+	tests := []struct {
+		name     string
+		segments []*jpegSegment
+		expected string
+	}{
+		{
+			name: "EXIF Software",
+			segments: []*jpegSegment{exifSegment(func(e *testExif) {
+				e.ifd0 = []testTag{e.ascii(0x010F, "Canon"), e.ascii(0x0131, "GIMP 2.10")}
+			})},
+			expected: "GIMP 2.10",
+		},
+		{
+			name: "iPhone version-only Software falls back to model",
+			segments: []*jpegSegment{exifSegment(func(e *testExif) {
+				e.ifd0 = []testTag{e.ascii(0x010F, "Apple"), e.ascii(0x0110, "iPhone 15 Pro"), e.ascii(0x0131, "17.1")}
+			})},
+			expected: "iPhone 15 Pro",
+		},
+		{
+			name: "Make and Model",
+			segments: []*jpegSegment{exifSegment(func(e *testExif) {
+				e.ifd0 = []testTag{e.ascii(0x010F, "FUJIFILM"), e.ascii(0x0110, "X-T5")}
+			})},
+			expected: "FUJIFILM X-T5",
+		},
+		{
+			name: "MakerNote signature",
+			segments: []*jpegSegment{exifSegment(func(e *testExif) {
+				e.exifIFD = []testTag{e.undefined(0x927C, []byte("Nikon\x00\x02\x10\x00\x00"))}
+			})},
+			expected: "Nikon",
+		},
+		{
+			name:     "XMP CreatorTool",
+			segments: []*jpegSegment{xmpSegment},
+			expected: "Adobe Photoshop Lightroom Classic 12.0",
+		},
+		{
+			name:     "Photoshop IRB only",
+			segments: []*jpegSegment{{MarkerId: markerAPP13, Data: []byte(PhotoshopHeader)}},
+			expected: "Adobe Photoshop",
+		},
+		{
+			name:     "no hints",
+			segments: []*jpegSegment{{MarkerId: markerAPP0, Data: []byte("JFIF\x00")}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectProducer(tt.segments); got != tt.expected {
+				t.Errorf("Expected producer %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInspectProducer(t *testing.T) {
+	jpegData, err := os.ReadFile("testdata/with_comprehensive_mixed.jpg")
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	summary, err := Inspect(jpegData)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if summary.Producer == "" {
+		t.Error("Expected a producer guess from the EXIF camera tags")
+	}
+}
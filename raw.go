@@ -0,0 +1,238 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Raw formats reported by RawImageError and Result.EmbeddedPreview
+const (
+	RawFormatTIFF = "TIFF"
+	RawFormatDNG  = "DNG"
+	RawFormatCR2  = "CR2"
+	RawFormatORF  = "ORF"
+	RawFormatRW2  = "RW2"
+	RawFormatRAF  = "RAF"
+)
+
+// TIFF tags used to locate embedded previews
+const (
+	tiffCompressionTag      = 0x0103
+	tiffStripByteCountsTag  = 0x0117
+	tiffDNGVersionTag       = 0xC612
+	tiffCompressionJPEG     = 6
+	tiffCompressionJPEGNew  = 7
+	rafHeader               = "FUJIFILMCCD-RAW "
+	rafPreviewPointerOffset = 84
+)
+
+// rawMagics maps the leading bytes of TIFF-based raw files to their format.
+// Plain TIFF magics cover DNG, NEF, ARW, PEF and the like, which are told apart later.
+var rawMagics = []struct {
+	magic  string
+	order  binary.ByteOrder
+	format string
+}{
+	{"II*\x00", binary.LittleEndian, RawFormatTIFF},
+	{"MM\x00*", binary.BigEndian, RawFormatTIFF},
+	{"IIRO", binary.LittleEndian, RawFormatORF},
+	{"IIRS", binary.LittleEndian, RawFormatORF},
+	{"MMOR", binary.BigEndian, RawFormatORF},
+	{"IIU\x00", binary.LittleEndian, RawFormatRW2},
+}
+
+// tiffEntry is a TIFF directory entry whose offsets are relative to the start of the file
+type tiffEntry struct {
+	Type  uint16
+	Count uint32
+	Value uint32
+}
+
+// detectRaw reports whether data is a raw camera file rather than a JPEG, and which format.
+// Only the first bytes are needed; with less data a DNG is reported as plain TIFF.
+func detectRaw(data []byte) (string, bool) {
+	if bytes.HasPrefix(data, []byte(rafHeader)) {
+		return RawFormatRAF, true
+	}
+	for _, m := range rawMagics {
+		if !bytes.HasPrefix(data, []byte(m.magic)) {
+			continue
+		}
+		if m.format != RawFormatTIFF {
+			return m.format, true
+		}
+		if len(data) >= 10 && string(data[8:10]) == "CR" {
+			return RawFormatCR2, true
+		}
+		if len(data) >= 8 {
+			if ifd, _, ok := readTIFFDirectory(data, m.order, int(m.order.Uint32(data[4:8]))); ok {
+				if _, found := ifd[tiffDNGVersionTag]; found {
+					return RawFormatDNG, true
+				}
+			}
+		}
+		return RawFormatTIFF, true
+	}
+	return "", false
+}
+
+// findRawPreview returns the largest baseline or progressive JPEG embedded in a raw file
+func findRawPreview(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte(rafHeader)) {
+		if len(data) < rafPreviewPointerOffset+8 {
+			return nil
+		}
+		offset := binary.BigEndian.Uint32(data[rafPreviewPointerOffset:])
+		length := binary.BigEndian.Uint32(data[rafPreviewPointerOffset+4:])
+		return previewAt(data, offset, length)
+	}
+
+	var order binary.ByteOrder
+	for _, m := range rawMagics {
+		if bytes.HasPrefix(data, []byte(m.magic)) {
+			order = m.order
+			break
+		}
+	}
+	if order == nil || len(data) < 8 {
+		return nil
+	}
+
+	w := &rawPreviewWalker{data: data, order: order, visited: map[int]bool{}}
+	w.walk(int(order.Uint32(data[4:8])), 1)
+	return w.best
+}
+
+// rawPreviewWalker follows the IFDs of a TIFF-based raw file looking for JPEG previews
+type rawPreviewWalker struct {
+	data    []byte
+	order   binary.ByteOrder
+	visited map[int]bool
+	best    []byte
+}
+
+// walk visits the IFD chain starting at pos and the SubIFDs it points to
+func (w *rawPreviewWalker) walk(pos, depth int) {
+	// The IFD chain and SubIFDs are followed with the same bounds as EXIF
+	for pos != 0 && depth <= DefaultMaxIFDDepth && !w.visited[pos] {
+		w.visited[pos] = true
+		ifd, next, ok := readTIFFDirectory(w.data, w.order, pos)
+		if !ok {
+			return
+		}
+		w.considerIFD(ifd)
+		if subIFDs, found := ifd[exifSubIFDsTag]; found {
+			for _, sub := range tiffLongs(w.data, w.order, subIFDs) {
+				w.walk(int(sub), depth+1)
+			}
+		}
+		pos = int(next)
+		depth++
+	}
+}
+
+// considerIFD keeps the thumbnail or JPEG-compressed strip of ifd if it is the largest so far
+func (w *rawPreviewWalker) considerIFD(ifd map[uint16]tiffEntry) {
+	if offset, found := ifd[exifThumbnailOffsetTag]; found {
+		if length, found := ifd[exifThumbnailLengthTag]; found {
+			w.consider(offset.Value, length.Value)
+		}
+	}
+	if compression, found := ifd[tiffCompressionTag]; found &&
+		(compression.Value == tiffCompressionJPEG || compression.Value == tiffCompressionJPEGNew) {
+		offset, hasOffset := ifd[exifStripOffsetTag]
+		length, hasLength := ifd[tiffStripByteCountsTag]
+		if hasOffset && hasLength && offset.Count == 1 && length.Count == 1 {
+			w.consider(offset.Value, length.Value)
+		}
+	}
+}
+
+// consider keeps the preview at offset if it is the largest so far
+func (w *rawPreviewWalker) consider(offset, length uint32) {
+	if preview := previewAt(w.data, offset, length); len(preview) > len(w.best) {
+		w.best = preview
+	}
+}
+
+// previewAt returns data[offset:offset+length] if it holds a JPEG with a baseline or
+// progressive frame. Lossless JPEG raw data (SOF3) and tiles are not previews.
+func previewAt(data []byte, offset, length uint32) []byte {
+	end := uint64(offset) + uint64(length)
+	if length < 4 || end > uint64(len(data)) {
+		return nil
+	}
+	preview := data[offset:end]
+	if preview[0] != 0xFF || preview[1] != 0xD8 {
+		return nil
+	}
+	switch previewFrameMarker(preview) {
+	case 0xC0, 0xC1, 0xC2:
+		return preview
+	}
+	return nil
+}
+
+// previewFrameMarker walks the marker segments of a JPEG and returns its first SOFn marker, or 0
+func previewFrameMarker(jpegData []byte) byte {
+	pos := 2
+	for pos+4 <= len(jpegData) {
+		if jpegData[pos] != 0xFF {
+			return 0
+		}
+		marker := jpegData[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if isSOFMarker(marker) {
+			return marker
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return 0
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(jpegData[pos+2:]))
+	}
+	return 0
+}
+
+// readTIFFDirectory reads the IFD at pos of a TIFF file, keyed by tag
+func readTIFFDirectory(data []byte, order binary.ByteOrder, pos int) (map[uint16]tiffEntry, uint32, bool) {
+	if pos < 8 || pos+2 > len(data) {
+		return nil, 0, false
+	}
+	count := int(order.Uint16(data[pos:]))
+	if count > DefaultMaxIFDEntries || pos+2+count*12+4 > len(data) {
+		return nil, 0, false
+	}
+	entries := make(map[uint16]tiffEntry, count)
+	for i := 0; i < count; i++ {
+		p := pos + 2 + i*12
+		entry := tiffEntry{Type: order.Uint16(data[p+2:]), Count: order.Uint32(data[p+4:])}
+		if entry.Type == 3 && entry.Count == 1 {
+			entry.Value = uint32(order.Uint16(data[p+8:]))
+		} else {
+			entry.Value = order.Uint32(data[p+8:])
+		}
+		entries[order.Uint16(data[p:])] = entry
+	}
+	return entries, order.Uint32(data[pos+2+count*12:]), true
+}
+
+// tiffLongs returns the LONG or IFD values of an entry, which are inline when there is only one
+func tiffLongs(data []byte, order binary.ByteOrder, entry tiffEntry) []uint32 {
+	if entry.Type != 4 && entry.Type != 13 {
+		return nil
+	}
+	if entry.Count == 1 {
+		return []uint32{entry.Value}
+	}
+	if entry.Count > DefaultMaxIFDEntries || uint64(entry.Value)+uint64(entry.Count)*4 > uint64(len(data)) {
+		return nil
+	}
+	values := make([]uint32, entry.Count)
+	for i := range values {
+		values[i] = order.Uint32(data[int(entry.Value)+i*4:])
+	}
+	return values
+}
@@ -0,0 +1,156 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTestTIFF builds a little-endian TIFF whose IFD0 holds a lossless JPEG main image and
+// whose SubIFD holds preview as a JPEG strip, the layout DNG files use
+func buildTestTIFF(t *testing.T, dng bool, preview []byte) []byte {
+	t.Helper()
+	order := binary.LittleEndian
+	lossless := []byte{0xFF, 0xD8, 0xFF, 0xC3, 0x00, 0x0B, 8, 0, 1, 0, 1, 1, 1, 0x11, 0, 0xFF, 0xD9}
+
+	type entry struct {
+		tag, typ    uint16
+		count, data uint32
+	}
+	ifd := func(b []byte, pos int, entries []entry) []byte {
+		order.PutUint16(b[pos:], uint16(len(entries)))
+		for i, e := range entries {
+			p := pos + 2 + i*12
+			order.PutUint16(b[p:], e.tag)
+			order.PutUint16(b[p+2:], e.typ)
+			order.PutUint32(b[p+4:], e.count)
+			order.PutUint32(b[p+8:], e.data)
+		}
+		return b
+	}
+
+	const ifd0Pos, subPos, dataPos = 8, 128, 256
+	losslessPos := uint32(dataPos)
+	previewPos := losslessPos + uint32(len(lossless))
+	b := make([]byte, int(previewPos)+len(preview))
+	copy(b, "II*\x00")
+	order.PutUint32(b[4:], ifd0Pos)
+
+	ifd0 := []entry{
+		{0x0103, 3, 1, tiffCompressionJPEGNew},
+		{exifStripOffsetTag, 4, 1, losslessPos},
+		{tiffStripByteCountsTag, 4, 1, uint32(len(lossless))},
+		{exifSubIFDsTag, 4, 1, subPos},
+	}
+	if dng {
+		ifd0 = append(ifd0, entry{tiffDNGVersionTag, 1, 4, 0x00000401})
+	}
+	ifd(b, ifd0Pos, ifd0)
+	ifd(b, subPos, []entry{
+		{0x0103, 3, 1, tiffCompressionJPEGNew},
+		{exifStripOffsetTag, 4, 1, previewPos},
+		{tiffStripByteCountsTag, 4, 1, uint32(len(preview))},
+	})
+	copy(b[losslessPos:], lossless)
+	copy(b[previewPos:], preview)
+	return b
+}
+
+func TestDetectRaw(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"DNG", buildTestTIFF(t, true, jpegData), RawFormatDNG},
+		{"Plain TIFF", buildTestTIFF(t, false, jpegData), RawFormatTIFF},
+		{"DNG header only", buildTestTIFF(t, true, jpegData)[:16], RawFormatTIFF},
+		{"CR2", []byte("II*\x00\x10\x00\x00\x00CR\x02\x00"), RawFormatCR2},
+		{"ORF", []byte("IIRO\x08\x00\x00\x00"), RawFormatORF},
+		{"RW2", []byte("IIU\x00\x08\x00\x00\x00"), RawFormatRW2},
+		{"RAF", []byte(rafHeader + "0201"), RawFormatRAF},
+		{"JPEG", jpegData, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, ok := detectRaw(tc.data)
+			if format != tc.format || ok != (tc.format != "") {
+				t.Errorf("Expected %q, got %q (%v)", tc.format, format, ok)
+			}
+		})
+	}
+}
+
+func TestStripRawRefused(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	dng := buildTestTIFF(t, true, jpegData)
+
+	var rawErr *RawImageError
+	if _, _, err := Strip(dng); !errors.As(err, &rawErr) || rawErr.Format != RawFormatDNG {
+		t.Errorf("Expected *RawImageError for DNG from Strip, got %v", err)
+	}
+	if _, err := Inspect(dng); !errors.As(err, &rawErr) {
+		t.Errorf("Expected *RawImageError from Inspect, got %v", err)
+	}
+	if _, err := StripStream(bytes.NewReader(dng), new(bytes.Buffer)); !errors.As(err, &rawErr) {
+		t.Errorf("Expected *RawImageError from StripStream, got %v", err)
+	}
+
+	// A raw file without a usable preview is refused even with WithEmbeddedPreview
+	if _, _, err := Strip(buildTestTIFF(t, true, nil), WithEmbeddedPreview()); !errors.As(err, &rawErr) {
+		t.Errorf("Expected *RawImageError without a preview, got %v", err)
+	}
+}
+
+func TestWithEmbeddedPreview(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	raf := make([]byte, 128)
+	copy(raf, rafHeader)
+	binary.BigEndian.PutUint32(raf[rafPreviewPointerOffset:], uint32(len(raf)))
+	binary.BigEndian.PutUint32(raf[rafPreviewPointerOffset+4:], uint32(len(jpegData)))
+	raf = append(raf, jpegData...)
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"DNG SubIFD preview", buildTestTIFF(t, true, jpegData), RawFormatDNG},
+		{"RAF header preview", raf, RawFormatRAF},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, result, err := Strip(tc.data, WithEmbeddedPreview())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Error("Expected the cleaned preview to match stripping the JPEG directly")
+			}
+			if result.EmbeddedPreview != tc.format || result.OriginalSize != int64(len(jpegData)) {
+				t.Errorf("Unexpected result: preview %q, original size %d", result.EmbeddedPreview, result.OriginalSize)
+			}
+		})
+	}
+}
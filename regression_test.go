@@ -0,0 +1,127 @@
+package jpegmetawebstrip
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ideamans/go-jpeg-meta-web-strip/datacreator"
+)
+
+var regressionCorpus = flag.String("regression", "", "sample set directory fetched with go run datacreator/cmd/main.go -corpus, checked image by image")
+
+// regressionPolicies are the option sets every sample image is stripped with
+var regressionPolicies = []*Policy{
+	NewPolicy("default"),
+	PresetPrivacy,
+	PresetAggressive,
+	PresetConservative,
+	NewPolicy("canonical", WithCanonicalExif(), WithKeepCopyright()),
+}
+
+// regressionTally counts the outcomes of one producer's images
+type regressionTally struct {
+	images, passed, rejected, failed int
+}
+
+// TestCorpusRegression strips every image of a fetched public sample set, such as the
+// exif-samples JPEGs, with each regression policy and checks for panics, invalid output
+// and changed pixels. Inputs Strip refuses with an error count as rejected, not failed.
+// A pass/fail matrix by producer is logged; run with -v to see it.
+func TestCorpusRegression(t *testing.T) {
+	if *regressionCorpus == "" {
+		t.Skip("no sample set; run with -regression <dir>")
+	}
+	manifest, err := datacreator.ReadCorpusManifest(*regressionCorpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matrix := make(map[string]*regressionTally)
+	for _, image := range manifest.Images {
+		data, err := os.ReadFile(filepath.Join(*regressionCorpus, image.Name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", image.Name, err)
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != image.SHA256 {
+			t.Fatalf("%s does not match the manifest; fetch the sample set again", image.Name)
+		}
+
+		producer := "unknown"
+		if sl, err := parseSegmentList(data); err == nil {
+			if detected := detectProducer(sl.Segments()); detected != "" {
+				producer = detected
+			}
+		}
+		tally := matrix[producer]
+		if tally == nil {
+			tally = &regressionTally{}
+			matrix[producer] = tally
+		}
+		tally.images++
+
+		for _, policy := range regressionPolicies {
+			rejected, err := checkRegression(data, policy.Options)
+			switch {
+			case err != nil:
+				tally.failed++
+				t.Errorf("%s (%s) with policy %q: %v", image.Name, producer, policy.Name, err)
+			case rejected:
+				tally.rejected++
+			default:
+				tally.passed++
+			}
+		}
+	}
+
+	producers := make([]string, 0, len(matrix))
+	for producer := range matrix {
+		producers = append(producers, producer)
+	}
+	sort.Strings(producers)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-40s %6s %6s %8s %6s\n", "producer", "images", "passed", "rejected", "failed")
+	for _, producer := range producers {
+		tally := matrix[producer]
+		fmt.Fprintf(&b, "%-40.40s %6d %6d %8d %6d\n", producer, tally.images, tally.passed, tally.rejected, tally.failed)
+	}
+	t.Logf("%d images x %d policies\n%s", len(manifest.Images), len(regressionPolicies), b.String())
+}
+
+// checkRegression strips data and verifies the output. It reports inputs that Strip
+// refuses as rejected and returns an error for panics, invalid output or changed pixels.
+func checkRegression(data []byte, opts []Option) (rejected bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	cleaned, _, err := Strip(data, opts...)
+	if err != nil {
+		return true, nil
+	}
+	if _, err := validateOutput(cleaned); err != nil {
+		return false, err
+	}
+
+	// Images the standard decoder cannot read (e.g. arithmetic coding) are checked structurally only
+	originalChecksum, err := getJPEGPixelChecksum(data)
+	if err != nil {
+		return false, nil
+	}
+	cleanedChecksum, err := getJPEGPixelChecksum(cleaned)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode output: %w", err)
+	}
+	if originalChecksum != cleanedChecksum {
+		return false, fmt.Errorf("pixel data checksum mismatch")
+	}
+	return false, nil
+}
@@ -0,0 +1,88 @@
+package jpegmetawebstrip
+
+import "time"
+
+// ResourceStats records the phase durations and memory use of a single Strip call.
+// It is only populated when WithResourceStats is used.
+type ResourceStats struct {
+	// ParseDuration is the time spent parsing the JPEG structure
+	ParseDuration time.Duration
+	// ProcessDuration is the time spent filtering and cleaning segments
+	ProcessDuration time.Duration
+	// WriteDuration is the time spent serializing and validating the output
+	WriteDuration time.Duration
+	// TotalDuration is the wall-clock time of the whole call
+	TotalDuration time.Duration
+	// AllocatedBytes is the temporary memory the call needed for its data: the payloads of
+	// rebuilt segments plus the output buffer. Kept segments and an unchanged input share
+	// the input and cost nothing. It is counted from the data rather than read from the
+	// runtime, so it is the same on every run and unaffected by concurrent calls.
+	AllocatedBytes int64
+}
+
+// resourceTracker measures phases of a Strip call; a nil tracker records nothing
+type resourceTracker struct {
+	stats ResourceStats
+	start time.Time
+	last  time.Time
+}
+
+// newResourceTracker returns a running tracker, or nil when tracking is disabled
+func newResourceTracker(enabled bool) *resourceTracker {
+	if !enabled {
+		return nil
+	}
+	now := time.Now()
+	return &resourceTracker{start: now, last: now}
+}
+
+// lap returns the time elapsed since the previous lap
+func (t *resourceTracker) lap() time.Duration {
+	if t == nil {
+		return 0
+	}
+	now := time.Now()
+	d := now.Sub(t.last)
+	t.last = now
+	return d
+}
+
+// parsed records the end of the parse phase
+func (t *resourceTracker) parsed() {
+	if t != nil {
+		t.stats.ParseDuration = t.lap()
+	}
+}
+
+// processed records the end of the segment processing phase and the payload bytes of the
+// cleaned segments that do not share their data with the original ones
+func (t *resourceTracker) processed(original, cleaned []*jpegSegment) {
+	if t == nil {
+		return
+	}
+	t.stats.ProcessDuration = t.lap()
+	shared := make(map[*byte]bool, len(original))
+	for _, segment := range original {
+		if len(segment.Data) > 0 {
+			shared[&segment.Data[0]] = true
+		}
+	}
+	for _, segment := range cleaned {
+		if len(segment.Data) > 0 && !shared[&segment.Data[0]] {
+			t.stats.AllocatedBytes += int64(len(segment.Data))
+		}
+	}
+}
+
+// finish records the write phase and the size of the output buffer, 0 when the input is
+// returned, and returns the collected stats
+func (t *resourceTracker) finish(written int) *ResourceStats {
+	if t == nil {
+		return nil
+	}
+	t.stats.AllocatedBytes += int64(written)
+	t.stats.WriteDuration = t.lap()
+	t.stats.TotalDuration = time.Since(t.start)
+	stats := t.stats
+	return &stats
+}
@@ -0,0 +1,69 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithResourceStats(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Resources != nil {
+		t.Error("Expected no resource stats without WithResourceStats")
+	}
+
+	_, result, err = Strip(jpegData, WithResourceStats())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	stats := result.Resources
+	if stats == nil {
+		t.Fatal("Expected resource stats to be recorded")
+	}
+	if stats.TotalDuration < stats.ParseDuration+stats.ProcessDuration {
+		t.Errorf("Total duration %v is shorter than its phases", stats.TotalDuration)
+	}
+	t.Logf("Parse=%v Process=%v Write=%v Total=%v",
+		stats.ParseDuration, stats.ProcessDuration, stats.WriteDuration, stats.TotalDuration)
+}
+
+func TestResourceStatsAllocatedBytes(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	cleaned, result, err := Strip(jpegData, WithResourceStats())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	// The rebuilt EXIF comes on top of the output buffer
+	allocated := result.Resources.AllocatedBytes
+	if allocated <= int64(len(cleaned)) || allocated >= int64(len(cleaned)+len(jpegData)) {
+		t.Errorf("AllocatedBytes = %d, want the output size %d plus the rebuilt segments", allocated, len(cleaned))
+	}
+	_, again, err := Strip(jpegData, WithResourceStats())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if again.Resources.AllocatedBytes != allocated {
+		t.Errorf("AllocatedBytes differs between runs: %d and %d", allocated, again.Resources.AllocatedBytes)
+	}
+
+	// Returning the input unchanged allocates nothing
+	_, unchanged, err := Strip(cleaned, WithResourceStats())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !unchanged.Unchanged || unchanged.Resources.AllocatedBytes != 0 {
+		t.Errorf("Expected an unchanged result without allocations, got %+v", unchanged.Resources)
+	}
+}
@@ -0,0 +1,37 @@
+package jpegmetawebstrip
+
+import "reflect"
+
+// Add returns a Result whose byte counts (every Removed category, Total and OriginalSize)
+// are the sums of r and other, for aggregating the results of many images.
+// Warnings, comments, flags and resource stats are not carried over. Nil results count as zero.
+func (r *Result) Add(other *Result) *Result {
+	return combineResults(r, other, 1)
+}
+
+// Sub returns a Result whose byte counts are r minus other, e.g. to compare two policies
+// or two library versions on the same image. Nil results count as zero.
+func (r *Result) Sub(other *Result) *Result {
+	return combineResults(r, other, -1)
+}
+
+// combineResults adds sign*b to a field by field
+func combineResults(a, b *Result, sign int64) *Result {
+	sum := &Result{}
+	if a == nil {
+		a = &Result{}
+	}
+	if b == nil {
+		b = &Result{}
+	}
+
+	// Categories are walked by reflection so new ones are covered without touching this code
+	out := reflect.ValueOf(&sum.Removed).Elem()
+	left, right := reflect.ValueOf(a.Removed), reflect.ValueOf(b.Removed)
+	for i := 0; i < out.NumField(); i++ {
+		out.Field(i).SetInt(left.Field(i).Int() + sign*right.Field(i).Int())
+	}
+	sum.Total = a.Total + sign*b.Total
+	sum.OriginalSize = a.OriginalSize + sign*b.OriginalSize
+	return sum
+}
@@ -0,0 +1,48 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResultArithmetic(t *testing.T) {
+	results := make([]*Result, 0)
+	for _, name := range []string{"with_comprehensive_mixed.jpg", "with_xmp.jpg"} {
+		jpegData, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		_, result, err := Strip(jpegData)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		results = append(results, result)
+	}
+	a, b := results[0], results[1]
+
+	sum := a.Add(b)
+	if sum.Total != a.Total+b.Total || sum.OriginalSize != a.OriginalSize+b.OriginalSize {
+		t.Errorf("unexpected sum totals: %+v", sum)
+	}
+	if sum.Removed.XMP != a.Removed.XMP+b.Removed.XMP || sum.Removed.ExifGPS != a.Removed.ExifGPS {
+		t.Errorf("unexpected sum categories: %+v", sum.Removed)
+	}
+
+	// Subtracting what was added restores every byte count
+	back := sum.Sub(b)
+	if !reflect.DeepEqual(back.Removed, a.Removed) || back.Total != a.Total || back.OriginalSize != a.OriginalSize {
+		t.Errorf("Add then Sub did not round-trip: %+v vs %+v", back, a)
+	}
+
+	// Nil operands count as zero, so aggregation can start from a nil Result
+	var total *Result
+	total = total.Add(a)
+	if !reflect.DeepEqual(total.Removed, a.Removed) || total.Total != a.Total {
+		t.Errorf("nil.Add(a) = %+v, want byte counts of %+v", total, a)
+	}
+	if delta := a.Sub(nil); delta.Total != a.Total {
+		t.Errorf("a.Sub(nil).Total = %d, want %d", delta.Total, a.Total)
+	}
+}
@@ -0,0 +1,281 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// JPEG marker IDs, the second byte of each 0xFF-prefixed marker
+const (
+	markerSOF0  = 0xC0
+	markerSOF1  = 0xC1
+	markerSOF2  = 0xC2
+	markerDHT   = 0xC4
+	markerJPG   = 0xC8
+	markerDAC   = 0xCC
+	markerSOF15 = 0xCF
+	markerSOI   = 0xD8
+	markerEOI   = 0xD9
+	markerSOS   = 0xDA
+	markerDQT   = 0xDB
+	markerAPP0  = 0xE0
+	markerAPP1  = 0xE1
+	markerAPP2  = 0xE2
+	markerAPP4  = 0xE4
+	markerAPP5  = 0xE5
+	markerAPP6  = 0xE6
+	markerAPP13 = 0xED
+	markerAPP14 = 0xEE
+	markerAPP15 = 0xEF
+	markerCOM   = 0xFE
+)
+
+// markerNames names the markers reported by describeSegments and DumpStructure.
+// Markers missing here are shown by their hex ID.
+var markerNames = map[byte]string{
+	markerSOI: "SOI", markerEOI: "EOI", markerSOS: "SOS", markerDQT: "DQT",
+	markerDHT: "DHT", markerJPG: "JPG", markerDAC: "DAC", markerCOM: "COM",
+	0xC0: "SOF0", 0xC1: "SOF1", 0xC2: "SOF2", 0xC3: "SOF3",
+	0xC5: "SOF5", 0xC6: "SOF6", 0xC7: "SOF7",
+	0xC9: "SOF9", 0xCA: "SOF10", 0xCB: "SOF11",
+	0xCD: "SOF13", 0xCE: "SOF14", 0xCF: "SOF15",
+	0xE0: "APP0", 0xE1: "APP1", 0xE2: "APP2", 0xE3: "APP3",
+	0xE4: "APP4", 0xE5: "APP5", 0xE6: "APP6", 0xE7: "APP7",
+	0xE8: "APP8", 0xEA: "APP10", 0xEC: "APP12", 0xED: "APP13",
+	0xEE: "APP14", 0xEF: "APP15",
+}
+
+// jpegSegment is one marker segment of a JPEG. Entropy-coded scan data is held in a
+// segment with MarkerId 0; it starts with the SOS header, which the SOS segment itself
+// leaves empty, and runs up to the final EOI.
+type jpegSegment struct {
+	MarkerId   byte
+	MarkerName string
+	Offset     int
+	Data       []byte
+}
+
+// segmentList is the ordered segments of a JPEG
+type segmentList struct {
+	segments []*jpegSegment
+	// end is the number of input bytes the segments cover; bytes after it, such as a
+	// trailer after EOI, were ignored when scanning
+	end int
+}
+
+// newSegmentList returns a segment list holding segments
+func newSegmentList(segments []*jpegSegment) *segmentList {
+	return &segmentList{segments: segments}
+}
+
+// Segments returns the segments in file order
+func (sl *segmentList) Segments() []*jpegSegment {
+	return sl.segments
+}
+
+// hasNoLength reports whether the marker is written without a length field
+func hasNoLength(marker byte) bool {
+	return marker == markerSOI || marker == markerEOI || marker == markerSOS || isStandaloneMarker(marker)
+}
+
+// Write encodes the segments to w in a single write
+func (sl *segmentList) Write(w io.Writer) error {
+	size := 0
+	for _, segment := range sl.segments {
+		size += 4 + len(segment.Data)
+	}
+
+	b := make([]byte, 0, size)
+	for _, segment := range sl.segments {
+		switch {
+		case segment.MarkerId == 0:
+			b = append(b, segment.Data...)
+			continue
+		case hasNoLength(segment.MarkerId):
+			b = append(b, 0xFF, segment.MarkerId)
+			continue
+		case len(segment.Data) > MaxSegmentSize:
+			return fmt.Errorf("%w: segment 0x%02X of %d bytes (max %d)", ErrLimitExceeded, segment.MarkerId, len(segment.Data), MaxSegmentSize)
+		}
+		b = append(b, 0xFF, segment.MarkerId)
+		b = binary.BigEndian.AppendUint16(b, uint16(2+len(segment.Data)))
+		b = append(b, segment.Data...)
+	}
+
+	_, err := w.Write(b)
+	return err
+}
+
+// scanSegments indexes the segment boundaries of a JPEG. Segment payloads and the scan
+// data are subslices of data, capped so that appending to them never writes into it.
+// Empty APPn and COM segments are skipped, and anything after the EOI that ends the scan
+// data is ignored.
+func scanSegments(data []byte) (*segmentList, error) {
+	if len(data) < 3 || data[0] != 0xFF || data[1] != markerSOI || data[2] != 0xFF {
+		return nil, fmt.Errorf("missing SOI marker")
+	}
+
+	var segments []*jpegSegment
+	pos := 0
+	for pos < len(data) {
+		marker, offset, err := readMarker(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		pos = offset + 2
+
+		var payload []byte
+		if !hasNoLength(marker) {
+			if payload, pos, err = readSegmentPayload(data, pos, marker, offset); err != nil {
+				return nil, err
+			}
+			if len(payload) == 0 {
+				// Some encoders emit APPn and COM with length 2; they carry nothing and are dropped
+				continue
+			}
+		}
+		segments = append(segments, &jpegSegment{MarkerId: marker, MarkerName: markerNames[marker], Offset: offset, Data: payload})
+		if len(segments) > MaxSegments {
+			return nil, fmt.Errorf("%w: more than %d segments", ErrLimitExceeded, MaxSegments)
+		}
+
+		switch marker {
+		case markerEOI:
+			return &segmentList{segments: segments, end: pos}, nil
+		case markerSOS:
+			// The scan data is kept as-is up to the EOI, including later scans of a
+			// progressive image and the tables between them
+			end, err := scanDataEnd(data, pos)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, &jpegSegment{Offset: pos, Data: data[pos:end:end]})
+			pos = end
+		}
+	}
+	return &segmentList{segments: segments, end: pos}, nil
+}
+
+// readMarker reads the marker at pos, which may be preceded by any number of 0xFF fill
+// bytes, and returns it with the offset of its own 0xFF prefix
+func readMarker(data []byte, pos int) (byte, int, error) {
+	if data[pos] != 0xFF {
+		return 0, 0, fmt.Errorf("expected marker at offset %d, found 0x%02X", pos, data[pos])
+	}
+	start := pos
+	for pos < len(data) && data[pos] == 0xFF {
+		pos++
+	}
+	if pos == len(data) {
+		return 0, 0, fmt.Errorf("truncated marker at offset %d", start)
+	}
+	if data[pos] == 0 {
+		return 0, 0, fmt.Errorf("invalid marker 0x00 at offset %d", pos-1)
+	}
+	return data[pos], pos - 1, nil
+}
+
+// readSegmentPayload reads the length field at pos of the segment whose marker is at offset
+// and returns its payload and the offset after it. Only APPn and COM may be empty; an empty
+// DQT, DHT or SOF is malformed.
+func readSegmentPayload(data []byte, pos int, marker byte, offset int) ([]byte, int, error) {
+	if pos+2 > len(data) {
+		return nil, 0, fmt.Errorf("truncated length of segment 0x%02X at offset %d", marker, offset)
+	}
+	size := int(binary.BigEndian.Uint16(data[pos:]))
+	if size == 2 && isEmptyRemovable(marker) {
+		return nil, pos + 2, nil
+	}
+	if size <= 2 {
+		return nil, 0, fmt.Errorf("invalid length %d of segment 0x%02X at offset %d", size, marker, offset)
+	}
+	end := pos + size
+	if end > len(data) {
+		return nil, 0, fmt.Errorf("truncated segment 0x%02X at offset %d", marker, offset)
+	}
+	return data[pos+2 : end : end], end, nil
+}
+
+// scanDataEnd returns the offset of the EOI marker ending the scan data whose SOS header
+// length starts at pos
+func scanDataEnd(data []byte, pos int) (int, error) {
+	if pos+2 > len(data) {
+		return 0, fmt.Errorf("truncated SOS header at offset %d", pos)
+	}
+	start := pos + int(binary.BigEndian.Uint16(data[pos:]))
+	if start > len(data) {
+		return 0, fmt.Errorf("truncated SOS header at offset %d", pos)
+	}
+	var walker scanWalker
+	n, done := walker.walk(data[start:])
+	if !done {
+		return 0, fmt.Errorf("scan data at offset %d is not terminated by EOI", pos)
+	}
+	return start + n - 2, nil
+}
+
+// scanWalker states
+const (
+	walkEntropy    = iota // entropy-coded data
+	walkMarker            // after 0xFF
+	walkLengthHigh        // after a marker that has a length field
+	walkLengthLow         // after the high byte of the length
+	walkPayload           // inside a marker segment payload
+)
+
+// scanWalker finds the EOI ending the scan data, starting after the first SOS header. In
+// entropy-coded data 0xFF is followed by 0x00, RSTn or a marker, but the DHT, DQT, SOS, DRI,
+// COM and APPn segments between the scans of a progressive image are not entropy-coded and
+// their payloads may hold any bytes, so they are skipped by their length rather than
+// searched for 0xFF 0xD9. It is fed chunk by chunk so that streams can be walked as well.
+type scanWalker struct {
+	state  int
+	length int // high length byte, then the payload bytes left
+}
+
+// walk consumes chunk and returns the number of bytes up to and including the EOI marker,
+// or len(chunk) and false when the EOI is not in it
+func (w *scanWalker) walk(chunk []byte) (int, bool) {
+	for i := 0; i < len(chunk); i++ {
+		switch w.state {
+		case walkEntropy:
+			next := bytes.IndexByte(chunk[i:], 0xFF)
+			if next < 0 {
+				return len(chunk), false
+			}
+			i += next
+			w.state = walkMarker
+		case walkMarker:
+			switch b := chunk[i]; {
+			case b == 0xFF: // Fill byte
+			case b == markerEOI:
+				w.state = walkEntropy
+				return i + 1, true
+			case b == 0x00, isStandaloneMarker(b):
+				w.state = walkEntropy
+			default:
+				w.state = walkLengthHigh
+			}
+		case walkLengthHigh:
+			w.length = int(chunk[i])
+			w.state = walkLengthLow
+		case walkLengthLow:
+			// The length counts its own two bytes; a malformed one is treated as empty
+			w.length = max((w.length<<8|int(chunk[i]))-2, 0)
+			w.state = walkPayload
+			if w.length == 0 {
+				w.state = walkEntropy
+			}
+		case walkPayload:
+			skip := min(w.length, len(chunk)-i)
+			w.length -= skip
+			i += skip - 1
+			if w.length == 0 {
+				w.state = walkEntropy
+			}
+		}
+	}
+	return len(chunk), false
+}
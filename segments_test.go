@@ -0,0 +1,156 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanSegmentsRoundTrip(t *testing.T) {
+
+	files, _ := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		sl, err := scanSegments(data)
+		if err != nil {
+			t.Fatalf("%s: scanSegments failed: %v", file, err)
+		}
+		b := new(bytes.Buffer)
+		if err := sl.Write(b); err != nil {
+			t.Fatalf("%s: Write failed: %v", file, err)
+		}
+		if !bytes.Equal(b.Bytes(), data) {
+			t.Errorf("%s: writing the scanned segments changed the bytes", filepath.Base(file))
+		}
+	}
+}
+
+func TestScanSegmentsLayout(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	segments := parseTestSegments(t, jpegData)
+	first, last := segments[0], segments[len(segments)-1]
+	if first.MarkerId != markerSOI || last.MarkerId != markerEOI || last.Offset != len(jpegData)-2 {
+		t.Fatalf("Expected SOI ... EOI ending at the input end, got %s", describeSegments(segments))
+	}
+	scan := segments[len(segments)-2]
+	sos := segments[len(segments)-3]
+	if scan.MarkerId != 0 || sos.MarkerId != markerSOS || len(sos.Data) != 0 {
+		t.Fatalf("Expected SOS followed by scan data, got %s", describeSegments(segments))
+	}
+	if scan.Offset != sos.Offset+2 || !bytes.Equal(scan.Data, jpegData[scan.Offset:last.Offset]) {
+		t.Errorf("Scan data does not cover the bytes between SOS and EOI")
+	}
+	for _, segment := range segments[1 : len(segments)-3] {
+		if !bytes.Equal(jpegData[segment.Offset+4:segment.Offset+4+len(segment.Data)], segment.Data) {
+			t.Errorf("Segment %s at %d does not match its offset", segment.MarkerName, segment.Offset)
+		}
+	}
+}
+
+func TestScanSegmentsPayloadCapacity(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	data := append([]byte{}, jpegData...)
+	for _, segment := range parseTestSegments(t, data) {
+		_ = append(segment.Data, 0xAA)
+	}
+	if !bytes.Equal(data, jpegData) {
+		t.Errorf("Appending to a segment payload wrote into the input")
+	}
+}
+
+func TestScanSegmentsFillBytesAndTrailer(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	data := append([]byte{0xFF, 0xD8, 0xFF}, jpegData[2:]...)
+	data = append(data, "trailer"...)
+	segments := parseTestSegments(t, data)
+	if segments[1].Offset != 3 {
+		t.Errorf("Offset after a fill byte = %d, want 3", segments[1].Offset)
+	}
+	if last := segments[len(segments)-1]; last.MarkerId != markerEOI || last.Offset != len(data)-len("trailer")-2 {
+		t.Errorf("Expected the trailer after EOI to be ignored, got %s", describeSegments(segments))
+	}
+}
+
+func TestScanSegmentsTablesBetweenScans(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	// A second scan whose DQT and SOS header hold the bytes 0xFF 0xD9
+	table := append([]byte{0x00}, bytes.Repeat([]byte{0xFF, 0xD9}, 32)...)
+	second := append([]byte{0xFF, markerDQT, 0x00, byte(2 + len(table))}, table...)
+	second = append(second, 0xFF, markerSOS, 0x00, 0x08, 0x01, 0x01, 0x00, 0xFF, 0xD9, 0x00)
+	second = append(second, 0x12, 0xFF, 0x00, 0x34, 0xFF, 0xD0, 0x56)
+	eoi := len(jpegData) - 2
+	data := append(append(bytes.Clone(jpegData[:eoi]), second...), 0xFF, markerEOI)
+
+	segments := parseTestSegments(t, data)
+	if last := segments[len(segments)-1]; last.MarkerId != markerEOI || last.Offset != len(data)-2 {
+		t.Fatalf("Expected the scan data to run to the final EOI, got %s ending at %d", describeSegments(segments), last.Offset)
+	}
+	if end := findEOI(data, int64(segments[len(segments)-2].Offset)); end != int64(len(data)) {
+		t.Errorf("findEOI = %d, want %d", end, len(data))
+	}
+	out := new(bytes.Buffer)
+	if _, err := StripStream(bytes.NewReader(append(bytes.Clone(data), "trailer"...)), out, WithTrimTrailer()); err != nil {
+		t.Fatalf("StripStream failed: %v", err)
+	}
+	if !bytes.HasSuffix(out.Bytes(), data[eoi:]) {
+		t.Error("Expected StripStream to copy the second scan")
+	}
+}
+
+func TestScanSegmentsMalformed(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	sosPos := bytes.Index(jpegData, []byte{0xFF, markerSOS})
+	tests := map[string][]byte{
+		"not a JPEG":        []byte("GIF89a"),
+		"truncated header":  jpegData[:sosPos-3],
+		"unterminated scan": jpegData[:len(jpegData)-2],
+		"empty segment":     append([]byte{0xFF, 0xD8, 0xFF, 0xDB, 0x00, 0x02}, jpegData[2:]...),
+		"garbage in header": append([]byte{0xFF, 0xD8, 0x00}, jpegData[2:]...),
+	}
+	for name, data := range tests {
+		if _, err := scanSegments(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestScanSegmentsEmptyAppAndComment(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	data := append([]byte{0xFF, 0xD8, 0xFF, markerAPP1, 0x00, 0x02, 0xFF, markerCOM, 0x00, 0x02}, jpegData[2:]...)
+	segments := parseTestSegments(t, data)
+	want := parseTestSegments(t, jpegData)
+	if len(segments) != len(want) {
+		t.Fatalf("Expected the empty APP1 and COM to be skipped, got %s", describeSegments(segments))
+	}
+	if segments[1].Offset != 10 {
+		t.Errorf("Offset after the empty segments = %d, want 10", segments[1].Offset)
+	}
+}
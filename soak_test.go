@@ -0,0 +1,130 @@
+package jpegmetawebstrip
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var soakDuration = flag.Duration("soak", 0, "loop the test corpus for this long, watching heap and goroutine growth")
+
+// soakOptions are the option sets exercised on every soak iteration
+var soakOptions = [][]Option{
+	nil,
+	{WithKeepXMPRating(), WithXMPPadding(256)},
+	{WithThumbnailMaxBytes(1 << 20), WithKeepSoftware()},
+	{WithWhitelistMode()},
+	{WithTimeout(time.Second), WithResourceStats()},
+}
+
+// soakCorpus loads the JPEG fixtures and any go fuzz corpus entries under testdata/fuzz
+func soakCorpus(t *testing.T) [][]byte {
+	t.Helper()
+	corpus := make([][]byte, 0)
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		corpus = append(corpus, data)
+	}
+
+	_ = filepath.WalkDir(filepath.Join("testdata", "fuzz"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		corpus = append(corpus, fuzzCorpusValues(data)...)
+		return nil
+	})
+	return corpus
+}
+
+// fuzzCorpusValues extracts the []byte values of a "go test fuzz v1" corpus file
+func fuzzCorpusValues(data []byte) [][]byte {
+	values := make([][]byte, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "[]byte(") || !strings.HasSuffix(line, ")") {
+			continue
+		}
+		value, err := strconv.Unquote(line[len("[]byte(") : len(line)-1])
+		if err == nil {
+			values = append(values, []byte(value))
+		}
+	}
+	return values
+}
+
+// soakSample is the live heap and goroutine count after a forced GC
+type soakSample struct {
+	heap       uint64
+	goroutines int
+}
+
+func takeSoakSample() soakSample {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return soakSample{heap: m.HeapAlloc, goroutines: runtime.NumGoroutine()}
+}
+
+// TestSoak runs only with -soak, e.g. go test -run TestSoak -soak 10m -timeout 0
+func TestSoak(t *testing.T) {
+	if *soakDuration <= 0 {
+		t.Skip("soak test disabled; enable with -soak <duration>")
+	}
+	corpus := soakCorpus(t)
+	iterate := func() {
+		for _, data := range corpus {
+			for _, opts := range soakOptions {
+				_, _, _ = Strip(data, opts...)
+			}
+			_, _ = Inspect(data)
+		}
+	}
+
+	// Warm up once so lazily initialized state is part of the baseline
+	iterate()
+	baseline := takeSoakSample()
+	t.Logf("baseline: heap=%d goroutines=%d corpus=%d", baseline.heap, baseline.goroutines, len(corpus))
+
+	deadline := time.Now().Add(*soakDuration)
+	nextReport := time.Now().Add(time.Minute)
+	iterations := 0
+	for time.Now().Before(deadline) {
+		iterate()
+		iterations++
+		if time.Now().After(nextReport) {
+			s := takeSoakSample()
+			t.Logf("after %d iterations: heap=%d goroutines=%d", iterations, s.heap, s.goroutines)
+			nextReport = time.Now().Add(time.Minute)
+		}
+	}
+
+	final := takeSoakSample()
+	t.Logf("final after %d iterations: heap=%d goroutines=%d", iterations, final.heap, final.goroutines)
+	if final.goroutines > baseline.goroutines {
+		t.Errorf("goroutines grew from %d to %d", baseline.goroutines, final.goroutines)
+	}
+	// Allow for GC noise; a leak grows with the iteration count
+	if limit := baseline.heap*2 + 8<<20; final.heap > limit {
+		t.Errorf("live heap grew from %d to %d bytes", baseline.heap, final.heap)
+	}
+}
@@ -0,0 +1,44 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+)
+
+// temMarker is the TEM standalone marker, which like RSTn carries no length
+const temMarker = 0x01
+
+// isStandaloneMarker reports whether the marker is TEM or RSTn, which have no length field
+func isStandaloneMarker(marker byte) bool {
+	return marker == temMarker || (marker >= 0xD0 && marker <= 0xD7)
+}
+
+// isEmptyRemovable reports whether a segment with this marker may be dropped when its
+// payload is empty. APPn and COM with length 2 carry nothing, but an empty DQT, DHT or SOF
+// is malformed and must still fail parsing.
+func isEmptyRemovable(marker byte) bool {
+	return marker == markerCOM || (marker >= markerAPP0 && marker <= markerAPP15)
+}
+
+// hasDroppedMarkers reports whether the header holds fill bytes, standalone markers or
+// empty APPn/COM segments, which parsing or cleaning drops without counting them as removed
+func hasDroppedMarkers(jpegData []byte) bool {
+	pos := 2
+	for pos+4 <= len(jpegData) && jpegData[pos] == 0xFF {
+		marker := jpegData[pos+1]
+		switch {
+		case marker == 0xFF, isStandaloneMarker(marker):
+			return true
+		case marker == markerSOS, marker == markerEOI:
+			return false
+		}
+		size := int(binary.BigEndian.Uint16(jpegData[pos+2:]))
+		if size == 2 && isEmptyRemovable(marker) {
+			return true
+		}
+		if size < 2 {
+			return false
+		}
+		pos += 2 + size
+	}
+	return false
+}
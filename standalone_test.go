@@ -0,0 +1,68 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStripEmptyAndStandaloneSegments(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_gps.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		segment []byte
+	}{
+		{"Empty APP1", []byte{0xFF, 0xE1, 0, 2}},
+		{"Empty APP5", []byte{0xFF, 0xE5, 0, 2}},
+		{"Empty COM", []byte{0xFF, 0xFE, 0, 2}},
+		{"TEM", []byte{0xFF, 0x01}},
+		{"RST0 between tables", []byte{0xFF, 0xD0}},
+		{"Fill bytes", []byte{0xFF, 0xFF, 0xFF, 0xFE, 0, 3, 'a'}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := append(append(append([]byte{}, jpegData[:2]...), tc.segment...), jpegData[2:]...)
+
+			output, result, err := Strip(input, WithStrictUnknown())
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if result.FellBack {
+				t.Fatalf("Unexpected fallback: %s", result.FallbackReason)
+			}
+			if !bytes.Equal(output, expected) {
+				t.Error("Expected the segment to be dropped and the rest cleaned as usual")
+			}
+
+			var streamed bytes.Buffer
+			if _, err := StripStream(bytes.NewReader(input), &streamed); err != nil {
+				t.Fatalf("StripStream failed: %v", err)
+			}
+			if !bytes.Equal(streamed.Bytes(), expected) {
+				t.Error("Expected StripStream to match Strip")
+			}
+
+			if _, err := Inspect(input); err != nil {
+				t.Errorf("Inspect failed: %v", err)
+			}
+		})
+	}
+
+	// Tables cannot be empty, so those still fail rather than being guessed at
+	input := append(append(append([]byte{}, jpegData[:2]...), 0xFF, 0xDB, 0, 2), jpegData[2:]...)
+	var unknown *UnknownMarkerError
+	if _, _, err := Strip(input); err == nil || errors.As(err, &unknown) {
+		t.Errorf("Expected a parse error for an empty DQT, got %v", err)
+	}
+}
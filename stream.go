@@ -0,0 +1,172 @@
+package jpegmetawebstrip
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// streamBufferSize is the read buffer used by StripStream for the entropy-coded data
+const streamBufferSize = 32 << 10
+
+// StripStream strips metadata from a JPEG read from r and writes the result to w.
+// Only the segments up to the first SOS are buffered and cleaned; once the scan starts, the
+// rest of the input is copied verbatim without inspection, which keeps latency low for
+// streaming proxies where the entropy-coded data dominates. Metadata segments placed after
+// the first scan are therefore passed through, and output validation, fallback and
+// WithColorVerify are not applied. Result.OriginalSize counts every byte read from r.
+func StripStream(r io.Reader, w io.Writer, opts ...Option) (*Result, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		o.deadline = time.Now().Add(o.timeout)
+	}
+
+	br := bufio.NewReaderSize(r, streamBufferSize)
+	if head, _ := br.Peek(len(rafHeader)); len(head) > 0 {
+		if format, ok := detectRaw(head); ok {
+			return nil, &RawImageError{Format: format}
+		}
+	}
+	segments, sos, headerSize, err := readHeaderSegments(br)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	cleaned, err := cleanSegments(segments, result, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := newSegmentList(cleaned).Write(w); err != nil {
+		return nil, fmt.Errorf("failed to write JPEG header: %w", err)
+	}
+	if _, err := w.Write(sos); err != nil {
+		return nil, fmt.Errorf("failed to write JPEG header: %w", err)
+	}
+
+	var copied int64
+	if o.trimTrailer {
+		copied, err = copyThroughEOI(w, br)
+	} else {
+		copied, err = io.Copy(w, br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy scan data: %w", err)
+	}
+	result.OriginalSize = headerSize + copied
+	return result, nil
+}
+
+// readHeaderSegments reads SOI and every segment before the first scan. It returns those
+// segments, the raw SOS marker segment and the number of bytes consumed.
+func readHeaderSegments(br *bufio.Reader) ([]*jpegSegment, []byte, int64, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xFF, markerSOI} {
+		return nil, nil, 0, fmt.Errorf("failed to parse JPEG: missing SOI marker")
+	}
+	segments := []*jpegSegment{{MarkerId: markerSOI}}
+	offset := int64(2)
+
+	for {
+		marker, n, err := readStreamMarker(br, offset)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		offset += n
+		segmentOffset := int(offset - 2)
+
+		switch {
+		case marker == markerEOI:
+			return nil, nil, 0, fmt.Errorf("failed to parse JPEG: image ends before the first scan")
+		case isStandaloneMarker(marker):
+			// TEM and RSTn carry no length and are dropped, as Strip does
+			continue
+		}
+
+		data, err := readStreamPayload(br, marker)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		offset += int64(2 + len(data))
+		if len(data) == 0 && isEmptyRemovable(marker) {
+			continue
+		}
+		if marker == markerSOS {
+			// SOS is written raw because the segment writer treats it as part of the scan data
+			sos := binary.BigEndian.AppendUint16([]byte{0xFF, marker}, uint16(2+len(data)))
+			return segments, append(sos, data...), offset, nil
+		}
+		segments = append(segments, &jpegSegment{MarkerId: marker, Offset: segmentOffset, Data: data})
+		if len(segments) > MaxSegments {
+			return nil, nil, 0, fmt.Errorf("failed to parse JPEG: %w: more than %d segments", ErrLimitExceeded, MaxSegments)
+		}
+	}
+}
+
+// readStreamMarker reads the next marker, which may be preceded by any number of 0xFF fill
+// bytes, and returns it with the number of bytes read. offset is only used in errors.
+func readStreamMarker(br *bufio.Reader, offset int64) (byte, int64, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse JPEG: no scan found")
+	}
+	if b != 0xFF {
+		return 0, 0, fmt.Errorf("failed to parse JPEG: expected marker at %d", offset)
+	}
+	n := int64(1)
+	marker := byte(0xFF)
+	for marker == 0xFF {
+		if marker, err = br.ReadByte(); err != nil {
+			return 0, 0, fmt.Errorf("failed to parse JPEG: truncated marker")
+		}
+		n++
+	}
+	return marker, n, nil
+}
+
+// readStreamPayload reads the length field and payload of a segment with the marker
+func readStreamPayload(br *bufio.Reader, marker byte) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(br, length[:]); err != nil {
+		return nil, fmt.Errorf("failed to parse JPEG: truncated segment length")
+	}
+	size := int(binary.BigEndian.Uint16(length[:]))
+	if size < 2 {
+		return nil, fmt.Errorf("failed to parse JPEG: invalid segment length %d", size)
+	}
+	data := make([]byte, size-2)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, fmt.Errorf("failed to parse JPEG: truncated segment 0x%02X", marker)
+	}
+	return data, nil
+}
+
+// copyThroughEOI copies the scan data up to and including the EOI marker and discards
+// anything after it. See scanWalker for how the EOI is told apart from table bytes.
+func copyThroughEOI(w io.Writer, br *bufio.Reader) (int64, error) {
+	var read int64
+	var walker scanWalker
+	for {
+		chunk, err := br.Peek(streamBufferSize)
+		if len(chunk) == 0 {
+			if err == io.EOF {
+				return read, nil
+			}
+			return read, err
+		}
+
+		end, done := walker.walk(chunk)
+		if _, err := w.Write(chunk[:end]); err != nil {
+			return read, err
+		}
+		_, _ = br.Discard(end)
+		read += int64(end)
+
+		if done {
+			trailer, err := io.Copy(io.Discard, br)
+			return read + trailer, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripStreamMatchesStrip(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			jpegData, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			expected, expectedResult, err := Strip(jpegData)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+
+			out := new(bytes.Buffer)
+			result, err := StripStream(bytes.NewReader(jpegData), out)
+			if err != nil {
+				t.Fatalf("StripStream failed: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), expected) {
+				t.Errorf("stream output differs from Strip: %d vs %d bytes", out.Len(), len(expected))
+			}
+			if result.Total != expectedResult.Total || result.OriginalSize != int64(len(jpegData)) {
+				t.Errorf("unexpected result: total %d (want %d), original %d", result.Total, expectedResult.Total, result.OriginalSize)
+			}
+		})
+	}
+}
+
+func TestStripStreamTrailer(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	withTrailer := append(append([]byte{}, jpegData...), []byte("PK\x03\x04 appended archive")...)
+
+	out := new(bytes.Buffer)
+	if _, err := StripStream(bytes.NewReader(withTrailer), out); err != nil {
+		t.Fatalf("StripStream failed: %v", err)
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte("appended archive")) {
+		t.Error("trailer should be passed through by default")
+	}
+
+	out.Reset()
+	result, err := StripStream(bytes.NewReader(withTrailer), out, WithTrimTrailer())
+	if err != nil {
+		t.Fatalf("StripStream failed: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Error("trimmed stream output should match Strip")
+	}
+	if result.OriginalSize != int64(len(withTrailer)) {
+		t.Errorf("OriginalSize = %d, want %d", result.OriginalSize, len(withTrailer))
+	}
+}
+
+func TestStripStreamInvalid(t *testing.T) {
+	for _, input := range []string{"", "not a jpeg", "\xFF\xD8\xFF\xD9", "\xFF\xD8\xFF\xE1\x00"} {
+		if _, err := StripStream(strings.NewReader(input), new(bytes.Buffer)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-
-	jpegstructure "github.com/dsoprea/go-jpeg-image-structure/v2"
+	"math"
+	"strings"
+	"time"
 )
 
 const (
@@ -13,108 +14,767 @@ const (
 	ExifHeader = "Exif\x00\x00"
 )
 
+// preservedMarkers and removedMarkers back PreservedMarkers and RemovedMarkers
+var (
+	preservedMarkers = [...]byte{
+		markerSOI,
+		markerAPP2,  // ICC Profile
+		markerAPP14, // Adobe
+		markerSOF0,
+		markerSOF1,
+		markerSOF2,
+		markerDQT,
+		markerDHT,
+		markerSOS,
+		markerEOI,
+	}
+	removedMarkers = [...]byte{
+		markerAPP13, // Photoshop IRB/IPTC
+		markerCOM,   // Comment
+	}
+)
+
+// PreservedMarkers returns the JPEG markers whose segments the default policy keeps.
+// APP1 is not listed because its handling depends on the payload (EXIF is cleaned, XMP is
+// removed). Options change this: whitelist mode can drop APP2 and APP14, and WithICCv2Swap
+// replaces APP2. The returned slice is a copy.
+func PreservedMarkers() []byte {
+	markers := preservedMarkers
+	return markers[:]
+}
+
+// RemovedMarkers returns the JPEG markers whose segments the default policy removes.
+// Options change this: PresetPrivacy keeps COM and APP13, and WithKeepCopyright keeps the
+// copyright datasets of APP13. The returned slice is a copy.
+func RemovedMarkers() []byte {
+	markers := removedMarkers
+	return markers[:]
+}
+
+// removableExifTags backs RemovableExifTags
+var removableExifTags = [...]uint16{
+	0x000B, // ProcessingSoftware
+	0x010F, // Make
+	0x0110, // Model
+	0x0131, // Software
+	0x8825, // GPS IFD Pointer
+	0x927C, // MakerNote
+	0xA005, // Interoperability IFD
+	0xA420, // ImageUniqueID (Exif IFD)
+	0xA433, // LensMake (Exif IFD)
+	0xA434, // LensModel (Exif IFD)
+	0xA435, // LensSerialNumber (Exif IFD)
+}
+
+// RemovableExifTags returns the IFD0 and Exif IFD tags that the default policy removes
+// from EXIF data. The returned slice is a copy.
+func RemovableExifTags() []uint16 {
+	tags := removableExifTags
+	return tags[:]
+}
+
+// cameraInfoTags are the RemovableExifTags() counted as Result.Removed.CameraInfo
+var cameraInfoTags = []uint16{
+	0x010F, // Make
+	0x0110, // Model
+	0x927C, // MakerNote
+	0xA005, // Interoperability IFD
+}
+
+// lensTags are the Exif IFD tags describing the lens, counted as Result.Removed.CameraInfo
+var lensTags = []uint16{
+	0xA433, // LensMake
+	0xA434, // LensModel
+	0xA435, // LensSerialNumber
+}
+
+// identifierTags are the Exif IFD tags counted as Result.Removed.Identifiers
+var identifierTags = []uint16{
+	0xA420, // ImageUniqueID
+}
+
+// softwareTags are the RemovableExifTags() counted as Result.Removed.Software
+var softwareTags = []uint16{
+	0x000B, // ProcessingSoftware
+	0x0131, // Software
+}
+
+// defaultWhitelistMarkers and defaultWhitelistExifTags back DefaultWhitelistMarkers and
+// DefaultWhitelistExifTags
+var (
+	defaultWhitelistMarkers = [...]byte{
+		markerAPP0,  // JFIF
+		markerAPP1,  // EXIF (XMP is removed regardless)
+		markerAPP2,  // ICC Profile
+		markerAPP14, // Adobe
+	}
+	defaultWhitelistExifTags = [...]uint16{
+		0x0100, // ImageWidth
+		0x0101, // ImageLength
+		0x0112, // Orientation
+		0x011A, // XResolution
+		0x011B, // YResolution
+		0x0128, // ResolutionUnit
+		0x0213, // YCbCrPositioning
+		0x8769, // Exif IFD Pointer
+		0x9000, // ExifVersion
+		0xA001, // ColorSpace
+		0xA002, // PixelXDimension
+		0xA003, // PixelYDimension
+		0xA500, // Gamma
+	}
+)
+
+// DefaultWhitelistMarkers returns the metadata markers kept in whitelist mode. Structural
+// markers (SOI, SOFn, DQT, DHT, DRI, SOS, RSTn, EOI) are always kept and are not listed.
+// The returned slice is a copy.
+func DefaultWhitelistMarkers() []byte {
+	markers := defaultWhitelistMarkers
+	return markers[:]
+}
+
+// DefaultWhitelistExifTags returns the IFD0 and Exif IFD tags kept in whitelist mode.
+// The returned slice is a copy.
+func DefaultWhitelistExifTags() []uint16 {
+	tags := defaultWhitelistExifTags
+	return tags[:]
+}
+
 // Result contains information about removed metadata
 type Result struct {
 	Removed struct {
 		ExifThumbnail int64
 		ExifGPS       int64
 		CameraInfo    int64
+		Software      int64
+		Identifiers   int64
 		XMP           int64
 		IPTC          int64
 		PhotoshopIRB  int64
 		Comments      int64
+		// Telemetry counts drone and action/360 camera telemetry segments (DJI, GoPro, Ricoh)
+		Telemetry int64
+		// Thermal counts FLIR radiometric data removed by WithRemoveRadiometric
+		Thermal int64
+		// Adobe counts vendor padding trimmed from APP14 by WithMinimizeAdobe
+		Adobe int64
+		// Other counts segments and tags removed only because whitelist mode did not allow them,
+		// EXIF dropped for exceeding the IFD limits and replaced WithProcessedMarker markers
+		Other int64
 	}
 	Total int64
+	// OriginalSize is the size of the input JPEG in bytes
+	OriginalSize int64
+
+	// FellBack is true when the cleaned output failed validation and the original bytes were returned instead
+	FellBack bool
+	// FallbackReason describes the validation failure and the input structure for diagnosis
+	FallbackReason string
+
+	// Warnings lists noteworthy removals, such as copyright attribution
+	Warnings []Warning
+
+	// ICCSwapped is true when WithICCv2Swap replaced a v4 sRGB profile with a v2 one
+	ICCSwapped bool
+	// DensityNormalized is true when WithNormalizeDensity reconciled JFIF and EXIF resolution
+	DensityNormalized bool
+	// OrientationReconciled is true when a kept XMP tiff:Orientation was updated or removed to match EXIF
+	OrientationReconciled bool
+
+	// Unchanged is true when nothing was removed or rewritten and the input slice was returned
+	// as is, without writing the segments again
+	Unchanged bool
+	// AlreadyProcessed is true when WithTrustProcessedMarkers was given and the input carried
+	// the WithProcessedComment and WithProcessedMarker markers of the same policy, so it was
+	// returned unchanged
+	AlreadyProcessed bool
+
+	// EmbeddedPreview names the raw format when WithEmbeddedPreview cleaned a preview extracted
+	// from a raw file; OriginalSize and the output then refer to that preview
+	EmbeddedPreview string
+
+	// RemovedComments holds the decoded text of removed COM segments when WithCaptureComments is used
+	RemovedComments []Comment
+
+	// Resources holds phase durations and allocated bytes when WithResourceStats is used
+	Resources *ResourceStats
 }
 
 // Strip removes unnecessary metadata from JPEG data for web optimization while preserving display-critical information
-func Strip(jpegData []byte) ([]byte, *Result, error) {
-	result := &Result{}
+func Strip(jpegData []byte, opts ...Option) ([]byte, *Result, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		return stripWithTimeout(jpegData, o)
+	}
+	return strip(jpegData, o)
+}
+
+// stripWithTimeout runs strip in the background and gives up once the timeout elapses.
+// The call is abandoned rather than cancelled: strip checks the deadline between segments
+// and between its parse, write and verify phases, so the goroutine stops at the next check,
+// but a phase already running, such as decoding both images for WithColorVerify, finishes
+// in the background first.
+func stripWithTimeout(jpegData []byte, o *options) ([]byte, *Result, error) {
+	type stripOutput struct {
+		data   []byte
+		result *Result
+		err    error
+	}
+
+	o.deadline = time.Now().Add(o.timeout)
+	done := make(chan stripOutput, 1)
+	go func() {
+		data, result, err := strip(jpegData, o)
+		done <- stripOutput{data, result, err}
+	}()
+
+	timer := time.NewTimer(o.timeout)
+	defer timer.Stop()
+	select {
+	case out := <-done:
+		return out.data, out.result, out.err
+	case <-timer.C:
+		return nil, nil, ErrTimeout
+	}
+}
+
+// strip performs the actual metadata removal
+func strip(jpegData []byte, o *options) ([]byte, *Result, error) {
+	if format, ok := detectRaw(jpegData); ok && o.embeddedPreview {
+		preview := findRawPreview(jpegData)
+		if preview == nil {
+			return nil, nil, &RawImageError{Format: format}
+		}
+		output, result, err := strip(preview, o)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.EmbeddedPreview = format
+		return output, result, nil
+	}
+
+	// A marked file is recognized from its headers without parsing it
+	if isMarkedProcessed(jpegData, o) {
+		return jpegData, &Result{OriginalSize: int64(len(jpegData)), Unchanged: true, AlreadyProcessed: true}, nil
+	}
+
+	tracker := newResourceTracker(o.resourceStats)
 
 	// Parse JPEG structure
-	jmp := jpegstructure.NewJpegMediaParser()
-	intfc, err := jmp.ParseBytes(jpegData)
+	sl, err := parseSegmentList(jpegData)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracker.parsed()
+	if o.expired() {
+		return nil, nil, ErrTimeout
+	}
+
+	return stripSegments(jpegData, sl, o, tracker)
+}
+
+// parseSegmentList parses JPEG data into its segment list
+func parseSegmentList(jpegData []byte) (*segmentList, error) {
+	if format, ok := detectRaw(jpegData); ok {
+		return nil, &RawImageError{Format: format}
+	}
+
+	sl, err := scanSegments(jpegData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JPEG: %w", err)
+	}
+	if err := checkSegmentLimits(sl.Segments()); err != nil {
+		return nil, fmt.Errorf("failed to parse JPEG: %w", err)
+	}
+	return sl, nil
+}
+
+// stripSegments removes metadata from an already parsed JPEG and writes the result.
+// The parsed segments are not modified, so they can be stripped again with other options.
+func stripSegments(jpegData []byte, sl *segmentList, o *options, tracker *resourceTracker) ([]byte, *Result, error) {
+	result := &Result{OriginalSize: int64(len(jpegData))}
+	newSegments, err := cleanSegments(sl.Segments(), result, o)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse JPEG: %w", err)
+		return nil, nil, err
+	}
+	tracker.processed(sl.Segments(), newSegments)
+
+	// Writing unchanged segments again could only alter the bytes, so the input is returned.
+	// A trailer after EOI is dropped like on every rewrite, so it rules this out.
+	if result.Total == 0 && unchangedSegments(sl.Segments(), newSegments) && !hasDroppedMarkers(jpegData) && sl.end == len(jpegData) {
+		result.Unchanged = true
+		result.Resources = tracker.finish(0)
+		return jpegData, result, nil
 	}
 
-	sl, ok := intfc.(*jpegstructure.SegmentList)
-	if !ok {
-		return nil, nil, fmt.Errorf("failed to get segment list")
+	if o.expired() {
+		return nil, nil, ErrTimeout
 	}
 
-	// Create new segment list for cleaned JPEG
-	newSegments := make([]*jpegstructure.Segment, 0)
+	// Create new segment list
+	newSl := newSegmentList(newSegments)
+
+	// Write cleaned JPEG
+	b := new(bytes.Buffer)
+	if err := newSl.Write(b); err != nil {
+		return nil, nil, fmt.Errorf("failed to write cleaned JPEG: %w", err)
+	}
+
+	output, result, err := validateOrFallback(jpegData, b.Bytes(), sl.Segments(), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.colorVerify && !result.FellBack {
+		if o.expired() {
+			return nil, nil, ErrTimeout
+		}
+		if err := verifyColors(jpegData, output, o.colorTolerance); err != nil {
+			return nil, nil, err
+		}
+	}
+	result.Resources = tracker.finish(b.Len())
+	return output, result, nil
+}
+
+// unchangedSegments reports whether cleaning kept every segment with the same marker and data
+func unchangedSegments(original, cleaned []*jpegSegment) bool {
+	if len(original) != len(cleaned) {
+		return false
+	}
+	for i, segment := range original {
+		if segment.MarkerId != cleaned[i].MarkerId || !bytes.Equal(segment.Data, cleaned[i].Data) {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanSegments runs the strict checks, the per-segment processing and the whole-file
+// passes over segments and returns the segments to write
+func cleanSegments(segments []*jpegSegment, result *Result, o *options) ([]*jpegSegment, error) {
+	// Files marked by an earlier run of the same policy need no work
+	if hasProcessedMarkers(segments, o) {
+		result.AlreadyProcessed = true
+		return segments, nil
+	}
+
+	if err := checkStrict(segments, o); err != nil {
+		return nil, err
+	}
 
 	// Iterate through segments and filter out unwanted metadata
-	for _, segment := range sl.Segments() {
-		processedSegment, keep := processSegment(segment, result)
+	newSegments, err := processSegments(segments, result, o)
+	if err != nil {
+		return nil, err
+	}
+
+	newSegments = insertProcessingMarkers(newSegments, o)
+	if o.iccV2Swap {
+		newSegments, result.ICCSwapped = swapICCv4(newSegments)
+	}
+	result.addWarnings(iccv4Warning(newSegments))
+	if wanted := o.keptXMPProperties(); len(wanted) > 0 {
+		newSegments, result.OrientationReconciled = reconcileOrientation(newSegments, wanted, o.xmpPadding)
+	}
+	if o.densityDPI > 0 && o.densityDPI <= math.MaxUint16 {
+		newSegments, result.DensityNormalized = normalizeDensity(newSegments, o.densityDPI)
+	}
+
+	// ICC profiles must survive byte-for-byte regardless of how EXIF was rewritten,
+	// unless a whitelist deliberately excludes them or the profile was swapped on request
+	if (!o.whitelist || o.whitelistMarkers[markerAPP2]) && !result.ICCSwapped {
+		if err := verifyICCPreserved(segments, newSegments); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rebuilt segments (XMP padding, swapped ICC, ...) must still fit the JPEG envelope
+	if err := checkSegmentLimits(newSegments); err != nil {
+		return nil, fmt.Errorf("failed to write cleaned JPEG: %w", err)
+	}
+	return newSegments, nil
+}
+
+// checkStrict applies the strict-mode checks that reject an input before any processing
+func checkStrict(segments []*jpegSegment, o *options) error {
+	// In strict mode, refuse to pass through segments we don't understand
+	if o.strictUnknown {
+		if unknown := findUnknownMarkers(segments, o.preserved); len(unknown) > 0 {
+			return &UnknownMarkerError{Markers: unknown}
+		}
+	}
+	if !o.strictIFDLimits {
+		return nil
+	}
+	for _, segment := range filterSegments(segments, markerAPP1) {
+		if !isExifSegment(segment) {
+			continue
+		}
+		if err := checkIFDLimits(segment.Data, o.maxIFDDepth, o.maxIFDEntries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processSegments runs processSegment over segments and returns the ones to keep
+func processSegments(segments []*jpegSegment, result *Result, o *options) ([]*jpegSegment, error) {
+	newSegments := make([]*jpegSegment, 0)
+	for _, segment := range segments {
+		if o.expired() {
+			return nil, ErrTimeout
+		}
+		if isReplacedProcessingMarker(segment, o) {
+			// The marker of another policy is replaced by insertProcessingMarkers
+			if segment.MarkerId == markerCOM {
+				result.Removed.Comments += int64(len(segment.Data))
+			} else {
+				result.Removed.Other += int64(len(segment.Data))
+			}
+			result.Total += int64(len(segment.Data))
+			continue
+		}
+		processedSegment, keep := processSegment(segment, result, o)
 		if keep {
 			newSegments = append(newSegments, processedSegment)
 		}
 	}
+	return newSegments, nil
+}
 
-	// Create new segment list
-	newSl := jpegstructure.NewSegmentList(newSegments)
+// validateOrFallback returns the cleaned output if it is a well-formed JPEG,
+// or the original data with a FellBack result if it is not.
+// A well-formed output whose frame header differs from the input is an error.
+func validateOrFallback(original, cleaned []byte, segments []*jpegSegment, result *Result) ([]byte, *Result, error) {
+	cleanedSegments, err := validateOutput(cleaned)
+	if err != nil {
+		return original, &Result{
+			OriginalSize:   result.OriginalSize,
+			FellBack:       true,
+			FallbackReason: fmt.Sprintf("%v (input structure: %s)", err, describeSegments(segments)),
+		}, nil
+	}
 
-	// Write cleaned JPEG
-	b := new(bytes.Buffer)
-	err = newSl.Write(b)
+	if err := verifyFrameUnchanged(segments, cleanedSegments); err != nil {
+		return nil, nil, err
+	}
+	return cleaned, result, nil
+}
+
+// validateOutput re-parses written JPEG data, checks its basic structure and returns its segments
+func validateOutput(data []byte) ([]*jpegSegment, error) {
+	sl, err := scanSegments(data)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to write cleaned JPEG: %w", err)
+		return nil, fmt.Errorf("output validation failed: %w", err)
+	}
+
+	segments := sl.Segments()
+	if len(segments) < 2 || segments[0].MarkerId != markerSOI {
+		return nil, fmt.Errorf("output validation failed: missing SOI")
+	}
+	if segments[len(segments)-1].MarkerId != markerEOI {
+		return nil, fmt.Errorf("output validation failed: missing EOI")
+	}
+	if len(filterSegments(segments, markerSOS)) == 0 {
+		return nil, fmt.Errorf("output validation failed: missing SOS")
+	}
+	return segments, nil
+}
+
+// frameHeader holds the image geometry declared by a SOFn segment
+type frameHeader struct {
+	Marker     byte
+	Precision  byte
+	Height     uint16
+	Width      uint16
+	Components byte
+}
+
+// readFrameHeader returns the first SOFn frame header of the segments
+func readFrameHeader(segments []*jpegSegment) (frameHeader, bool) {
+	for _, segment := range segments {
+		if !isSOFMarker(segment.MarkerId) || len(segment.Data) < 6 {
+			continue
+		}
+		return frameHeader{
+			Marker:     segment.MarkerId,
+			Precision:  segment.Data[0],
+			Height:     binary.BigEndian.Uint16(segment.Data[1:3]),
+			Width:      binary.BigEndian.Uint16(segment.Data[3:5]),
+			Components: segment.Data[5],
+		}, true
+	}
+	return frameHeader{}, false
+}
+
+// isSOFMarker reports whether the marker is a start-of-frame marker (excluding DHT, JPG and DAC)
+func isSOFMarker(marker byte) bool {
+	return marker >= markerSOF0 && marker <= markerSOF15 &&
+		marker != markerDHT && marker != markerJPG && marker != markerDAC
+}
+
+// verifyFrameUnchanged fails if the output frame header differs from the input
+func verifyFrameUnchanged(original, cleaned []*jpegSegment) error {
+	originalFrame, originalOK := readFrameHeader(original)
+	cleanedFrame, cleanedOK := readFrameHeader(cleaned)
+	if originalOK != cleanedOK || originalFrame != cleanedFrame {
+		return fmt.Errorf("%w: input %+v, output %+v", ErrFrameChanged, originalFrame, cleanedFrame)
 	}
+	return nil
+}
 
-	return b.Bytes(), result, nil
+// describeSegments renders a compact marker sequence such as "SOI APP0 DQT ... EOI"
+func describeSegments(segments []*jpegSegment) string {
+	names := make([]string, len(segments))
+	for i, segment := range segments {
+		if segment.MarkerName != "" {
+			names[i] = segment.MarkerName
+		} else {
+			names[i] = fmt.Sprintf("0x%02X", segment.MarkerId)
+		}
+	}
+	return strings.Join(names, " ")
 }
 
 // processSegment processes a single JPEG segment and determines if it should be kept
-func processSegment(segment *jpegstructure.Segment, result *Result) (*jpegstructure.Segment, bool) {
+func processSegment(segment *jpegSegment, result *Result, o *options) (*jpegSegment, bool) {
 	removedSize := int64(len(segment.Data))
 
-	switch segment.MarkerId {
-	case jpegstructure.MARKER_APP1: // EXIF/XMP
-		return processAPP1Segment(segment, result, removedSize)
+	// TEM and RSTn outside scan data carry nothing and decoders skip them
+	if isStandaloneMarker(segment.MarkerId) {
+		return segment, false
+	}
+
+	// Preserved segments are kept before any other rule can remove them
+	if isPreservedSegment(segment, o.preserved) {
+		return segment, true
+	}
+
+	// Segments claimed by a registered handler bypass the built-in processing
+	if handler := findSegmentHandler(segment); handler != nil {
+		return processWithHandler(handler, segment, result)
+	}
 
-	case jpegstructure.MARKER_APP13: // Photoshop IRB/IPTC
-		result.Removed.PhotoshopIRB += removedSize
+	// Only the EXIF thumbnail (and comments for PresetConservative) is touched in thumbnail-only mode
+	isExif := segment.MarkerId == markerAPP1 && isExifSegment(segment)
+	if skippedInThumbnailOnly(segment, isExif, o) {
+		return segment, true
+	}
+
+	// Thermal measurements are kept in every mode unless removal is requested
+	if isFLIRSegment(segment) {
+		return processFLIRSegment(segment, result, removedSize, o)
+	}
+
+	// Vendor telemetry can record a whole flight path, so it goes under every policy but thumbnail-only
+	if telemetryVendor(segment) != "" {
+		result.Removed.Telemetry += removedSize
 		result.Total += removedSize
 		return segment, false
+	}
 
-	case jpegstructure.MARKER_COM: // Comment
+	// In privacy-only mode, only EXIF and XMP carrying location or identifying data change
+	if o.privacyOnly && !isExif {
+		return processPrivacySegment(segment, result, removedSize, o)
+	}
+
+	switch segment.MarkerId {
+	case markerAPP1: // EXIF/XMP
+		return processAPP1Segment(segment, result, removedSize, o)
+
+	case markerAPP13: // Photoshop IRB/IPTC
+		return processAPP13Segment(segment, result, removedSize, o)
+
+	case markerCOM: // Comment
+		if o.captureComments {
+			result.RemovedComments = append(result.RemovedComments, DecodeComment(segment.Data))
+		}
 		result.Removed.Comments += removedSize
 		result.Total += removedSize
 		return segment, false
 
-	case jpegstructure.MARKER_APP2, // ICC Profile
-		jpegstructure.MARKER_APP14,                                                      // Adobe
-		jpegstructure.MARKER_SOF0, jpegstructure.MARKER_SOF1, jpegstructure.MARKER_SOF2, // Start of Frame
-		jpegstructure.MARKER_DQT, jpegstructure.MARKER_DHT, // Quantization and Huffman tables
-		jpegstructure.MARKER_SOS,                           // Start of Scan
-		jpegstructure.MARKER_SOI, jpegstructure.MARKER_EOI: // Start/End of Image
-		// Keep these segments
-		return segment, true
+	case markerAPP14: // Adobe
+		return processAPP14Segment(segment, result, removedSize, o)
 
 	default:
-		// Keep unknown segments by default
+		// Keep ICC profiles, frame, table and scan segments, and unknown segments by default
+		return keepSegment(segment, result, removedSize, o)
+	}
+}
+
+// skippedInThumbnailOnly reports whether thumbnail-only mode leaves the segment alone; only
+// the EXIF segment holding the thumbnail, and comments for PresetConservative, are processed
+func skippedInThumbnailOnly(segment *jpegSegment, isExif bool, o *options) bool {
+	return o.thumbnailOnly && !isExif && !(o.removeComments && segment.MarkerId == markerCOM)
+}
+
+// processPrivacySegment handles the segments other than EXIF in privacy-only mode: XMP
+// carrying location or identifying data is removed, everything else is kept
+func processPrivacySegment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if segment.MarkerId != markerAPP1 || !hasPrivacyXMP(segment.Data) {
 		return segment, true
 	}
+	if isExtendedXMPSegment(segment) {
+		result.Removed.XMP += removedSize
+		result.Total += removedSize
+		return segment, false
+	}
+	if !isXMPSegment(segment) {
+		return segment, true
+	}
+	if kept, ok := keptXMPSegment(segment, result, removedSize, o); ok {
+		return kept, true
+	}
+	result.addWarnings(copyrightFromXMP(segment.Data))
+	result.Removed.XMP += removedSize
+	result.Total += removedSize
+	return segment, false
+}
+
+// processAPP13Segment removes a Photoshop IRB/IPTC segment, keeping only its copyright
+// datasets when WithKeepCopyright is set
+func processAPP13Segment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if o.keepCopyright {
+		if filtered := filterIPTCSegment(segment.Data, copyrightIPTCWanted); filtered != nil {
+			if saved := removedSize - int64(len(filtered)); saved > 0 {
+				result.Removed.PhotoshopIRB += saved
+				result.Total += saved
+			}
+			return &jpegSegment{
+				MarkerId:   segment.MarkerId,
+				MarkerName: segment.MarkerName,
+				Offset:     segment.Offset,
+				Data:       filtered,
+			}, true
+		}
+	}
+	result.addWarnings(copyrightFromIPTC(segment.Data))
+	result.Removed.PhotoshopIRB += removedSize
+	result.Total += removedSize
+	return segment, false
+}
+
+// processAPP14Segment keeps an Adobe segment, minimized when WithMinimizeAdobe is set
+func processAPP14Segment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if o.minimizeAdobe {
+		if minimized, ok := minimizeAdobeSegment(segment); ok {
+			saved := removedSize - int64(len(minimized.Data))
+			result.Removed.Adobe += saved
+			result.Total += saved
+			return keepSegment(minimized, result, int64(len(minimized.Data)), o)
+		}
+	}
+	return keepSegment(segment, result, removedSize, o)
+}
+
+// keepSegment keeps a segment unless whitelist mode does not allow its marker
+func keepSegment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if o.whitelist && !isStructuralMarker(segment.MarkerId) && !o.whitelistMarkers[segment.MarkerId] {
+		result.Removed.Other += removedSize
+		result.Total += removedSize
+		return segment, false
+	}
+	return segment, true
+}
+
+// isStructuralMarker reports whether the marker carries image data rather than metadata
+func isStructuralMarker(marker byte) bool {
+	switch {
+	case marker == 0x00: // Scan data pseudo-segment produced by the parser
+		return true
+	case marker >= markerSOF0 && marker <= markerSOF15: // SOFn, DHT, JPG, DAC
+		return true
+	case marker >= 0xD0 && marker <= 0xD7: // RSTn
+		return true
+	}
+
+	switch marker {
+	case markerSOI, markerEOI, markerSOS,
+		markerDQT,
+		0xDD: // DRI
+		return true
+	}
+	return false
+}
+
+// isKnownMarker reports whether the marker is one the library understands
+func isKnownMarker(marker byte) bool {
+	if isStructuralMarker(marker) {
+		return true
+	}
+
+	switch marker {
+	case markerAPP0, // JFIF
+		markerAPP1, markerAPP2, // EXIF/XMP, ICC Profile
+		markerAPP13, markerAPP14, // Photoshop IRB/IPTC, Adobe
+		markerCOM,
+		temMarker:
+		return true
+	}
+	return false
+}
+
+// findUnknownMarkers returns the distinct unknown markers in order of first appearance
+func findUnknownMarkers(segments []*jpegSegment, preserved []PreservedSegment) []byte {
+	unknown := make([]byte, 0)
+	seen := make(map[byte]bool)
+	for _, segment := range segments {
+		if isKnownMarker(segment.MarkerId) || (segment.MarkerId == markerAPP15 && isProcessedMarker(segment.Data)) || telemetryVendor(segment) != "" || isPreservedSegment(segment, preserved) || findSegmentHandler(segment) != nil {
+			continue
+		}
+		if !seen[segment.MarkerId] {
+			seen[segment.MarkerId] = true
+			unknown = append(unknown, segment.MarkerId)
+		}
+	}
+	return unknown
 }
 
 // processAPP1Segment processes APP1 segments (EXIF/XMP)
-func processAPP1Segment(segment *jpegstructure.Segment, result *Result, removedSize int64) (*jpegstructure.Segment, bool) {
+func processAPP1Segment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if isExtendedXMPSegment(segment) {
+		// The main packet is removed or rebuilt without its xmpNote:HasExtendedXMP
+		// reference, so the extension chunks are never needed
+		result.Removed.XMP += removedSize
+		result.Total += removedSize
+		return segment, false
+	}
+
 	if isXMPSegment(segment) {
+		// Keep a minimal packet when selected properties must survive
+		if kept, ok := keptXMPSegment(segment, result, removedSize, o); ok {
+			return kept, true
+		}
+
 		// Remove XMP metadata
+		result.addWarnings(copyrightFromXMP(segment.Data))
 		result.Removed.XMP += removedSize
 		result.Total += removedSize
 		return segment, false
 	}
 
+	if isExifSegment(segment) {
+		if err := checkIFDLimits(segment.Data, o.maxIFDDepth, o.maxIFDEntries); err != nil {
+			// Adversarial directory structures are dropped rather than walked by every cleaner
+			result.addWarnings([]Warning{{Code: WarningIFDLimit, Source: "EXIF", Text: err.Error()}})
+			return dropExifSegment(segment, result, removedSize, o)
+		}
+	}
+
+	if o.whitelist && !o.whitelistMarkers[segment.MarkerId] {
+		if isExifSegment(segment) {
+			return dropExifSegment(segment, result, removedSize, o)
+		}
+		return keepSegment(segment, result, removedSize, o)
+	}
+
 	if isExifSegment(segment) {
 		// Process EXIF data to remove thumbnails and other unwanted data
-		cleanedExif, modified, removedBytes := cleanExifSegment(segment.Data, result)
+		cleanedExif, modified, removedBytes := cleanExifSegment(segment.Data, result, o)
 		if modified {
+			result.addWarnings(removedExifCopyright(segment.Data, cleanedExif))
 			// Create new segment with cleaned EXIF data
-			newSegment := &jpegstructure.Segment{
+			newSegment := &jpegSegment{
 				MarkerId:   segment.MarkerId,
 				MarkerName: segment.MarkerName,
 				Offset:     segment.Offset,
@@ -127,11 +787,113 @@ func processAPP1Segment(segment *jpegstructure.Segment, result *Result, removedS
 	}
 
 	// Keep other APP1 segments
+	if o.whitelist {
+		// Only EXIF is understood well enough to be allowed through in whitelist mode
+		result.Removed.Other += removedSize
+		result.Total += removedSize
+		return segment, false
+	}
 	return segment, true
 }
 
+// keptXMPSegment rebuilds an XMP segment with only the properties that must survive
+// stripping. The rebuilt packet is used even when it is larger than the original, which
+// may hold other properties such as GPS. It returns false when nothing needs to be kept.
+func keptXMPSegment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	wanted := o.keptXMPProperties()
+	if len(wanted) == 0 {
+		return nil, false
+	}
+	filtered := filterXMPSegment(segment.Data, wanted, o.xmpPadding)
+	if filtered == nil {
+		return nil, false
+	}
+	if !o.keepCopyright {
+		// None of the kept properties carry attribution, so any rights in the packet are lost
+		result.addWarnings(copyrightFromXMP(segment.Data))
+	}
+	if saved := removedSize - int64(len(filtered)); saved > 0 {
+		result.Removed.XMP += saved
+		result.Total += saved
+	}
+	return &jpegSegment{
+		MarkerId:   segment.MarkerId,
+		MarkerName: segment.MarkerName,
+		Offset:     segment.Offset,
+		Data:       filtered,
+	}, true
+}
+
+// dropExifSegment removes an EXIF segment as Other, keeping a minimal EXIF with its
+// attribution tags when WithKeepCopyright is set
+func dropExifSegment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if o.keepCopyright {
+		if minimal := copyrightExif(segment.Data); minimal != nil {
+			saved := removedSize - int64(len(minimal))
+			result.Removed.Other += saved
+			result.Total += saved
+			return &jpegSegment{
+				MarkerId:   segment.MarkerId,
+				MarkerName: segment.MarkerName,
+				Offset:     segment.Offset,
+				Data:       minimal,
+			}, true
+		}
+	}
+	result.addWarnings(copyrightFromExif(segment.Data))
+	result.Removed.Other += removedSize
+	result.Total += removedSize
+	return segment, false
+}
+
+// verifyICCPreserved ensures APP2 segments are kept in the same order with identical payloads
+func verifyICCPreserved(original, cleaned []*jpegSegment) error {
+	originalICC := filterSegments(original, markerAPP2)
+	cleanedICC := filterSegments(cleaned, markerAPP2)
+	if len(originalICC) != len(cleanedICC) {
+		return fmt.Errorf("ICC profile invariant violated: %d APP2 segments in input, %d in output", len(originalICC), len(cleanedICC))
+	}
+	for i := range originalICC {
+		if !bytes.Equal(originalICC[i].Data, cleanedICC[i].Data) {
+			return fmt.Errorf("ICC profile invariant violated: APP2 segment %d was modified", i)
+		}
+	}
+	return nil
+}
+
+// filterSegments returns the segments with the given marker in their original order
+func filterSegments(segments []*jpegSegment, markerID byte) []*jpegSegment {
+	filtered := make([]*jpegSegment, 0)
+	for _, segment := range segments {
+		if segment.MarkerId == markerID {
+			filtered = append(filtered, segment)
+		}
+	}
+	return filtered
+}
+
+// adobeSegmentSize is the payload size of an APP14 Adobe segment without padding:
+// "Adobe", version, flags0, flags1 and the color transform byte
+const adobeSegmentSize = 12
+
+// minimizeAdobeSegment trims an APP14 Adobe segment to its defined fields.
+// The version, flags and color transform byte are kept verbatim.
+func minimizeAdobeSegment(segment *jpegSegment) (*jpegSegment, bool) {
+	if len(segment.Data) <= adobeSegmentSize || !bytes.HasPrefix(segment.Data, []byte("Adobe")) {
+		return segment, false
+	}
+	data := make([]byte, adobeSegmentSize)
+	copy(data, segment.Data)
+	return &jpegSegment{
+		MarkerId:   segment.MarkerId,
+		MarkerName: segment.MarkerName,
+		Offset:     segment.Offset,
+		Data:       data,
+	}, true
+}
+
 // isExifSegment checks if the APP1 segment contains EXIF data
-func isExifSegment(segment *jpegstructure.Segment) bool {
+func isExifSegment(segment *jpegSegment) bool {
 	if len(segment.Data) < 6 {
 		return false
 	}
@@ -139,17 +901,26 @@ func isExifSegment(segment *jpegstructure.Segment) bool {
 	return bytes.HasPrefix(segment.Data, []byte(ExifHeader))
 }
 
+// isExtendedXMPSegment checks if a segment is an Extended XMP chunk
+func isExtendedXMPSegment(segment *jpegSegment) bool {
+	return bytes.HasPrefix(segment.Data, []byte(ExtendedXMPHeader))
+}
+
 // isXMPSegment checks if the APP1 segment contains XMP data
-func isXMPSegment(segment *jpegstructure.Segment) bool {
+func isXMPSegment(segment *jpegSegment) bool {
 	if len(segment.Data) < 29 {
 		return false
 	}
 	// Check for "http://ns.adobe.com/xap/1.0/\x00" header
-	return bytes.HasPrefix(segment.Data, []byte("http://ns.adobe.com/xap/1.0/\x00"))
+	return bytes.HasPrefix(segment.Data, []byte(XMPHeader))
 }
 
 // cleanExifSegment removes unwanted data from EXIF segment
-func cleanExifSegment(exifData []byte, result *Result) ([]byte, bool, int64) {
+func cleanExifSegment(exifData []byte, result *Result, o *options) ([]byte, bool, int64) {
+	if o.privacyOnly {
+		return cleanPrivacyExif(exifData, result)
+	}
+
 	// First try to remove thumbnail
 	cleanedData, thumbRemoved, thumbSize, err := removeThumbnailFromExif(exifData)
 	if err != nil {
@@ -157,30 +928,100 @@ func cleanExifSegment(exifData []byte, result *Result) ([]byte, bool, int64) {
 		return exifData, false, 0
 	}
 
+	// Small thumbnails may be kept when a size threshold is configured
+	if thumbRemoved && keepsThumbnail(exifData, o) {
+		thumbRemoved = false
+	}
+
+	if o.thumbnailOnly {
+		if !thumbRemoved {
+			return exifData, false, 0
+		}
+		result.Removed.ExifThumbnail += thumbSize
+		return cleanedData, true, thumbSize
+	}
+
 	totalRemoved := int64(0)
+	modified := false
 	if thumbRemoved {
 		result.Removed.ExifThumbnail += thumbSize
 		totalRemoved += thumbSize
 		exifData = cleanedData
+	} else {
+		// A kept thumbnail must not carry location or camera data of its own
+		exifData, totalRemoved = applyExifRemovals(exifData, []exifRemoval{
+			{&result.Removed.ExifGPS, func(data []byte) ([]byte, bool, int64) { return removeIFD1Tags(data, []uint16{0x8825}) }},
+			{&result.Removed.CameraInfo, func(data []byte) ([]byte, bool, int64) { return removeIFD1Tags(data, cameraInfoTags) }},
+		})
+		exifData, modified = alignThumbnailOrientation(exifData)
 	}
 
-	// Then remove GPS data
-	cleanedData, gpsRemoved, gpsSize := removeGPSFromExif(exifData)
-	if gpsRemoved {
-		result.Removed.ExifGPS += gpsSize
-		totalRemoved += gpsSize
-		exifData = cleanedData
+	exifData, removed := applyExifRemovals(exifData, exifRemovals(result, o))
+	totalRemoved += removed
+
+	// Rebuilding reclaims the space of cleared entries, their values and the wiped GPS
+	// directory, which would otherwise stay in the segment
+	if o.canonicalExif || o.byteOrder != nil {
+		if rebuilt, ok := rebuildExif(exifData, o.byteOrder); ok {
+			exifData = rebuilt
+			modified = true
+		}
+	} else if totalRemoved > 0 {
+		if rebuilt, ok := rebuildExif(exifData, nil); ok && len(rebuilt) < len(exifData) {
+			exifData = rebuilt
+		}
 	}
 
-	// Remove camera-specific data
-	cleanedData, camRemoved, camSize := removeCameraInfoFromExif(exifData)
-	if camRemoved {
-		result.Removed.CameraInfo += camSize
-		totalRemoved += camSize
-		exifData = cleanedData
+	return exifData, modified || totalRemoved > 0, totalRemoved
+}
+
+// keepsThumbnail reports whether the EXIF thumbnail is small enough to keep under
+// WithThumbnailMaxBytes. A thumbnail whose length cannot be read is never kept.
+func keepsThumbnail(exifData []byte, o *options) bool {
+	length := getThumbnailLength(exifData)
+	return o.thumbnailMaxBytes > 0 && length >= 0 && length <= o.thumbnailMaxBytes
+}
+
+// exifRemoval removes one kind of EXIF data, whose size is added to counter
+type exifRemoval struct {
+	counter *int64
+	remove  func([]byte) ([]byte, bool, int64)
+}
+
+// exifRemovals lists the EXIF data removed outside privacy and thumbnail-only modes, in order
+func exifRemovals(result *Result, o *options) []exifRemoval {
+	removals := []exifRemoval{
+		{&result.Removed.ExifGPS, removeGPSFromExif},
+		{&result.Removed.CameraInfo, removeCameraInfoFromExif},
+	}
+	// Editing software fingerprints
+	if !o.keepSoftware {
+		removals = append(removals, exifRemoval{&result.Removed.Software, removeSoftwareFromExif})
 	}
+	// Identifiers that allow the same photo to be tracked across sites
+	removals = append(removals, exifRemoval{&result.Removed.Identifiers, func(data []byte) ([]byte, bool, int64) {
+		return removeExifIFDTags(data, identifierTags)
+	}})
+	// In whitelist mode, every remaining tag that is not explicitly allowed
+	if o.whitelist {
+		removals = append(removals, exifRemoval{&result.Removed.Other, func(data []byte) ([]byte, bool, int64) {
+			return removeNonWhitelistedTags(data, o.allowedExifTags())
+		}})
+	}
+	return removals
+}
 
-	return exifData, totalRemoved > 0, totalRemoved
+// applyExifRemovals runs removals in order and returns the cleaned EXIF and the bytes removed
+func applyExifRemovals(exifData []byte, removals []exifRemoval) ([]byte, int64) {
+	total := int64(0)
+	for _, removal := range removals {
+		if cleanedData, removed, size := removal.remove(exifData); removed {
+			*removal.counter += size
+			total += size
+			exifData = cleanedData
+		}
+	}
+	return exifData, total
 }
 
 // removeThumbnailFromExif removes thumbnail from EXIF segment data
@@ -237,142 +1078,224 @@ func removeThumbnailFromExif(exifData []byte) ([]byte, bool, int64, error) {
 	return result, true, thumbSize, nil
 }
 
-// removeGPSFromExif removes GPS IFD from EXIF data
-func removeGPSFromExif(exifData []byte) ([]byte, bool, int64) {
-	if len(exifData) < 6 || string(exifData[0:6]) != ExifHeader {
-		return exifData, false, 0
+// getThumbnailLength returns the size of the IFD1 thumbnail image, or -1 if it cannot be determined
+func getThumbnailLength(exifData []byte) int64 {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return -1
 	}
-
-	// TIFF header starts from byte 6
-	pos := 6
-	if len(exifData) < pos+8 {
-		return exifData, false, 0
+	_, ifd1Offset, err := readIFD(exifData, order, ifd0Pos)
+	if err != nil || ifd1Offset == 0 {
+		return -1
 	}
 
-	byteOrder := binary.BigEndian.Uint16(exifData[pos : pos+2])
-	littleEndian := byteOrder == 0x4949
-	var readUint16 func([]byte) uint16
-	var readUint32 func([]byte) uint32
-	var writeUint32 func([]byte, uint32)
-	if littleEndian {
-		readUint16 = func(b []byte) uint16 { return binary.LittleEndian.Uint16(b) }
-		readUint32 = func(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
-		writeUint32 = func(b []byte, v uint32) { binary.LittleEndian.PutUint32(b, v) }
-	} else {
-		readUint16 = func(b []byte) uint16 { return binary.BigEndian.Uint16(b) }
-		readUint32 = func(b []byte) uint32 { return binary.BigEndian.Uint32(b) }
-		writeUint32 = func(b []byte, v uint32) { binary.BigEndian.PutUint32(b, v) }
+	ifd1Pos := tiffHeaderPos + int(ifd1Offset)
+	entries, _, err := readIFD(exifData, order, ifd1Pos)
+	if err != nil {
+		return -1
+	}
+	for _, entry := range entries {
+		if entry.Tag == 0x0202 { // JPEGInterchangeFormatLength
+			return int64(entry.offset(order))
+		}
 	}
 
-	ifd0Offset := int(readUint32(exifData[pos+4 : pos+8]))
-	ifd0Pos := pos + ifd0Offset
-	if len(exifData) < ifd0Pos+2 {
+	// Fall back to the space between IFD1 and the end of the segment
+	return int64(len(exifData) - ifd1Pos)
+}
+
+// removeGPSFromExif clears the GPS IFD pointer and wipes the GPS directory and its values,
+// so no coordinates remain in the payload even when it cannot be rebuilt afterwards.
+// The returned size covers the pointer entry, the directory and its out-of-line values.
+func removeGPSFromExif(exifData []byte) ([]byte, bool, int64) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
 		return exifData, false, 0
 	}
 
-	result := make([]byte, len(exifData))
-	copy(result, exifData)
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+	for _, entry := range entries {
+		if entry.Tag != 0x8825 { // GPS IFD Pointer
+			continue
+		}
+		result := make([]byte, len(exifData))
+		copy(result, exifData)
+		clear(result[entry.Pos : entry.Pos+12])
+		removedSize := int64(12)
+		if gpsOffset := entry.offset(order); gpsOffset != 0 {
+			removedSize += wipeIFD(result, exifData, order, tiffHeaderPos+int(gpsOffset))
+		}
+		return result, true, removedSize
+	}
+	return exifData, false, 0
+}
 
-	entryCount := int(readUint16(exifData[ifd0Pos : ifd0Pos+2]))
-	gpsIFDOffset := uint32(0)
-	gpsTagFound := false
+// wipeIFD zeroes in dst the IFD of src at ifdPos together with its out-of-line values and
+// returns the number of bytes wiped
+func wipeIFD(dst, src []byte, order binary.ByteOrder, ifdPos int) int64 {
+	entries, _, err := readIFD(src, order, ifdPos)
+	if err != nil {
+		return 0
+	}
 
-	// Look for GPS IFD pointer tag (0x8825)
-	for i := 0; i < entryCount; i++ {
-		entryPos := ifd0Pos + 2 + i*12
-		if len(exifData) < entryPos+12 {
-			break
-		}
-		tag := readUint16(exifData[entryPos : entryPos+2])
-		if tag == 0x8825 { // GPS IFD Pointer
-			gpsTagFound = true
-			// Get GPS IFD offset
-			gpsIFDOffset = readUint32(exifData[entryPos+8 : entryPos+12])
-			// Set GPS IFD pointer to 0
-			writeUint32(result[entryPos+8:entryPos+12], 0)
-			break
+	wiped := int64(0)
+	for _, entry := range entries {
+		if value, ok := entry.valueBytes(src, order); ok && len(value) > 4 {
+			start := tiffHeaderPos + int(entry.offset(order))
+			clear(dst[start : start+len(value)])
+			wiped += int64(len(value))
 		}
 	}
+	end := min(ifdPos+2+12*len(entries)+4, len(dst))
+	clear(dst[ifdPos:end])
+	return wiped + int64(end-ifdPos)
+}
 
-	if !gpsTagFound || gpsIFDOffset == 0 {
-		return exifData, false, 0
+// removeCameraInfoFromExif removes camera-specific tags from EXIF data, including the
+// MakerNote of the Exif IFD
+func removeCameraInfoFromExif(exifData []byte) ([]byte, bool, int64) {
+	exifData, removed, size := removeIFD0Tags(exifData, cameraInfoTags)
+	if cleanedData, lensRemoved, lensSize := removeExifIFDTags(exifData, lensTags); lensRemoved {
+		exifData, removed, size = cleanedData, true, size+lensSize
+	}
+	if cleanedData, noteRemoved, noteSize := removeMakerNote(exifData); noteRemoved {
+		return cleanedData, true, size + noteSize
 	}
+	return exifData, removed, size
+}
 
-	// Estimate GPS data size (rough estimation)
-	gpsDataSize := int64(200) // Typical GPS IFD size
+// removeMakerNote clears the Exif IFD MakerNote entry and wipes the vendor data block it
+// points to, so serial numbers and previews inside do not survive even when the EXIF cannot
+// be rebuilt. The reported size covers the entry and the data block, which a rebuild reclaims.
+func removeMakerNote(exifData []byte) ([]byte, bool, int64) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return exifData, false, 0
+	}
+	ifd0, _, _ := readIFD(exifData, order, ifd0Pos)
+	for _, pointer := range ifd0 {
+		if pointer.Tag != 0x8769 { // Exif IFD Pointer
+			continue
+		}
+		entries, _, _ := readIFD(exifData, order, tiffHeaderPos+int(pointer.offset(order)))
+		for _, entry := range entries {
+			if entry.Tag != exifMakerNoteTag {
+				continue
+			}
+			cleaned := bytes.Clone(exifData)
+			clear(cleaned[entry.Pos : entry.Pos+12])
+			removedSize := int64(12)
+			if value, ok := entry.valueBytes(exifData, order); ok && len(value) > 4 {
+				start := tiffHeaderPos + int(entry.offset(order))
+				clear(cleaned[start : start+len(value)])
+				removedSize += int64(len(value))
+			}
+			return cleaned, true, removedSize
+		}
+	}
+	return exifData, false, 0
+}
 
-	return result, true, gpsDataSize
+// removeSoftwareFromExif removes editing-tool fingerprints from EXIF data
+func removeSoftwareFromExif(exifData []byte) ([]byte, bool, int64) {
+	return removeIFD0Tags(exifData, softwareTags)
 }
 
-// removeCameraInfoFromExif removes camera-specific tags from EXIF data
-func removeCameraInfoFromExif(exifData []byte) ([]byte, bool, int64) {
-	if len(exifData) < 6 || string(exifData[0:6]) != ExifHeader {
+// removeIFD0Tags zeroes the IFD0 entries whose tags are listed and returns the removed value size
+func removeIFD0Tags(exifData []byte, tags []uint16) ([]byte, bool, int64) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
 		return exifData, false, 0
 	}
+	return zeroIFDTags(exifData, order, ifd0Pos, tags)
+}
 
-	// TIFF header starts from byte 6
-	pos := 6
-	if len(exifData) < pos+8 {
+// removeExifIFDTags zeroes the Exif IFD entries whose tags are listed and returns the removed value size
+func removeExifIFDTags(exifData []byte, tags []uint16) ([]byte, bool, int64) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
 		return exifData, false, 0
 	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+	for _, entry := range entries {
+		if entry.Tag == 0x8769 { // Exif IFD Pointer
+			return zeroIFDTags(exifData, order, tiffHeaderPos+int(entry.offset(order)), tags)
+		}
+	}
+	return exifData, false, 0
+}
 
-	byteOrder := binary.BigEndian.Uint16(exifData[pos : pos+2])
-	littleEndian := byteOrder == 0x4949
-	var readUint16 func([]byte) uint16
-	var readUint32 func([]byte) uint32
-	if littleEndian {
-		readUint16 = func(b []byte) uint16 { return binary.LittleEndian.Uint16(b) }
-		readUint32 = func(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
-	} else {
-		readUint16 = func(b []byte) uint16 { return binary.BigEndian.Uint16(b) }
-		readUint32 = func(b []byte) uint32 { return binary.BigEndian.Uint32(b) }
+// zeroIFDTags zeroes the entries of the IFD at ifdPos whose tags are listed
+func zeroIFDTags(exifData []byte, order binary.ByteOrder, ifdPos int, tags []uint16) ([]byte, bool, int64) {
+	entries, _, _ := readIFD(exifData, order, ifdPos)
+
+	tagsToRemove := make(map[uint16]bool, len(tags))
+	for _, tag := range tags {
+		tagsToRemove[tag] = true
 	}
 
-	ifd0Offset := int(readUint32(exifData[pos+4 : pos+8]))
-	ifd0Pos := pos + ifd0Offset
-	if len(exifData) < ifd0Pos+2 {
+	result := make([]byte, len(exifData))
+	copy(result, exifData)
+	removedSize := int64(0)
+
+	// Mark tags for removal by zeroing their entries
+	for _, entry := range entries {
+		if tagsToRemove[entry.Tag] {
+			removedSize += getTagDataSize(entry.Type, entry.Count)
+			for j := 0; j < 12; j++ {
+				result[entry.Pos+j] = 0
+			}
+		}
+	}
+
+	if removedSize == 0 {
+		return exifData, false, 0
+	}
+
+	return result, true, removedSize
+}
+
+// removeNonWhitelistedTags zeroes IFD0 and Exif IFD entries whose tags are not in allowed
+func removeNonWhitelistedTags(exifData []byte, allowed map[uint16]bool) ([]byte, bool, int64) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
 		return exifData, false, 0
 	}
 
 	result := make([]byte, len(exifData))
 	copy(result, exifData)
-
-	entryCount := int(readUint16(exifData[ifd0Pos : ifd0Pos+2]))
 	removedSize := int64(0)
 
-	// Tags to remove (camera-specific)
-	cameraTagsToRemove := map[uint16]bool{
-		0x010F: true, // Make
-		0x0110: true, // Model
-		0x927C: true, // MakerNote
-		0xA005: true, // Interoperability IFD
-	}
-
-	// Mark tags for removal by setting their type to 0
-	for i := 0; i < entryCount; i++ {
-		entryPos := ifd0Pos + 2 + i*12
-		if len(exifData) < entryPos+12 {
-			break
-		}
-		tag := readUint16(exifData[entryPos : entryPos+2])
-		if cameraTagsToRemove[tag] {
-			// Get data size for this tag
-			tagType := readUint16(exifData[entryPos+2 : entryPos+4])
-			count := readUint32(exifData[entryPos+4 : entryPos+8])
-			dataSize := getTagDataSize(tagType, count)
-			removedSize += dataSize
-
-			// Zero out the tag entry
+	// zeroEntries clears disallowed entries of the IFD at ifdPos and returns the Exif IFD offset if kept
+	zeroEntries := func(ifdPos int) uint32 {
+		exifIFDOffset := uint32(0)
+		entries, _, _ := readIFD(exifData, order, ifdPos)
+		for _, entry := range entries {
+			if entry.Tag == 0 {
+				// Already removed
+				continue
+			}
+			if allowed[entry.Tag] {
+				if entry.Tag == 0x8769 { // Exif IFD Pointer
+					exifIFDOffset = entry.offset(order)
+				}
+				continue
+			}
+			removedSize += getTagDataSize(entry.Type, entry.Count)
 			for j := 0; j < 12; j++ {
-				result[entryPos+j] = 0
+				result[entry.Pos+j] = 0
 			}
 		}
+		return exifIFDOffset
+	}
+
+	if exifIFDOffset := zeroEntries(ifd0Pos); exifIFDOffset != 0 {
+		zeroEntries(tiffHeaderPos + int(exifIFDOffset))
 	}
 
 	if removedSize == 0 {
 		return exifData, false, 0
 	}
-
 	return result, true, removedSize
 }
 
@@ -3,13 +3,20 @@ package jpegmetawebstrip
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
 	"image/jpeg"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/ideamans/go-jpeg-meta-web-strip/datacreator"
 )
 
 func TestStrip(t *testing.T) {
@@ -160,37 +167,118 @@ func TestStrip(t *testing.T) {
 	}
 }
 
-// getImageMetadata uses exiftool to get metadata (if available)
+// thumbnailTagNames names the IFD1 tags reported by getImageMetadata; other tags use ExifTags
+var thumbnailTagNames = map[uint16]string{
+	0x0201: "ThumbnailOffset",
+	0x0202: "ThumbnailLength",
+}
+
+// getImageMetadata lists the metadata in a JPEG as "Name (Group): value" lines using the
+// package's own parsers, so metadata checks run hermetically without exiftool or temp files
 func getImageMetadata(t *testing.T, jpegData []byte) string {
-	// Check if exiftool is available
-	if _, err := exec.LookPath("exiftool"); err != nil {
-		t.Skip("exiftool not found, skipping metadata verification")
-		return ""
+	t.Helper()
+	b := new(strings.Builder)
+	for _, segment := range parseTestSegments(t, jpegData) {
+		switch {
+		case segment.MarkerId == markerAPP0 && bytes.HasPrefix(segment.Data, []byte("JFIF\x00")) && len(segment.Data) >= 12:
+			fmt.Fprintf(b, "XResolution (JFIF): %d\n", binary.BigEndian.Uint16(segment.Data[8:10]))
+			fmt.Fprintf(b, "YResolution (JFIF): %d\n", binary.BigEndian.Uint16(segment.Data[10:12]))
+		case segment.MarkerId == markerAPP1 && isExifSegment(segment):
+			writeExifMetadata(b, segment.Data)
+		case segment.MarkerId == markerAPP1 && isXMPSegment(segment):
+			fmt.Fprintf(b, "XMP (XMP): %d bytes\n", len(segment.Data))
+		case segment.MarkerId == markerAPP2 && bytes.HasPrefix(segment.Data, ICCHeader):
+			writeICCMetadata(b, segment)
+		case segment.MarkerId == markerAPP13:
+			fmt.Fprintf(b, "Photoshop (APP13): %d bytes\n", len(segment.Data))
+			for _, dataset := range iptcDatasets(segment.Data) {
+				fmt.Fprintf(b, "IPTC%d:%d (IPTC): %s\n", dataset.Record, dataset.Dataset, dataset.Value)
+			}
+		case segment.MarkerId == markerCOM:
+			fmt.Fprintf(b, "Comment (COM): %s\n", segment.Data)
+		}
+	}
+	if frame, ok := readFrameHeader(parseTestSegments(t, jpegData)); ok {
+		fmt.Fprintf(b, "ImageWidth (File): %d\n", frame.Width)
+		fmt.Fprintf(b, "ImageHeight (File): %d\n", frame.Height)
 	}
+	return b.String()
+}
 
-	// Write to temp file
-	tmpFile, err := os.CreateTemp("", "test-*.jpg")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
+// writeICCMetadata lists the header fields of an ICC profile held in a single APP2 segment
+func writeICCMetadata(b *strings.Builder, segment *jpegSegment) {
+	profile := assembleICCProfile([]*jpegSegment{segment})
+	if info, ok := parseICCProfile(profile); ok {
+		fmt.Fprintf(b, "ProfileDescription (ICC): %s\n", info.Description)
+		fmt.Fprintf(b, "ProfileClass (ICC): %s\n", profile[12:16])
+		fmt.Fprintf(b, "ProfileCreator (ICC): %s\n", profile[80:84])
+		fmt.Fprintf(b, "ColorSpaceData (ICC): %s\n", info.ColorSpace)
 	}
-	defer os.Remove(tmpFile.Name())
+}
 
-	if _, err := tmpFile.Write(jpegData); err != nil {
-		t.Fatalf("Failed to write temp file: %v", err)
+// testTagName names an EXIF tag for writeExifMetadata; GPS tags are named by their ID
+func testTagName(group string, tag uint16) (string, bool) {
+	if group == "GPS" {
+		return fmt.Sprintf("GPS0x%04X", tag), true
+	}
+	if info, known := ExifTags[tag]; known {
+		return info.Name, true
 	}
-	tmpFile.Close()
+	name, ok := thumbnailTagNames[tag]
+	return name, ok
+}
 
-	// Run exiftool
-	cmd := exec.Command("exiftool", tmpFile.Name())
-	output, err := cmd.Output()
+// writeExifMetadata lists the named tags of every IFD in an EXIF payload
+func writeExifMetadata(b *strings.Builder, exifData []byte) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
 	if err != nil {
-		t.Fatalf("Failed to run exiftool: %v", err)
+		return
 	}
 
-	return string(output)
+	var walk func(group string, ifdPos int)
+	walk = func(group string, ifdPos int) {
+		entries, next, err := readIFD(exifData, order, ifdPos)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			isPointer := entry.Tag == 0x8769 || entry.Tag == 0x8825 || entry.Tag == 0xA005
+			if isPointer && entry.offset(order) == 0 {
+				// Cleared pointers no longer reference a directory
+				continue
+			}
+			if name, ok := testTagName(group, entry.Tag); ok {
+				fmt.Fprintf(b, "%s (%s): type=%d count=%d\n", name, group, entry.Type, entry.Count)
+			}
+			switch entry.Tag {
+			case 0x8769:
+				walk("ExifIFD", tiffHeaderPos+int(entry.offset(order)))
+			case 0x8825:
+				walk("GPS", tiffHeaderPos+int(entry.offset(order)))
+			}
+		}
+		if group == "IFD0" && next != 0 {
+			walk("IFD1", tiffHeaderPos+int(next))
+		}
+	}
+	walk("IFD0", ifd0Pos)
+
+	if length := getThumbnailLength(exifData); length > 0 {
+		fmt.Fprintf(b, "ThumbnailImage (IFD1): %d bytes\n", length)
+	}
+}
+
+// parseTestSegments parses JPEG data into segments, failing the test on error
+func parseTestSegments(t *testing.T, jpegData []byte) []*jpegSegment {
+	t.Helper()
+	sl, err := scanSegments(jpegData)
+	if err != nil {
+		t.Fatalf("Failed to parse JPEG: %v", err)
+	}
+	return sl.Segments()
 }
 
-// getMetadataKeys extracts metadata field names from exiftool output
+// getMetadataKeys extracts metadata field names from getImageMetadata output
 func getMetadataKeys(metadata string) []string {
 	keys := []string{}
 	lines := strings.Split(metadata, "\n")
@@ -210,7 +298,6 @@ func getMetadataKeys(metadata string) []string {
 func containsMetadata(metadata, tag string) bool {
 	lines := strings.Split(metadata, "\n")
 	for _, line := range lines {
-		// Check if line starts with the tag name (considering exiftool format)
 		parts := strings.SplitN(line, ":", 2)
 		if len(parts) >= 1 {
 			fieldName := strings.TrimSpace(parts[0])
@@ -232,6 +319,227 @@ func isValidJPEG(data []byte) bool {
 	return data[0] == 0xFF && data[1] == 0xD8
 }
 
+// TestICCProfilePreserved verifies that APP2 ICC segments are byte-identical before and after stripping
+func TestICCProfilePreserved(t *testing.T) {
+	testFiles := []string{
+		"with_icc_profile_srgb.jpg",
+		"with_icc_profile_p3.jpg",
+		"with_thumbnail_and_icc.jpg",
+	}
+
+	for _, filename := range testFiles {
+		t.Run(filename, func(t *testing.T) {
+			jpegData, err := os.ReadFile(filepath.Join("testdata", filename))
+			if err != nil {
+				t.Fatalf("Failed to read test file %s: %v", filename, err)
+			}
+
+			cleanedData, _, err := Strip(jpegData)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+
+			originalICC := getSegmentPayloads(t, jpegData, markerAPP2)
+			cleanedICC := getSegmentPayloads(t, cleanedData, markerAPP2)
+			if len(originalICC) == 0 {
+				t.Fatal("Expected test file to contain an ICC profile")
+			}
+			if len(originalICC) != len(cleanedICC) {
+				t.Fatalf("APP2 segment count changed: original=%d, cleaned=%d", len(originalICC), len(cleanedICC))
+			}
+			for i := range originalICC {
+				if !bytes.Equal(originalICC[i], cleanedICC[i]) {
+					t.Errorf("APP2 segment %d differs after strip", i)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyICCPreserved(t *testing.T) {
+	icc := &jpegSegment{MarkerId: markerAPP2, Data: []byte("ICC_PROFILE\x00\x01\x01data")}
+	modified := &jpegSegment{MarkerId: markerAPP2, Data: []byte("ICC_PROFILE\x00\x01\x01date")}
+
+	if err := verifyICCPreserved([]*jpegSegment{icc}, []*jpegSegment{icc}); err != nil {
+		t.Errorf("Expected identical segments to pass, got %v", err)
+	}
+	if err := verifyICCPreserved([]*jpegSegment{icc}, nil); err == nil {
+		t.Error("Expected error when APP2 segment is dropped")
+	}
+	if err := verifyICCPreserved([]*jpegSegment{icc}, []*jpegSegment{modified}); err == nil {
+		t.Error("Expected error when APP2 segment is modified")
+	}
+}
+
+// TestMarkerContract verifies that processSegment honors the exported marker sets
+func TestMarkerContract(t *testing.T) {
+	for _, marker := range PreservedMarkers() {
+		segment := &jpegSegment{MarkerId: marker, Data: []byte("payload")}
+		if _, keep := processSegment(segment, &Result{}, newOptions(nil)); !keep {
+			t.Errorf("Expected marker 0x%02X to be preserved", marker)
+		}
+	}
+
+	for _, marker := range RemovedMarkers() {
+		segment := &jpegSegment{MarkerId: marker, Data: []byte("payload")}
+		result := &Result{}
+		if _, keep := processSegment(segment, result, newOptions(nil)); keep {
+			t.Errorf("Expected marker 0x%02X to be removed", marker)
+		}
+		if result.Total != int64(len(segment.Data)) {
+			t.Errorf("Expected removal of marker 0x%02X to be counted, got %d", marker, result.Total)
+		}
+	}
+	// Callers get copies and cannot change the contract
+	PreservedMarkers()[0] = markerCOM
+	RemovedMarkers()[0] = markerAPP2
+	if PreservedMarkers()[0] != markerSOI || RemovedMarkers()[0] != markerAPP13 {
+		t.Error("Expected the marker sets to be returned as copies")
+	}
+}
+
+// assertPixelsUnchanged fails the test if the decoded pixels of two JPEGs differ
+func assertPixelsUnchanged(t *testing.T, original, cleaned []byte) {
+	t.Helper()
+	originalChecksum, err := getJPEGPixelChecksum(original)
+	if err != nil {
+		t.Fatalf("Failed to decode original JPEG: %v", err)
+	}
+	cleanedChecksum, err := getJPEGPixelChecksum(cleaned)
+	if err != nil {
+		t.Fatalf("Failed to decode cleaned JPEG: %v", err)
+	}
+	if originalChecksum != cleanedChecksum {
+		t.Errorf("Pixel data checksum mismatch: original=%s, cleaned=%s", originalChecksum, cleanedChecksum)
+	}
+}
+
+// insertSegment returns a copy of jpegData with a segment inserted right after SOI
+func insertSegment(jpegData []byte, markerID byte, payload []byte) []byte {
+	segment := []byte{0xFF, markerID, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+
+	out := make([]byte, 0, len(jpegData)+len(segment))
+	out = append(out, jpegData[:2]...)
+	out = append(out, segment...)
+	out = append(out, jpegData[2:]...)
+	return out
+}
+
+func TestValidateOrFallback(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comment.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleanedData, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.FellBack {
+		t.Fatalf("Unexpected fallback: %s", result.FallbackReason)
+	}
+
+	// Simulate a writer bug that truncates the output
+	truncated := cleanedData[:len(cleanedData)/2]
+	output, fallbackResult, err := validateOrFallback(jpegData, truncated, nil, result)
+	if err != nil {
+		t.Fatalf("Expected fallback rather than error, got %v", err)
+	}
+	if !bytes.Equal(output, jpegData) {
+		t.Error("Expected original data to be returned on validation failure")
+	}
+	if !fallbackResult.FellBack || fallbackResult.FallbackReason == "" {
+		t.Error("Expected FellBack with a reason")
+	}
+	if fallbackResult.Total != 0 {
+		t.Errorf("Expected no removal to be reported on fallback, got %d", fallbackResult.Total)
+	}
+}
+
+func TestRemoveImageUniqueID(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.short(0x0112, 1)}
+	e.exifIFD = []testTag{
+		e.short(0xA001, 1),
+		e.ascii(0xA420, "0123456789abcdef0123456789abcdef"),
+	}
+	jpegData = insertSegment(jpegData, 0xE1, e.build())
+
+	cleanedData, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	exifData := getSegmentPayloads(t, cleanedData, 0xE1)[0]
+	if _, ok := findTag(t, exifData, 0xA420); ok {
+		t.Error("Expected ImageUniqueID to be removed")
+	}
+	if _, ok := findTag(t, exifData, 0xA001); !ok {
+		t.Error("Expected ColorSpace to be preserved")
+	}
+	if result.Removed.Identifiers != 33 {
+		t.Errorf("Expected 33 bytes under Identifiers, got %d", result.Removed.Identifiers)
+	}
+}
+
+// TestRemovableExifTagCategories verifies that every removable tag belongs to exactly one category
+func TestRemovableExifTagCategories(t *testing.T) {
+	categorized := map[uint16]int{0x8825: 1} // GPS IFD Pointer is handled by removeGPSFromExif
+	for _, tag := range append(append(append(append([]uint16{}, cameraInfoTags...), lensTags...), softwareTags...), identifierTags...) {
+		categorized[tag]++
+	}
+	removable := RemovableExifTags()
+	if len(categorized) != len(removable) {
+		t.Errorf("Expected %d categorized tags, got %d", len(removable), len(categorized))
+	}
+	for _, tag := range removable {
+		if categorized[tag] != 1 {
+			t.Errorf("Tag 0x%04X is in %d categories", tag, categorized[tag])
+		}
+	}
+
+	// Callers get a copy and cannot change the contract
+	removable[0] = 0x0112
+	if RemovableExifTags()[0] != 0x000B {
+		t.Error("Expected RemovableExifTags to return a copy")
+	}
+}
+
+func TestVerifyFrameUnchanged(t *testing.T) {
+	sof := &jpegSegment{MarkerId: markerSOF0, Data: []byte{8, 0x00, 0xD6, 0x00, 0xF0, 3}}
+	resized := &jpegSegment{MarkerId: markerSOF0, Data: []byte{8, 0x00, 0xD5, 0x00, 0xF0, 3}}
+	dht := &jpegSegment{MarkerId: markerDHT, Data: []byte{0, 1, 2, 3, 4, 5}}
+
+	if err := verifyFrameUnchanged([]*jpegSegment{dht, sof}, []*jpegSegment{sof}); err != nil {
+		t.Errorf("Expected identical frames to pass, got %v", err)
+	}
+	if err := verifyFrameUnchanged([]*jpegSegment{sof}, []*jpegSegment{resized}); !errors.Is(err, ErrFrameChanged) {
+		t.Errorf("Expected ErrFrameChanged for changed height, got %v", err)
+	}
+	if err := verifyFrameUnchanged([]*jpegSegment{sof}, []*jpegSegment{dht}); !errors.Is(err, ErrFrameChanged) {
+		t.Errorf("Expected ErrFrameChanged for missing SOF, got %v", err)
+	}
+}
+
+// getSegmentPayloads parses JPEG data and returns payloads of all segments with the given marker
+func getSegmentPayloads(t *testing.T, jpegData []byte, markerID byte) [][]byte {
+	sl, err := scanSegments(jpegData)
+	if err != nil {
+		t.Fatalf("Failed to parse JPEG: %v", err)
+	}
+
+	payloads := [][]byte{}
+	for _, segment := range sl.Segments() {
+		if segment.MarkerId == markerID {
+			payloads = append(payloads, segment.Data)
+		}
+	}
+	return payloads
+}
+
 func TestStripInvalidData(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -252,6 +560,53 @@ func TestStripInvalidData(t *testing.T) {
 	}
 }
 
+func TestStripUnchanged(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	cleaned, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Unchanged {
+		t.Error("Expected a file with metadata to be rewritten")
+	}
+
+	// A clean file comes back as the very same slice
+	output, result, err := Strip(cleaned)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if !result.Unchanged || &output[0] != &cleaned[0] {
+		t.Errorf("Expected the input slice to be returned untouched, got Unchanged=%v Total=%d", result.Unchanged, result.Total)
+	}
+
+	// A trailer or fill bytes are dropped, as on every rewrite, so the file is not returned as is
+	for name, input := range map[string][]byte{
+		"trailer":    append(bytes.Clone(cleaned), "trailer"...),
+		"fill bytes": append([]byte{0xFF, 0xD8, 0xFF}, cleaned[2:]...),
+	} {
+		output, result, err := Strip(input)
+		if err != nil {
+			t.Fatalf("%s: Strip failed: %v", name, err)
+		}
+		if result.Unchanged || !bytes.Equal(output, cleaned) {
+			t.Errorf("%s: expected it to be dropped, got Unchanged=%v and %d bytes", name, result.Unchanged, len(output))
+		}
+	}
+
+	// Rewrites that remove nothing still produce new output
+	output, result, err = Strip(cleaned, WithProcessedComment("web"))
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Unchanged || bytes.Equal(output, cleaned) {
+		t.Error("Expected an inserted marker not to be reported as unchanged")
+	}
+
+}
+
 // TestJpegDecodeIntegrity verifies that JPEG decoding produces identical results before and after metadata removal
 func TestJpegDecodeIntegrity(t *testing.T) {
 	testFiles := []string{
@@ -335,3 +690,157 @@ func getJPEGPixelChecksum(jpegData []byte) (string, error) {
 
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
+
+// categoriesWithoutFixture lists Result.Removed categories that no fixture can exercise,
+// because the fixtures are stripped with default options or the data cannot be generated;
+// they are covered by synthetic segments in unit tests instead. Adding a category to
+// Result.Removed requires either a fixture in datacreator.Manifest or an entry here.
+var categoriesWithoutFixture = map[string]bool{
+	"IPTC":      true, // never counted by default: the whole APP13 is counted under PhotoshopIRB
+	"Telemetry": true, // ImageMagick and exiftool cannot write DJI, GoPro or Ricoh segments
+	"Thermal":   true, // only counted with WithRemoveRadiometric
+	"Adobe":     true, // only counted with WithMinimizeAdobe
+	"Other":     true, // only counted in whitelist mode
+}
+
+// TestFixtureManifest keeps the datacreator manifest and the Result categories in lockstep
+func TestFixtureManifest(t *testing.T) {
+	removed := reflect.TypeOf(Result{}.Removed)
+	covered := make(map[string]bool)
+
+	for _, fixture := range datacreator.Manifest {
+		t.Run(fixture.Name, func(t *testing.T) {
+			assertFixtureRemovals(t, fixture, covered)
+		})
+	}
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	listed := make(map[string]bool)
+	for _, fixture := range datacreator.Manifest {
+		listed[fixture.Name] = true
+	}
+	for _, file := range files {
+		if !listed[filepath.Base(file)] {
+			t.Errorf("Fixture %s is missing from datacreator.Manifest", filepath.Base(file))
+		}
+	}
+
+	for i := 0; i < removed.NumField(); i++ {
+		name := removed.Field(i).Name
+		switch {
+		case !covered[name] && !categoriesWithoutFixture[name]:
+			t.Errorf("Category %s has no fixture in datacreator.Manifest", name)
+		case covered[name] && categoriesWithoutFixture[name]:
+			t.Errorf("Category %s now has a fixture; remove it from categoriesWithoutFixture", name)
+		}
+	}
+}
+
+// assertFixtureRemovals checks that stripping the fixture removes exactly its manifest
+// categories and marks them in covered
+func assertFixtureRemovals(t *testing.T, fixture datacreator.Fixture, covered map[string]bool) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", fixture.Name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	removed := reflect.TypeOf(Result{}.Removed)
+	expected := make(map[string]bool)
+	for _, category := range fixture.Categories {
+		if _, ok := removed.FieldByName(category); !ok {
+			t.Errorf("Manifest names unknown category %q", category)
+		}
+		expected[category] = true
+		covered[category] = true
+	}
+
+	values := reflect.ValueOf(result.Removed)
+	for i := 0; i < removed.NumField(); i++ {
+		name := removed.Field(i).Name
+		if got := values.Field(i).Int() > 0; got != expected[name] {
+			t.Errorf("Category %s: expected removal %v, got %d bytes", name, expected[name], values.Field(i).Int())
+		}
+	}
+}
+
+// Allocation ceilings for TestStripAllocations, about 25% above the measured counts.
+// Raise them only together with an explanation of where the new allocations come from.
+const (
+	maxStripAllocs      = 450
+	maxCleanStripAllocs = 320
+)
+
+func TestStripAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocation guard skipped in short mode")
+	}
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	clean, _, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		limit float64
+	}{
+		{"standard", jpegData, maxStripAllocs},
+		{"already clean", clean, maxCleanStripAllocs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(20, func() {
+				_, _, _ = Strip(tt.data)
+			})
+			if allocs > tt.limit {
+				t.Errorf("Strip allocated %.0f times per run, limit is %.0f", allocs, tt.limit)
+			}
+		})
+	}
+}
+
+var benchCorpus = flag.String("corpus", "", "benchmark corpus directory fetched with go run datacreator/cmd/main.go -corpus")
+
+// BenchmarkStripCorpus strips every image of a fetched benchmark corpus per iteration.
+// Images are verified against the manifest so results are comparable across machines.
+func BenchmarkStripCorpus(b *testing.B) {
+	if *benchCorpus == "" {
+		b.Skip("no corpus; run with -corpus <dir>")
+	}
+	manifest, err := datacreator.ReadCorpusManifest(*benchCorpus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	images := make([][]byte, 0, len(manifest.Images))
+	total := int64(0)
+	for _, image := range manifest.Images {
+		data, err := os.ReadFile(filepath.Join(*benchCorpus, image.Name))
+		if err != nil {
+			b.Fatalf("Failed to read %s: %v", image.Name, err)
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != image.SHA256 {
+			b.Fatalf("%s does not match the manifest; fetch the corpus again", image.Name)
+		}
+		images = append(images, data)
+		total += image.Size
+	}
+
+	b.SetBytes(total)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, data := range images {
+			_, _, _ = Strip(data)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package jpegmetawebstrip
+
+// TagInfo names an EXIF tag and describes it for end users.
+// The descriptions are written for this package and are not derived from other tools.
+type TagInfo struct {
+	Name     string
+	English  string
+	Japanese string
+}
+
+// Description returns the description in the given language, falling back to English
+func (i TagInfo) Description(lang string) string {
+	if lang == LangJapanese {
+		return i.Japanese
+	}
+	return i.English
+}
+
+// ExifTags maps IFD0 and Exif IFD tag IDs to their names and descriptions.
+// It covers every tag the package removes or keeps by contract. Do not modify.
+var ExifTags = map[uint16]TagInfo{
+	0x000B: {"ProcessingSoftware", "Software used to process the image", "画像の処理に使用したソフトウェア"},
+	0x0100: {"ImageWidth", "Image width in pixels", "画像の幅（ピクセル）"},
+	0x0101: {"ImageLength", "Image height in pixels", "画像の高さ（ピクセル）"},
+	0x010E: {"ImageDescription", "Image title or description", "画像のタイトルまたは説明"},
+	0x010F: {"Make", "Camera manufacturer", "カメラのメーカー"},
+	0x0110: {"Model", "Camera model", "カメラの機種"},
+	0x0112: {"Orientation", "Display rotation of the image", "画像の表示向き"},
+	0x011A: {"XResolution", "Horizontal resolution", "水平解像度"},
+	0x011B: {"YResolution", "Vertical resolution", "垂直解像度"},
+	0x0128: {"ResolutionUnit", "Unit of the resolution values", "解像度の単位"},
+	0x0131: {"Software", "Software that created or edited the image", "画像を作成・編集したソフトウェア"},
+	0x0132: {"DateTime", "Date and time the file was changed", "ファイルの更新日時"},
+	0x013B: {"Artist", "Name of the photographer or creator", "撮影者または作成者の名前"},
+	0x0213: {"YCbCrPositioning", "Position of chroma samples", "色差信号の位置"},
+	0x8298: {"Copyright", "Copyright notice", "著作権表示"},
+	0x8769: {"ExifIFDPointer", "Location of the Exif data", "Exif情報の位置"},
+	0x8825: {"GPSIFDPointer", "Location of the GPS data (where the photo was taken)", "GPS情報（撮影場所）の位置"},
+	0x829A: {"ExposureTime", "Shutter speed", "シャッタースピード"},
+	0x829D: {"FNumber", "Aperture", "絞り値"},
+	0x8827: {"ISOSpeedRatings", "ISO sensitivity", "ISO感度"},
+	0x9000: {"ExifVersion", "Version of the Exif standard", "Exif規格のバージョン"},
+	0x9003: {"DateTimeOriginal", "Date and time the photo was taken", "撮影日時"},
+	0x9004: {"DateTimeDigitized", "Date and time the image was digitized", "デジタル化日時"},
+	0x920A: {"FocalLength", "Lens focal length", "レンズの焦点距離"},
+	0x927C: {"MakerNote", "Manufacturer-specific camera data", "メーカー独自のカメラ情報"},
+	0x9286: {"UserComment", "User comment", "ユーザーコメント"},
+	0xA001: {"ColorSpace", "Color space of the image", "画像の色空間"},
+	0xA002: {"PixelXDimension", "Valid image width", "有効画像の幅"},
+	0xA003: {"PixelYDimension", "Valid image height", "有効画像の高さ"},
+	0xA005: {"InteroperabilityIFDPointer", "Location of the interoperability data", "互換性情報の位置"},
+	0xA420: {"ImageUniqueID", "Unique identifier of the image", "画像の一意な識別子"},
+	0xA430: {"CameraOwnerName", "Name of the camera owner", "カメラ所有者の名前"},
+	0xA431: {"BodySerialNumber", "Camera body serial number", "カメラ本体のシリアル番号"},
+	0xA433: {"LensMake", "Lens manufacturer", "レンズのメーカー"},
+	0xA434: {"LensModel", "Lens model", "レンズの機種"},
+	0xA435: {"LensSerialNumber", "Lens serial number", "レンズのシリアル番号"},
+	0xA500: {"Gamma", "Gamma value", "ガンマ値"},
+}
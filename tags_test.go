@@ -0,0 +1,40 @@
+package jpegmetawebstrip
+
+import "testing"
+
+func TestExifTagsCoverContract(t *testing.T) {
+	lists := map[string][]uint16{
+		"RemovableExifTags":        RemovableExifTags(),
+		"DefaultWhitelistExifTags": DefaultWhitelistExifTags(),
+		"identifierTags":           identifierTags,
+	}
+	for tag := range copyrightExifTags {
+		lists["copyrightExifTags"] = append(lists["copyrightExifTags"], tag)
+	}
+
+	for list, tags := range lists {
+		for _, tag := range tags {
+			info, ok := ExifTags[tag]
+			if !ok {
+				t.Errorf("%s: tag 0x%04X has no ExifTags entry", list, tag)
+				continue
+			}
+			if info.Name == "" || info.English == "" || info.Japanese == "" {
+				t.Errorf("%s: tag 0x%04X has an incomplete entry %+v", list, tag, info)
+			}
+		}
+	}
+}
+
+func TestTagInfoDescription(t *testing.T) {
+	info := ExifTags[0x8825]
+	if info.Description(LangEnglish) != info.English {
+		t.Errorf("Expected English description, got %q", info.Description(LangEnglish))
+	}
+	if info.Description(LangJapanese) != info.Japanese {
+		t.Errorf("Expected Japanese description, got %q", info.Description(LangJapanese))
+	}
+	if info.Description("fr") != info.English {
+		t.Errorf("Expected unknown language to fall back to English, got %q", info.Description("fr"))
+	}
+}
@@ -0,0 +1,34 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+)
+
+// telemetrySignature identifies a vendor telemetry or stitching segment by marker and payload prefix
+type telemetrySignature struct {
+	marker byte
+	prefix []byte
+	vendor string
+}
+
+// telemetrySignatures lists the drone and action/360 camera segments removed as
+// Result.Removed.Telemetry. They can hold flight paths and sensor logs and are never
+// needed for display.
+var telemetrySignatures = []telemetrySignature{
+	{markerAPP4, []byte{0xAA, 0x55, 0x12, 0x06}, "DJI"}, // Thermal parameters
+	{markerAPP4, []byte{0xAA, 0x55, 0x38, 0x00}, "DJI"},
+	{markerAPP4, []byte{0x2C, 0x01, 0x20, 0x00}, "DJI"},
+	{markerAPP4, []byte("DJI"), "DJI"},
+	{markerAPP5, []byte("RMETA\x00"), "Ricoh"}, // Theta and other Ricoh metadata
+	{markerAPP6, []byte("GoPro\x00"), "GoPro"}, // GPMF telemetry
+}
+
+// telemetryVendor returns the vendor of a telemetry segment, or "" if it is not one
+func telemetryVendor(segment *jpegSegment) string {
+	for _, signature := range telemetrySignatures {
+		if segment.MarkerId == signature.marker && bytes.HasPrefix(segment.Data, signature.prefix) {
+			return signature.vendor
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripTelemetry(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	gopro := append([]byte("GoPro\x00"), []byte("DEVC....GPS5 flight path")...)
+	ricoh := append([]byte("RMETA\x00"), []byte("stitching")...)
+	dji := append([]byte{0xAA, 0x55, 0x12, 0x06}, []byte("thermal")...)
+	vendor := []byte("other vendor data")
+	withTelemetry := insertSegment(jpegData, 0xE6, gopro)
+	withTelemetry = insertSegment(withTelemetry, 0xE5, ricoh)
+	withTelemetry = insertSegment(withTelemetry, 0xE4, dji)
+	withTelemetry = insertSegment(withTelemetry, 0xE6, vendor)
+	telemetrySize := int64(len(gopro) + len(ricoh) + len(dji))
+
+	for _, tc := range []struct {
+		name   string
+		opts   []Option
+		remove bool
+	}{
+		{"Default", nil, true},
+		{"Privacy", PresetPrivacy.Options, true},
+		{"Whitelist", []Option{WithWhitelistMode()}, true},
+		{"Conservative", PresetConservative.Options, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cleaned, result, err := Strip(withTelemetry, tc.opts...)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			assertPixelsUnchanged(t, jpegData, cleaned)
+
+			if !tc.remove {
+				if result.Removed.Telemetry != 0 || len(getSegmentPayloads(t, cleaned, 0xE4)) != 1 {
+					t.Errorf("Expected telemetry to be kept, removed %d bytes", result.Removed.Telemetry)
+				}
+				return
+			}
+			if result.Removed.Telemetry != telemetrySize {
+				t.Errorf("Expected %d telemetry bytes removed, got %d", telemetrySize, result.Removed.Telemetry)
+			}
+			if len(getSegmentPayloads(t, cleaned, 0xE4)) != 0 || len(getSegmentPayloads(t, cleaned, 0xE5)) != 0 {
+				t.Error("Expected the DJI and Ricoh segments to be removed")
+			}
+			for _, payload := range getSegmentPayloads(t, cleaned, 0xE6) {
+				if strings.HasPrefix(string(payload), "GoPro") {
+					t.Error("Expected the GoPro segment to be removed")
+				}
+			}
+		})
+	}
+
+	// Telemetry is understood, so strict mode does not refuse it
+	if _, _, err := Strip(insertSegment(jpegData, 0xE6, gopro), WithStrictUnknown()); err != nil {
+		t.Errorf("Expected telemetry to pass strict mode, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+)
+
+// FLIRHeader starts each APP1 segment of a FLIR thermal JPEG's FFF record, which
+// holds the raw radiometric data split over several segments
+const FLIRHeader = "FLIR\x00"
+
+// isFLIRSegment reports whether segment carries FLIR radiometric data
+func isFLIRSegment(segment *jpegSegment) bool {
+	return segment.MarkerId == markerAPP1 && bytes.HasPrefix(segment.Data, []byte(FLIRHeader))
+}
+
+// processFLIRSegment keeps radiometric data with a warning, because removing it destroys
+// the temperature measurements, or removes it as Thermal with WithRemoveRadiometric
+func processFLIRSegment(segment *jpegSegment, result *Result, removedSize int64, o *options) (*jpegSegment, bool) {
+	if o.removeRadiometric {
+		result.Removed.Thermal += removedSize
+		result.Total += removedSize
+		return segment, false
+	}
+	for _, w := range result.Warnings {
+		if w.Code == WarningRadiometric {
+			return segment, true
+		}
+	}
+	result.addWarnings([]Warning{{Code: WarningRadiometric, Source: "FLIR APP1", Text: "radiometric data kept"}})
+	return segment, true
+}
@@ -0,0 +1,65 @@
+package jpegmetawebstrip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFLIRRadiometricData(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	// FFF records are split over several APP1 segments with an index and the last index
+	first := append([]byte(FLIRHeader+"\x01\x00\x01"), []byte("FFF\x00 radiometric raw data")...)
+	second := append([]byte(FLIRHeader+"\x01\x01\x01"), []byte("more raw data")...)
+	withFLIR := insertSegment(insertSegment(jpegData, 0xE1, second), 0xE1, first)
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Default", nil},
+		{"Whitelist", []Option{WithWhitelistMode()}},
+		{"Privacy", PresetPrivacy.Options},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cleaned, result, err := Strip(withFLIR, tc.opts...)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			if len(getSegmentPayloads(t, cleaned, 0xE1)) != 2 || result.Removed.Thermal != 0 {
+				t.Errorf("Expected both FLIR segments to be kept, removed %d bytes", result.Removed.Thermal)
+			}
+			warnings := 0
+			for _, w := range result.Warnings {
+				if w.Code == WarningRadiometric {
+					warnings++
+				}
+			}
+			if warnings != 1 {
+				t.Errorf("Expected one %s warning, got %v", WarningRadiometric, result.Warnings)
+			}
+		})
+	}
+
+	t.Run("Removed", func(t *testing.T) {
+		for _, opts := range [][]Option{{WithRemoveRadiometric()}, PresetAggressive.Options} {
+			cleaned, result, err := Strip(withFLIR, opts...)
+			if err != nil {
+				t.Fatalf("Strip failed: %v", err)
+			}
+			assertPixelsUnchanged(t, jpegData, cleaned)
+			if len(getSegmentPayloads(t, cleaned, 0xE1)) != 0 {
+				t.Error("Expected the FLIR segments to be removed")
+			}
+			if result.Removed.Thermal != int64(len(first)+len(second)) {
+				t.Errorf("Expected %d thermal bytes removed, got %d", len(first)+len(second), result.Removed.Thermal)
+			}
+			if len(result.Warnings) != 0 {
+				t.Errorf("Expected no warnings, got %v", result.Warnings)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package jpegmetawebstrip
+
+// ifd1Position returns the position of IFD1 within an EXIF payload, or false when there is none
+func ifd1Position(exifData []byte) (int, bool) {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return 0, false
+	}
+	_, ifd1Offset, err := readIFD(exifData, order, ifd0Pos)
+	if err != nil || ifd1Offset == 0 {
+		return 0, false
+	}
+	return tiffHeaderPos + int(ifd1Offset), true
+}
+
+// removeIFD1Tags zeroes the IFD1 entries whose tags are listed and returns the removed value size.
+// Some vendors duplicate GPS and camera tags into the thumbnail directory.
+func removeIFD1Tags(exifData []byte, tags []uint16) ([]byte, bool, int64) {
+	ifd1Pos, ok := ifd1Position(exifData)
+	if !ok {
+		return exifData, false, 0
+	}
+	order, _, _ := exifByteOrder(exifData)
+	return zeroIFDTags(exifData, order, ifd1Pos, tags)
+}
+
+// alignThumbnailOrientation sets the IFD1 Orientation to the IFD0 value (1 when IFD0 has none),
+// so viewers that show the thumbnail rotate it the same way as the main image
+func alignThumbnailOrientation(exifData []byte) ([]byte, bool) {
+	ifd1Pos, ok := ifd1Position(exifData)
+	if !ok {
+		return exifData, false
+	}
+	order, _, _ := exifByteOrder(exifData)
+	want, ok := exifOrientation(exifData)
+	if !ok {
+		want = 1
+	}
+
+	entries, _, _ := readIFD(exifData, order, ifd1Pos)
+	for _, entry := range entries {
+		if entry.Tag != exifOrientationTag || entry.Type != 3 || order.Uint16(entry.Value[0:2]) == want {
+			continue
+		}
+		result := make([]byte, len(exifData))
+		copy(result, exifData)
+		order.PutUint16(result[entry.Pos+8:entry.Pos+10], want)
+		return result, true
+	}
+	return exifData, false
+}
@@ -0,0 +1,104 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeptThumbnailCleanup(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			e := newTestExif(order)
+			e.ifd0 = []testTag{e.short(0x0112, 6)}
+			e.ifd1 = []testTag{e.short(0x0112, 1), e.ascii(0x010F, "TestCamera"), e.long(0x8825, 0)}
+			e.thumbnail = []byte{0xFF, 0xD8, 0xFF, 0xD9}
+			exifData := e.build()
+
+			result := &Result{}
+			cleaned, modified, _ := cleanExifSegment(exifData, result, newOptions([]Option{WithThumbnailMaxBytes(1 << 10)}))
+			if !modified {
+				t.Fatal("Expected the thumbnail directory to be cleaned")
+			}
+			if result.Removed.ExifThumbnail != 0 {
+				t.Error("Thumbnail should have been kept")
+			}
+			if result.Removed.CameraInfo == 0 || result.Removed.ExifGPS == 0 {
+				t.Errorf("Expected IFD1 camera and GPS tags to be counted, got %+v", result.Removed)
+			}
+
+			ifd1Pos, ok := ifd1Position(cleaned)
+			if !ok {
+				t.Fatal("IFD1 was removed")
+			}
+			entries, _, err := readIFD(cleaned, order, ifd1Pos)
+			if err != nil {
+				t.Fatalf("Failed to read IFD1: %v", err)
+			}
+			for _, entry := range entries {
+				switch entry.Tag {
+				case 0x010F, 0x8825:
+					t.Errorf("Tag 0x%04X should have been removed from IFD1", entry.Tag)
+				case 0x0112:
+					if v := order.Uint16(entry.Value[0:2]); v != 6 {
+						t.Errorf("Expected IFD1 orientation 6, got %d", v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWithThumbnailOnly(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_comprehensive_mixed.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	withTrailer := append(append([]byte{}, jpegData...), []byte("trailing vendor data")...)
+
+	cleaned, result, err := Strip(withTrailer, WithThumbnailOnly())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if result.Removed.ExifThumbnail == 0 || result.Total != result.Removed.ExifThumbnail {
+		t.Errorf("Expected only the thumbnail to be removed, got %+v", result.Removed)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no removal warnings, got %v", result.Warnings)
+	}
+	assertPixelsUnchanged(t, jpegData, cleaned)
+
+	// Every segment other than EXIF is untouched and every EXIF tag survives
+	original, output := parseTestSegments(t, jpegData), parseTestSegments(t, cleaned)
+	if len(original) != len(output) {
+		t.Fatalf("Expected %d segments, got %d", len(original), len(output))
+	}
+	for i := range original {
+		if isExifSegment(original[i]) {
+			continue
+		}
+		if original[i].MarkerId != output[i].MarkerId || !bytes.Equal(original[i].Data, output[i].Data) {
+			t.Errorf("segment %d (%s) changed", i, original[i].MarkerName)
+		}
+	}
+	before, _ := Inspect(jpegData)
+	after, _ := Inspect(cleaned)
+	if !equalTags(before.ExifTags, after.ExifTags) {
+		t.Errorf("EXIF tags changed: %v -> %v", before.ExifTags, after.ExifTags)
+	}
+
+	// StripStream drops the trailer too
+	streamed := new(bytes.Buffer)
+	if _, err := StripStream(bytes.NewReader(withTrailer), streamed, WithThumbnailOnly()); err != nil {
+		t.Fatalf("StripStream failed: %v", err)
+	}
+	if !bytes.Equal(streamed.Bytes(), cleaned) {
+		t.Error("Expected StripStream to match Strip")
+	}
+
+	if err := NewPolicy("conservative", WithThumbnailOnly(), WithWhitelistMode()).Validate(); err == nil {
+		t.Error("Expected thumbnail-only with a whitelist to be reported")
+	}
+}
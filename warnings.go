@@ -0,0 +1,118 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Warning codes reported in Result.Warnings
+const (
+	// WarningCopyrightRemoved means copyright or creator attribution was removed
+	WarningCopyrightRemoved = "CopyrightRemoved"
+	// WarningICCv4 means the output keeps an ICC v4 profile, which some older renderers ignore
+	WarningICCv4 = "ICCv4Profile"
+	// WarningIFDLimit means an EXIF segment was dropped because it exceeded the IFD limits
+	WarningIFDLimit = "IFDLimitExceeded"
+	// WarningRadiometric means FLIR radiometric data was kept; see WithRemoveRadiometric
+	WarningRadiometric = "RadiometricData"
+)
+
+// Warning describes something an integrator may want to act on, such as removed rights information
+type Warning struct {
+	// Code identifies the kind of warning, e.g. WarningCopyrightRemoved
+	Code string
+	// Source names where the data was found, e.g. "EXIF Copyright" or "IPTC By-line"
+	Source string
+	// Text is the affected value
+	Text string
+}
+
+// copyrightXMPProperties are the XMP properties treated as rights attribution
+var copyrightXMPProperties = []xmpProperty{
+	{Namespace: dcNS, Prefix: "dc", Name: "rights", Container: "Alt"},
+	{Namespace: dcNS, Prefix: "dc", Name: "creator", Container: "Seq"},
+}
+
+// copyrightExifTags maps IFD0 attribution tags to their names
+var copyrightExifTags = map[uint16]string{
+	0x013B: "Artist",
+	0x8298: "Copyright",
+}
+
+// copyrightIPTCDatasets maps record 2 attribution datasets to their names
+var copyrightIPTCDatasets = map[byte]string{
+	80:  "By-line",
+	116: "CopyrightNotice",
+}
+
+// addWarnings appends warnings to the result
+func (r *Result) addWarnings(warnings []Warning) {
+	r.Warnings = append(r.Warnings, warnings...)
+}
+
+// copyrightFromExif returns a warning for each attribution tag present in IFD0
+func copyrightFromExif(exifData []byte) []Warning {
+	order, ifd0Pos, err := exifByteOrder(exifData)
+	if err != nil {
+		return nil
+	}
+	entries, _, _ := readIFD(exifData, order, ifd0Pos)
+
+	warnings := make([]Warning, 0)
+	for _, entry := range entries {
+		name, ok := copyrightExifTags[entry.Tag]
+		if !ok {
+			continue
+		}
+		value, ok := entry.valueBytes(exifData, order)
+		if !ok {
+			continue
+		}
+		if text := strings.TrimRight(string(value), "\x00 "); text != "" {
+			warnings = append(warnings, Warning{Code: WarningCopyrightRemoved, Source: "EXIF " + name, Text: text})
+		}
+	}
+	return warnings
+}
+
+// removedExifCopyright returns warnings for attribution present before cleaning but missing after
+func removedExifCopyright(before, after []byte) []Warning {
+	kept := make(map[string]bool)
+	for _, w := range copyrightFromExif(after) {
+		kept[w.Source] = true
+	}
+	removed := make([]Warning, 0)
+	for _, w := range copyrightFromExif(before) {
+		if !kept[w.Source] {
+			removed = append(removed, w)
+		}
+	}
+	return removed
+}
+
+// copyrightFromXMP returns a warning for each attribution property in an XMP segment
+func copyrightFromXMP(xmpData []byte) []Warning {
+	packet := bytes.TrimPrefix(xmpData, []byte(XMPHeader))
+	warnings := make([]Warning, 0)
+	for _, p := range extractXMPProperties(packet, copyrightXMPProperties) {
+		if p.Value != "" {
+			warnings = append(warnings, Warning{Code: WarningCopyrightRemoved, Source: "XMP " + p.Prefix + ":" + p.Name, Text: p.Value})
+		}
+	}
+	return warnings
+}
+
+// copyrightFromIPTC returns a warning for each attribution dataset in an APP13 segment
+func copyrightFromIPTC(app13Data []byte) []Warning {
+	warnings := make([]Warning, 0)
+	for _, dataset := range iptcDatasets(app13Data) {
+		name, ok := copyrightIPTCDatasets[dataset.Dataset]
+		if dataset.Record != 2 || !ok {
+			continue
+		}
+		if text := strings.TrimSpace(string(dataset.Value)); text != "" {
+			warnings = append(warnings, Warning{Code: WarningCopyrightRemoved, Source: "IPTC " + name, Text: text})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,91 @@
+package jpegmetawebstrip
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// warningTexts maps warning sources to texts for easy assertions
+func warningTexts(result *Result) map[string]string {
+	texts := map[string]string{}
+	for _, w := range result.Warnings {
+		if w.Code == WarningCopyrightRemoved {
+			texts[w.Source] = w.Text
+		}
+	}
+	return texts
+}
+
+func TestCopyrightWarningsIPTC(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_iptc.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	texts := warningTexts(result)
+	if texts["IPTC CopyrightNotice"] != "Copyright 2024 Test" {
+		t.Errorf("Expected IPTC CopyrightNotice warning, got %v", texts)
+	}
+	if texts["IPTC By-line"] != "Test Photographer" {
+		t.Errorf("Expected IPTC By-line warning, got %v", texts)
+	}
+}
+
+func TestCopyrightWarningsXMP(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_xmp.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if texts := warningTexts(result); texts["XMP dc:creator"] != "Test Creator" {
+		t.Errorf("Expected XMP dc:creator warning, got %v", texts)
+	}
+}
+
+func TestCopyrightWarningsEXIFKeptByDefault(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	e := newTestExif(binary.BigEndian)
+	e.ifd0 = []testTag{e.ascii(0x010F, "Canon"), e.ascii(0x8298, "(c) 2024 Example")}
+	jpegData = insertSegment(jpegData, 0xE1, e.build())
+
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings while EXIF Copyright is preserved, got %v", result.Warnings)
+	}
+
+	_, result, err = Strip(jpegData, WithWhitelistMode())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if texts := warningTexts(result); texts["EXIF Copyright"] != "(c) 2024 Example" {
+		t.Errorf("Expected EXIF Copyright warning in whitelist mode, got %v", texts)
+	}
+}
+
+func TestCopyrightWarningsNoAttribution(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_exif_thumbnail.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	_, result, err := Strip(jpegData)
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+}
@@ -0,0 +1,239 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	// XMPHeader is the namespace header that starts a standard XMP APP1 segment
+	XMPHeader = "http://ns.adobe.com/xap/1.0/\x00"
+	// ExtendedXMPHeader starts each APP1 chunk of Extended XMP, which Photoshop and Lightroom
+	// use for packets over 64 KB; a GUID, the full length and the chunk offset follow
+	ExtendedXMPHeader = "http://ns.adobe.com/xmp/extension/\x00"
+
+	// xmpBasicNS is the XMP Basic namespace (xmp:Rating, xmp:Label, ...)
+	xmpBasicNS = "http://ns.adobe.com/xap/1.0/"
+	// dcNS is the Dublin Core namespace (dc:rights, dc:creator, ...)
+	dcNS = "http://purl.org/dc/elements/1.1/"
+	// tiffNS is the XMP TIFF namespace (tiff:Orientation, ...)
+	tiffNS = "http://ns.adobe.com/tiff/1.0/"
+	rdfNS  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// xmpProperty is a simple-valued XMP property
+type xmpProperty struct {
+	Namespace string
+	Prefix    string
+	Name      string
+	Value     string
+	// Items holds the rdf:li values of an array-valued property, which Value joins with "; "
+	Items []string
+	// Container is "Alt" or "Seq" for array-valued properties such as dc:rights and
+	// dc:creator, and empty for simple values
+	Container string
+}
+
+// ratingProperties are the XMP properties kept by WithKeepXMPRating
+var ratingProperties = []xmpProperty{
+	{Namespace: xmpBasicNS, Prefix: "xmp", Name: "Rating"},
+	{Namespace: xmpBasicNS, Prefix: "xmp", Name: "Label"},
+}
+
+// orientationProperty is kept alongside other kept XMP properties so Lightroom and browsers
+// agree on rotation; reconcileOrientation aligns it with EXIF
+var orientationProperty = xmpProperty{Namespace: tiffNS, Prefix: "tiff", Name: "Orientation"}
+
+// extractXMPProperties finds the wanted simple properties in an XMP packet, whether they are
+// written as rdf:Description attributes or as child elements. Values are filled into copies of wanted.
+func extractXMPProperties(packet []byte, wanted []xmpProperty) []xmpProperty {
+	found := make([]xmpProperty, 0)
+	seen := make(map[string]bool)
+	match := func(space, local string, items []string) {
+		for _, w := range wanted {
+			key := w.Namespace + w.Name
+			if w.Namespace == space && w.Name == local && !seen[key] {
+				seen[key] = true
+				w.Value = strings.TrimSpace(strings.Join(items, "; "))
+				w.Items = items
+				found = append(found, w)
+			}
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			match(attr.Name.Space, attr.Name.Local, []string{attr.Value})
+		}
+		if isWantedElement(start.Name, wanted) {
+			match(start.Name.Space, start.Name.Local, elementText(decoder))
+		}
+	}
+
+	// Preserve the order of wanted for stable output
+	ordered := make([]xmpProperty, 0, len(found))
+	for _, w := range wanted {
+		for _, f := range found {
+			if f.Namespace == w.Namespace && f.Name == w.Name {
+				ordered = append(ordered, f)
+			}
+		}
+	}
+	return ordered
+}
+
+// elementText consumes the current element and returns its character data, one part per
+// nested rdf:li item (rdf:Alt, rdf:Seq, rdf:Bag)
+func elementText(decoder *xml.Decoder) []string {
+	parts := make([]string, 0)
+	depth := 1
+	for depth > 0 {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return parts
+}
+
+// isWantedElement reports whether an element name matches one of the wanted properties
+func isWantedElement(name xml.Name, wanted []xmpProperty) bool {
+	for _, w := range wanted {
+		if w.Namespace == name.Space && w.Name == name.Local {
+			return true
+		}
+	}
+	return false
+}
+
+// xpacketTrailer matches the closing processing instruction of an XMP packet
+var xpacketTrailer = regexp.MustCompile(`<\?xpacket\s+end\s*=\s*["']([rw])["']\s*\?>`)
+
+// xpacketEnd returns the end attribute ('w' writable or 'r' read-only) of a packet,
+// defaulting to 'w' when the packet has no trailer
+func xpacketEnd(packet []byte) byte {
+	if m := xpacketTrailer.FindSubmatch(packet); m != nil {
+		return m[1][0]
+	}
+	return 'w'
+}
+
+// buildXMPPacket serializes properties into a minimal XMP packet with an xpacket wrapper.
+// padding bytes of whitespace are added before a writable trailer so XMP editors can update
+// the packet in place; read-only packets (end 'r') never get padding.
+func buildXMPPacket(properties []xmpProperty, padding int, end byte) []byte {
+	b := new(bytes.Buffer)
+	b.WriteString("<?xpacket begin=\"\xef\xbb\xbf\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"" + rdfNS + "\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"")
+
+	declared := make(map[string]bool)
+	for _, p := range properties {
+		if !declared[p.Prefix] {
+			declared[p.Prefix] = true
+			b.WriteString("\n    xmlns:" + p.Prefix + "=\"" + p.Namespace + "\"")
+		}
+	}
+	arrays := make([]xmpProperty, 0)
+	for _, p := range properties {
+		if p.Container != "" {
+			arrays = append(arrays, p)
+			continue
+		}
+		b.WriteString("\n    " + p.Prefix + ":" + p.Name + "=\"")
+		writeEscaped(b, p.Value)
+		b.WriteString("\"")
+	}
+
+	if len(arrays) == 0 {
+		b.WriteString("/>\n")
+	} else {
+		b.WriteString(">\n")
+		for _, p := range arrays {
+			writeXMPArray(b, p)
+		}
+		b.WriteString("  </rdf:Description>\n")
+	}
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	if end == 'w' {
+		writeXMPPadding(b, padding)
+	}
+	b.WriteString("<?xpacket end=\"" + string(end) + "\"?>")
+	return b.Bytes()
+}
+
+// writeXMPArray writes an array-valued property as a child element. An Alt holds the value
+// as its x-default item; a Seq gets one item per value extracted.
+func writeXMPArray(b *bytes.Buffer, p xmpProperty) {
+	items := []string{p.Value}
+	if p.Container == "Seq" && len(p.Items) > 0 {
+		items = p.Items
+	}
+	b.WriteString("   <" + p.Prefix + ":" + p.Name + ">\n")
+	b.WriteString("    <rdf:" + p.Container + ">\n")
+	for _, item := range items {
+		if p.Container == "Alt" {
+			b.WriteString("     <rdf:li xml:lang=\"x-default\">")
+		} else {
+			b.WriteString("     <rdf:li>")
+		}
+		writeEscaped(b, item)
+		b.WriteString("</rdf:li>\n")
+	}
+	b.WriteString("    </rdf:" + p.Container + ">\n")
+	b.WriteString("   </" + p.Prefix + ":" + p.Name + ">\n")
+}
+
+// writeXMPPadding writes n bytes of whitespace as lines of at most 100 bytes, as the XMP
+// specification recommends
+func writeXMPPadding(b *bytes.Buffer, n int) {
+	for n > 0 {
+		line := min(n, 100)
+		b.Write(bytes.Repeat([]byte{' '}, line-1))
+		b.WriteByte('\n')
+		n -= line
+	}
+}
+
+// writeEscaped writes s with XML special characters escaped
+func writeEscaped(w io.Writer, s string) {
+	_ = xml.EscapeText(w, []byte(s))
+}
+
+// filterXMPSegment rebuilds an XMP segment payload keeping only the wanted properties,
+// preserving the writable/read-only end attribute of the original packet.
+// It returns nil when none of them are present; tiff:Orientation alone does not
+// justify keeping a packet.
+func filterXMPSegment(data []byte, wanted []xmpProperty, padding int) []byte {
+	packet := bytes.TrimPrefix(data, []byte(XMPHeader))
+	properties := extractXMPProperties(packet, wanted)
+	if len(properties) == 0 || (len(properties) == 1 && properties[0].Name == orientationProperty.Name && properties[0].Namespace == orientationProperty.Namespace) {
+		return nil
+	}
+	return append([]byte(XMPHeader), buildXMPPacket(properties, padding, xpacketEnd(packet))...)
+}
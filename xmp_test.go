@@ -0,0 +1,240 @@
+package jpegmetawebstrip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractXMPProperties(t *testing.T) {
+	testCases := []struct {
+		name   string
+		packet string
+		rating string
+		label  string
+	}{
+		{
+			name: "Element form",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/">
+<xmp:Label>Red</xmp:Label><xmp:Rating>4</xmp:Rating><xmp:CreatorTool>Tool</xmp:CreatorTool>
+</rdf:Description></rdf:RDF></x:xmpmeta>`,
+			rating: "4",
+			label:  "Red",
+		},
+		{
+			name: "Attribute form with custom prefix",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:xap="http://ns.adobe.com/xap/1.0/" xap:Rating="2" xap:CreatorTool="Tool"/>
+</rdf:RDF></x:xmpmeta>`,
+			rating: "2",
+		},
+		{
+			name:   "No rating",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := map[string]string{}
+			for _, p := range extractXMPProperties([]byte(tc.packet), ratingProperties) {
+				values[p.Name] = p.Value
+			}
+			if values["Rating"] != tc.rating {
+				t.Errorf("Expected Rating %q, got %q", tc.rating, values["Rating"])
+			}
+			if values["Label"] != tc.label {
+				t.Errorf("Expected Label %q, got %q", tc.label, values["Label"])
+			}
+		})
+	}
+}
+
+func TestBuildXMPPacketRoundTrip(t *testing.T) {
+	properties := []xmpProperty{
+		{Namespace: xmpBasicNS, Prefix: "xmp", Name: "Rating", Value: "5"},
+		{Namespace: xmpBasicNS, Prefix: "xmp", Name: "Label", Value: `Review & "Approve"`},
+	}
+	packet := buildXMPPacket(properties, 0, 'w')
+
+	if !bytes.HasPrefix(packet, []byte("<?xpacket begin=")) || !bytes.HasSuffix(packet, []byte(`<?xpacket end="w"?>`)) {
+		t.Errorf("Expected xpacket wrapper, got %s", packet)
+	}
+
+	parsed := extractXMPProperties(packet, ratingProperties)
+	if len(parsed) != 2 || parsed[0].Value != "5" || parsed[1].Value != `Review & "Approve"` {
+		t.Errorf("Round trip mismatch: %+v", parsed)
+	}
+}
+
+func TestWithKeepXMPRating(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "with_xmp.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	cleanedData, result, err := Strip(jpegData, WithKeepXMPRating())
+	if err != nil {
+		t.Fatalf("Strip failed: %v", err)
+	}
+
+	var xmpPayloads [][]byte
+	for _, payload := range getSegmentPayloads(t, cleanedData, 0xE1) {
+		if bytes.HasPrefix(payload, []byte(XMPHeader)) {
+			xmpPayloads = append(xmpPayloads, payload)
+		}
+	}
+	if len(xmpPayloads) != 1 {
+		t.Fatalf("Expected one XMP segment, got %d", len(xmpPayloads))
+	}
+	packet := string(xmpPayloads[0])
+	for _, expected := range []string{`xmp:Rating="5"`, `xmp:Label="Test Label"`} {
+		if !strings.Contains(packet, expected) {
+			t.Errorf("Expected packet to contain %s", expected)
+		}
+	}
+	for _, removed := range []string{"CreatorTool", "Test Creator", "CreateDate"} {
+		if strings.Contains(packet, removed) {
+			t.Errorf("Expected %s to be removed from the packet", removed)
+		}
+	}
+	if result.Removed.XMP <= 0 {
+		t.Error("Expected XMP savings to be reported")
+	}
+	assertPixelsUnchanged(t, jpegData, cleanedData)
+}
+
+func TestWithKeepXMPRatingCompactPacket(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	// A packet smaller than any rebuilt one, with GPS next to the kept rating
+	xmp := XMPHeader + `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><rdf:Description ` +
+		`xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:exif="http://ns.adobe.com/exif/1.0/" ` +
+		`xmp:Rating="4" exif:GPSLatitude="35,40.5N"/></rdf:RDF>`
+	jpegData = insertSegment(jpegData, 0xE1, []byte(xmp))
+
+	for _, opts := range [][]Option{{WithKeepXMPRating()}, {WithKeepXMPRating(), WithXMPPadding(2048)}} {
+		cleaned, _, err := Strip(jpegData, opts...)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		payloads := getSegmentPayloads(t, cleaned, 0xE1)
+		if len(payloads) != 1 || !bytes.Contains(payloads[0], []byte(`xmp:Rating="4"`)) {
+			t.Fatalf("Expected a rebuilt packet with the rating, got %q", payloads)
+		}
+		if bytes.Contains(payloads[0], []byte("GPSLatitude")) {
+			t.Error("Expected GPS to be removed even though the rebuilt packet is larger")
+		}
+	}
+}
+
+func TestXMPPacketWrapper(t *testing.T) {
+	properties := []xmpProperty{{Namespace: xmpBasicNS, Prefix: "xmp", Name: "Rating", Value: "3"}}
+
+	tests := []struct {
+		name    string
+		padding int
+		end     byte
+		trailer string
+		spaces  int
+	}{
+		{"writable without padding", 0, 'w', `<?xpacket end="w"?>`, 0},
+		{"writable with padding", 250, 'w', `<?xpacket end="w"?>`, 250},
+		{"read-only ignores padding", 250, 'r', `<?xpacket end="r"?>`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := buildXMPPacket(properties, tt.padding, tt.end)
+			if !bytes.HasSuffix(packet, []byte(tt.trailer)) {
+				t.Fatalf("Expected trailer %s, got %s", tt.trailer, packet)
+			}
+			if xpacketEnd(packet) != tt.end {
+				t.Errorf("Expected end %q to round trip", tt.end)
+			}
+
+			body := bytes.TrimSuffix(packet, []byte(tt.trailer))
+			meta := bytes.LastIndex(body, []byte("</x:xmpmeta>\n"))
+			padding := body[meta+len("</x:xmpmeta>\n"):]
+			if len(padding) != tt.spaces || len(bytes.TrimSpace(padding)) != 0 {
+				t.Errorf("Expected %d bytes of whitespace padding, got %q", tt.spaces, padding)
+			}
+			for _, line := range bytes.Split(padding, []byte("\n")) {
+				if len(line) > 99 {
+					t.Errorf("Expected padding lines of at most 100 bytes, got %d", len(line)+1)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterXMPSegmentPreservesReadOnly(t *testing.T) {
+	packet := XMPHeader + `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>` +
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="4"/></rdf:RDF></x:xmpmeta>` +
+		`<?xpacket end='r'?>`
+
+	filtered := filterXMPSegment([]byte(packet), ratingProperties, 100)
+	if !bytes.HasSuffix(filtered, []byte(`<?xpacket end="r"?>`)) {
+		t.Errorf("Expected read-only trailer to be preserved, got %s", filtered)
+	}
+}
+
+// extendedXMPChunk builds an Extended XMP APP1 payload holding part of a packet
+func extendedXMPChunk(guid string, fullLength, offset uint32, part string) []byte {
+	chunk := append([]byte(ExtendedXMPHeader), guid...)
+	chunk = binary.BigEndian.AppendUint32(chunk, fullLength)
+	chunk = binary.BigEndian.AppendUint32(chunk, offset)
+	return append(chunk, part...)
+}
+
+func TestStripExtendedXMP(t *testing.T) {
+	jpegData, err := os.ReadFile(filepath.Join("testdata", "basic_copy.jpg"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	guid := "0123456789ABCDEF0123456789ABCDEF"
+	main := XMPHeader + `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:xmpNote="http://ns.adobe.com/xmp/note/" xmpNote:HasExtendedXMP="` + guid + `"/></rdf:RDF></x:xmpmeta>`
+	extended := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description xmlns:photoshop="http://ns.adobe.com/photoshop/1.0/" photoshop:History="` + strings.Repeat("edit ", 40) + `"/></rdf:RDF></x:xmpmeta>`
+	half := len(extended) / 2
+	first := extendedXMPChunk(guid, uint32(len(extended)), 0, extended[:half])
+	second := extendedXMPChunk(guid, uint32(len(extended)), uint32(half), extended[half:])
+
+	withXMP := insertSegment(jpegData, 0xE1, second)
+	withXMP = insertSegment(withXMP, 0xE1, first)
+	withXMP = insertSegment(withXMP, 0xE1, []byte(main))
+	total := int64(len(main) + len(first) + len(second))
+
+	for _, opts := range [][]Option{nil, {WithWhitelistMode()}, {WithKeepXMPRating()}} {
+		cleaned, result, err := Strip(withXMP, opts...)
+		if err != nil {
+			t.Fatalf("Strip failed: %v", err)
+		}
+		assertPixelsUnchanged(t, jpegData, cleaned)
+		for _, payload := range getSegmentPayloads(t, cleaned, 0xE1) {
+			if bytes.HasPrefix(payload, []byte(ExtendedXMPHeader)) || bytes.HasPrefix(payload, []byte(XMPHeader)) {
+				t.Errorf("Expected every XMP segment to be removed, found %.40q", payload)
+			}
+		}
+		if result.Removed.XMP != total || result.Removed.Other != 0 {
+			t.Errorf("Expected %d XMP bytes and nothing under Other, got %+v", total, result.Removed)
+		}
+	}
+
+	// Privacy mode keeps chunks that carry nothing identifying
+	cleaned, _, err := StripWithPreset(withXMP, PresetPrivacy)
+	if err != nil {
+		t.Fatalf("StripWithPreset failed: %v", err)
+	}
+	if len(getSegmentPayloads(t, cleaned, 0xE1)) != 3 {
+		t.Error("Expected the privacy preset to keep XMP without identifying properties")
+	}
+}